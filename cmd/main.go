@@ -3,18 +3,38 @@ package main
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"log"
 	"log/slog"
+	nethttp "net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/generative-ai-go/genai"
+	"github.com/octokerbs/chronocode/internal/adapters/anthropic"
+	"github.com/octokerbs/chronocode/internal/adapters/cache"
+	"github.com/octokerbs/chronocode/internal/adapters/chunking"
+	"github.com/octokerbs/chronocode/internal/adapters/fallback"
 	"github.com/octokerbs/chronocode/internal/adapters/gemini"
+	"github.com/octokerbs/chronocode/internal/adapters/gitea"
 	github2 "github.com/octokerbs/chronocode/internal/adapters/github"
+	"github.com/octokerbs/chronocode/internal/adapters/gitlab"
+	"github.com/octokerbs/chronocode/internal/adapters/heuristic"
+	"github.com/octokerbs/chronocode/internal/adapters/llm"
+	"github.com/octokerbs/chronocode/internal/adapters/localgit"
 	"github.com/octokerbs/chronocode/internal/adapters/memory"
+	"github.com/octokerbs/chronocode/internal/adapters/multihost"
+	"github.com/octokerbs/chronocode/internal/adapters/ollama"
+	"github.com/octokerbs/chronocode/internal/adapters/openai"
 	"github.com/octokerbs/chronocode/internal/adapters/postgres"
+	"github.com/octokerbs/chronocode/internal/adapters/pricing"
+	"github.com/octokerbs/chronocode/internal/adapters/retry"
 	"github.com/octokerbs/chronocode/internal/application"
 	"github.com/octokerbs/chronocode/internal/application/command"
 	"github.com/octokerbs/chronocode/internal/application/query"
+	"github.com/octokerbs/chronocode/internal/domain/agent"
 
 	"github.com/octokerbs/chronocode/internal/ports/http"
 	"golang.org/x/oauth2"
@@ -25,20 +45,35 @@ import (
 func NewApplication(ctx context.Context) application.Application {
 	slog.Info("Initializing application dependencies")
 
-	slog.Info("Connecting to Gemini AI")
-	geminiClient, err := genai.NewClient(ctx, option.WithAPIKey(os.Getenv("GEMINI_API_KEY")))
+	for _, prefix := range retryPrefixes {
+		if err := retryPolicy(prefix).Validate(); err != nil {
+			slog.Error("Invalid retry policy", "prefix", prefix, "error", err)
+			panic(err)
+		}
+	}
+
+	prompts, err := llm.LoadPrompts(os.Getenv("PROMPT_TEMPLATE_DIR"))
 	if err != nil {
-		slog.Error("Failed to create Gemini client", "error", err)
+		slog.Error("Failed to load prompt templates", "error", err)
 		panic(err)
 	}
-	slog.Info("Gemini AI client connected")
+	slog.Info("Prompt templates loaded", "version", prompts.Version(), "dir", os.Getenv("PROMPT_TEMPLATE_DIR"))
 
-	agent, err := gemini.NewAgent(geminiClient, os.Getenv("GEMINI_GENERATIVE_MODEL"))
+	providerAgent, err := newAgent(ctx, os.Getenv("AGENT_PROVIDER"), prompts)
 	if err != nil {
-		slog.Error("Failed to create Gemini agent", "error", err)
+		slog.Error("Failed to create agent", "error", err)
 		panic(err)
 	}
 
+	limits := chunking.DefaultLimits
+	if value, err := strconv.Atoi(os.Getenv("AGENT_MAX_CHUNK_TOKENS")); err == nil {
+		limits.MaxChunkTokens = value
+	}
+	if value, err := strconv.Atoi(os.Getenv("AGENT_SUMMARIZE_FILE_TOKENS")); err == nil {
+		limits.SummarizeFileTokens = value
+	}
+	chunkingAgent := chunking.NewAgent(providerAgent, limits)
+
 	slog.Info("Connecting to PostgreSQL")
 	postgresClient, err := sql.Open("postgres", os.Getenv("DATABASE_URL"))
 	if err != nil {
@@ -52,6 +87,12 @@ func NewApplication(ctx context.Context) application.Application {
 	}
 	slog.Info("PostgreSQL connected successfully")
 
+	agent, err := newCachingAgent(chunkingAgent, os.Getenv("AGENT_CACHE"), postgresClient, prompts)
+	if err != nil {
+		slog.Error("Failed to create analysis cache", "error", err)
+		panic(err)
+	}
+
 	repoRepository, err := postgres.NewRepoRepository(postgresClient)
 	if err != nil {
 		slog.Error("Failed to create repo repository", "error", err)
@@ -64,25 +105,320 @@ func NewApplication(ctx context.Context) application.Application {
 		panic(err)
 	}
 
-	codeHostFactory := github2.NewGithubCodeHostFactory()
+	analysisRunRepository, err := postgres.NewAnalysisRunRepository(postgresClient)
+	if err != nil {
+		slog.Error("Failed to create analysis run repository", "error", err)
+		panic(err)
+	}
+
+	commitStateRepository, err := postgres.NewCommitStateRepository(postgresClient)
+	if err != nil {
+		slog.Error("Failed to create commit state repository", "error", err)
+		panic(err)
+	}
+
+	codeHostFactory := multihost.NewCodeHostFactory(github2.PublicHost, github2.NewGithubCodeHostFactory(retryPolicy("GITHUB").Wrap(nil)))
+
+	// Users sign in with the enterprise server, so it also answers profile and search calls.
+	// github.com repositories are then only reached with the caller's own github.com
+	// token, the enterprise one is never sent there.
+	if enterpriseHost := os.Getenv("GITHUB_ENTERPRISE_HOST"); enterpriseHost != "" {
+		enterpriseFactory, err := github2.NewGithubEnterpriseCodeHostFactory(enterpriseHost, os.Getenv("GITHUB_ENTERPRISE_API_URL"), os.Getenv("GITHUB_ENTERPRISE_UPLOAD_URL"), retryPolicy("GITHUB").Wrap(nil))
+		if err != nil {
+			slog.Error("Failed to create GitHub Enterprise code host factory", "error", err)
+			panic(err)
+		}
+		codeHostFactory = multihost.NewCodeHostFactory(enterpriseFactory.Host(), enterpriseFactory)
+		codeHostFactory.RegisterWithHostToken(github2.PublicHost, github2.NewGithubCodeHostFactory(retryPolicy("GITHUB").Wrap(nil)))
+		slog.Info("GitHub Enterprise code host configured", "host", enterpriseFactory.Host())
+	}
+
+	if gitlabBaseURL := os.Getenv("GITLAB_BASE_URL"); gitlabBaseURL != "" {
+		gitlabFactory, err := gitlab.NewCodeHostFactory(gitlabBaseURL, os.Getenv("GITLAB_TOKEN"), strings.Split(os.Getenv("GITLAB_PROJECTS"), ","), retryPolicy("GITLAB").Wrap(&nethttp.Client{Timeout: time.Minute}))
+		if err != nil {
+			slog.Error("Failed to create GitLab code host factory", "error", err)
+			panic(err)
+		}
+		codeHostFactory.RegisterWithHostToken(gitlabFactory.Host(), gitlabFactory)
+		slog.Info("GitLab code host configured", "host", gitlabFactory.Host())
+	}
+
+	if giteaBaseURL := os.Getenv("GITEA_BASE_URL"); giteaBaseURL != "" {
+		giteaFactory, err := gitea.NewCodeHostFactory(giteaBaseURL, os.Getenv("GITEA_TOKEN"), strings.Split(os.Getenv("GITEA_REPOS"), ","), retryPolicy("GITEA").Wrap(&nethttp.Client{Timeout: time.Minute}))
+		if err != nil {
+			slog.Error("Failed to create Gitea code host factory", "error", err)
+			panic(err)
+		}
+		codeHostFactory.RegisterWithHostToken(giteaFactory.Host(), giteaFactory)
+		slog.Info("Gitea code host configured", "host", giteaFactory.Host())
+	}
+
+	if localGitRoot := os.Getenv("LOCALGIT_ROOT"); localGitRoot != "" {
+		localGitFactory, err := localgit.NewCodeHostFactory(localGitRoot)
+		if err != nil {
+			slog.Error("Failed to create local git code host factory", "error", err)
+			panic(err)
+		}
+		codeHostFactory.Register(multihost.FileScheme, localGitFactory)
+		slog.Info("Local git code host configured", "root", localGitRoot)
+	}
+
+	globalBudget, err := analysisBudget()
+	if err != nil {
+		slog.Error("Invalid global analysis budget", "error", err)
+		panic(err)
+	}
+
 	locker := memory.NewInMemoryLocker()
+	progressBus := memory.NewInMemoryProgressBus()
+	cancelRegistry := memory.NewInMemoryCancelRegistry()
 
 	slog.Info("All dependencies initialized successfully")
 
 	return application.Application{
 		Commands: application.Commands{
-			AnalyzeRepo: command.NewAnalyzeRepoHandler(repoRepository, subcommitRepository, agent, codeHostFactory, locker),
+			AnalyzeRepo:          command.NewAnalyzeRepoHandler(repoRepository, subcommitRepository, analysisRunRepository, commitStateRepository, agent, codeHostFactory, locker, progressBus, cancelRegistry, globalBudget),
+			CancelAnalysis:       command.NewCancelAnalysisHandler(repoRepository, codeHostFactory, cancelRegistry),
+			RetryFailedCommits:   command.NewRetryFailedCommitsHandler(repoRepository, subcommitRepository, analysisRunRepository, commitStateRepository, agent, codeHostFactory, locker, progressBus, cancelRegistry, globalBudget),
+			SetIgnorePatterns:    command.NewSetIgnorePatternsHandler(repoRepository, codeHostFactory),
+			SetBudget:            command.NewSetBudgetHandler(repoRepository, codeHostFactory),
+			SetPromptOverrides:   command.NewSetPromptOverridesHandler(repoRepository, codeHostFactory),
+			SetModificationTypes: command.NewSetModificationTypesHandler(repoRepository, codeHostFactory),
+			SetIssueTrackers:     command.NewSetIssueTrackersHandler(repoRepository, codeHostFactory),
 		},
 		Queries: application.Queries{
-			GetSubcommits:   query.NewGetSubcommitsHandler(repoRepository, subcommitRepository, codeHostFactory),
-			GetRepos:        query.NewGetReposHandler(repoRepository),
-			GetUserProfile:  query.NewGetUserProfileHandler(codeHostFactory),
-			SearchUserRepos: query.NewSearchUserReposHandler(codeHostFactory),
+			GetSubcommits:          query.NewGetSubcommitsHandler(repoRepository, subcommitRepository, codeHostFactory),
+			GetRepos:               query.NewGetReposHandler(repoRepository),
+			GetUserProfile:         query.NewGetUserProfileHandler(codeHostFactory),
+			SearchUserRepos:        query.NewSearchUserReposHandler(codeHostFactory),
+			GetAnalysisRuns:        query.NewGetAnalysisRunsHandler(repoRepository, analysisRunRepository, codeHostFactory),
+			GetAnalysisRun:         query.NewGetAnalysisRunHandler(repoRepository, analysisRunRepository, codeHostFactory),
+			WatchAnalysisProgress:  query.NewWatchAnalysisProgressHandler(repoRepository, progressBus, codeHostFactory),
+			GetIgnorePatterns:      query.NewGetIgnorePatternsHandler(repoRepository, codeHostFactory),
+			GetUsage:               query.NewGetUsageHandler(repoRepository, commitStateRepository, codeHostFactory),
+			GetPromptOverrides:     query.NewGetPromptOverridesHandler(repoRepository, codeHostFactory),
+			GetModificationTypes:   query.NewGetModificationTypesHandler(repoRepository, codeHostFactory),
+			GetPullRequestTimeline: query.NewGetPullRequestTimelineHandler(repoRepository, subcommitRepository, codeHostFactory),
+			GetIssueTrackers:       query.NewGetIssueTrackersHandler(repoRepository, codeHostFactory),
+			GetIssueSubcommits:     query.NewGetIssueSubcommitsHandler(repoRepository, subcommitRepository, codeHostFactory),
 		},
 		Locker: locker,
 	}
 }
 
+// newAgent
+// AGENT_PROVIDER lists one provider, or several separated by commas to fall back from
+// one to the next behind a circuit breaker
+func newAgent(ctx context.Context, providers string, prompts *llm.Prompts) (agent.Agent, error) {
+	names := strings.Split(providers, ",")
+	if len(names) == 1 {
+		return newPricedProviderAgent(ctx, strings.TrimSpace(names[0]), prompts)
+	}
+
+	members := make([]fallback.Member, len(names))
+	for i, name := range names {
+		name = strings.TrimSpace(name)
+		providerAgent, err := newPricedProviderAgent(ctx, name, prompts)
+		if err != nil {
+			return nil, fmt.Errorf("%s agent: %w", name, err)
+		}
+		members[i] = fallback.Member{Name: name, Agent: providerAgent}
+	}
+
+	threshold := 5
+	if value, err := strconv.Atoi(os.Getenv("AGENT_BREAKER_THRESHOLD")); err == nil {
+		threshold = value
+	}
+
+	cooldown := time.Minute
+	if value, err := time.ParseDuration(os.Getenv("AGENT_BREAKER_COOLDOWN")); err == nil {
+		cooldown = value
+	}
+
+	slog.Info("Agent fallback chain configured", "agents", names, "breaker_threshold", threshold, "breaker_cooldown", cooldown)
+	return fallback.NewAgent(threshold, cooldown, members...)
+}
+
+// newCachingAgent
+// AGENT_CACHE picks where analyses are cached: "postgres" (default), "memory" or "off"
+func newCachingAgent(a agent.Agent, backend string, db *sql.DB, prompts *llm.Prompts) (agent.Agent, error) {
+	var analysisCache agent.AnalysisCache
+	switch backend {
+	case "", "postgres":
+		postgresCache, err := postgres.NewAnalysisCache(db)
+		if err != nil {
+			return nil, err
+		}
+		analysisCache = postgresCache
+	case "memory":
+		analysisCache = memory.NewAnalysisCache()
+	case "off":
+		slog.Info("Analysis cache disabled")
+		return a, nil
+	default:
+		return nil, fmt.Errorf("unknown AGENT_CACHE %q", backend)
+	}
+
+	namespace := "prompt=" + prompts.Version() + ";agents=" + agentModels(os.Getenv("AGENT_PROVIDER"))
+	return cache.NewAgent(a, analysisCache, namespace), nil
+}
+
+// agentModels
+// The providers of AGENT_PROVIDER with the model each one is configured with
+func agentModels(providers string) string {
+	names := strings.Split(providers, ",")
+	models := make([]string, len(names))
+	for i, name := range names {
+		name = strings.TrimSpace(name)
+		switch name {
+		case "", "gemini":
+			models[i] = "gemini:" + os.Getenv("GEMINI_GENERATIVE_MODEL")
+		case "openai":
+			models[i] = "openai:" + os.Getenv("OPENAI_BASE_URL") + ":" + os.Getenv("OPENAI_MODEL")
+		case "ollama":
+			models[i] = "ollama:" + os.Getenv("OLLAMA_MODEL")
+		case "anthropic":
+			models[i] = "anthropic:" + os.Getenv("ANTHROPIC_MODEL")
+		default:
+			models[i] = name
+		}
+	}
+	return strings.Join(models, ",")
+}
+
+// newPricedProviderAgent
+// Prices the tokens of a provider at <PREFIX>_PROMPT_PRICE_PER_MTOK and
+// <PREFIX>_RESPONSE_PRICE_PER_MTOK USD per million, when either is set
+func newPricedProviderAgent(ctx context.Context, provider string, prompts *llm.Prompts) (agent.Agent, error) {
+	providerAgent, err := newProviderAgent(ctx, provider, prompts)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := strings.ToUpper(provider)
+	if prefix == "" {
+		prefix = "GEMINI"
+	}
+
+	var price agent.Price
+	promptPrice, promptErr := strconv.ParseFloat(os.Getenv(prefix+"_PROMPT_PRICE_PER_MTOK"), 64)
+	responsePrice, responseErr := strconv.ParseFloat(os.Getenv(prefix+"_RESPONSE_PRICE_PER_MTOK"), 64)
+	if promptErr != nil && responseErr != nil {
+		return providerAgent, nil
+	}
+	if promptErr == nil {
+		price.PromptPerMillion = promptPrice
+	}
+	if responseErr == nil {
+		price.ResponsePerMillion = responsePrice
+	}
+
+	slog.Info("Agent pricing configured", "provider", prefix, "prompt_per_mtok", price.PromptPerMillion, "response_per_mtok", price.ResponsePerMillion)
+	return pricing.NewAgent(providerAgent, price), nil
+}
+
+// newProviderAgent
+// Builds the agent.Agent for a single provider, Gemini unless told otherwise
+func newProviderAgent(ctx context.Context, provider string, prompts *llm.Prompts) (agent.Agent, error) {
+	switch provider {
+	case "", "gemini":
+		slog.Info("Connecting to Gemini AI")
+		geminiClient, err := genai.NewClient(ctx, option.WithAPIKey(os.Getenv("GEMINI_API_KEY")))
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("Gemini AI client connected")
+
+		geminiAgent, err := gemini.NewAgent(geminiClient, os.Getenv("GEMINI_GENERATIVE_MODEL"), prompts)
+		if err != nil {
+			return nil, err
+		}
+		return retry.NewAgent(geminiAgent, retryPolicy("GEMINI"), gemini.Retryable), nil
+	case "openai":
+		return openai.NewAgent(openai.Config{
+			BaseURL:    os.Getenv("OPENAI_BASE_URL"),
+			APIKey:     os.Getenv("OPENAI_API_KEY"),
+			Model:      os.Getenv("OPENAI_MODEL"),
+			APIVersion: os.Getenv("OPENAI_API_VERSION"),
+			Retry:      retryPolicy("OPENAI"),
+			Prompts:    prompts,
+		})
+	case "ollama":
+		numCtx, _ := strconv.Atoi(os.Getenv("OLLAMA_NUM_CTX"))
+		return ollama.NewAgent(ollama.Config{
+			BaseURL: os.Getenv("OLLAMA_BASE_URL"),
+			Model:   os.Getenv("OLLAMA_MODEL"),
+			NumCtx:  numCtx,
+			Retry:   retryPolicy("OLLAMA"),
+			Prompts: prompts,
+		})
+	case "anthropic":
+		maxTokens, _ := strconv.Atoi(os.Getenv("ANTHROPIC_MAX_TOKENS"))
+		return anthropic.NewAgent(anthropic.Config{
+			BaseURL:   os.Getenv("ANTHROPIC_BASE_URL"),
+			APIKey:    os.Getenv("ANTHROPIC_API_KEY"),
+			Model:     os.Getenv("ANTHROPIC_MODEL"),
+			MaxTokens: maxTokens,
+			Retry:     retryPolicy("ANTHROPIC"),
+			Prompts:   prompts,
+		})
+	case "heuristic":
+		return heuristic.NewAgent(), nil
+	default:
+		return nil, fmt.Errorf("unknown AGENT_PROVIDER %q", provider)
+	}
+}
+
+// analysisBudget
+// AGENT_BUDGET_TOKENS and AGENT_BUDGET_USD cap the usage of every repository together,
+// unset means unlimited
+func analysisBudget() (agent.Budget, error) {
+	var budget agent.Budget
+	if value, err := strconv.ParseInt(os.Getenv("AGENT_BUDGET_TOKENS"), 10, 64); err == nil {
+		budget.MaxTokens = value
+	}
+	if value, err := strconv.ParseFloat(os.Getenv("AGENT_BUDGET_USD"), 64); err == nil {
+		budget.MaxCostUSD = value
+	}
+	return budget, budget.Validate()
+}
+
+// retryPrefixes
+// The adapters whose retries are configured with <PREFIX>_RETRY_* variables
+var retryPrefixes = []string{"GITHUB", "GITLAB", "GITEA", "GEMINI", "OPENAI", "OLLAMA", "ANTHROPIC"}
+
+// retryPolicy
+// retry.DefaultPolicy, overridden by <PREFIX>_RETRY_MAX_ATTEMPTS, <PREFIX>_RETRY_BASE_DELAY
+// and <PREFIX>_RETRY_MAX_DELAY. The max delay is raised to the base delay when below it.
+func retryPolicy(prefix string) retry.Policy {
+	policy := retry.DefaultPolicy
+	if value, err := strconv.Atoi(os.Getenv(prefix + "_RETRY_MAX_ATTEMPTS")); err == nil {
+		policy.MaxAttempts = value
+	}
+	if value, err := time.ParseDuration(os.Getenv(prefix + "_RETRY_BASE_DELAY")); err == nil {
+		policy.BaseDelay = value
+	}
+	if value, err := time.ParseDuration(os.Getenv(prefix + "_RETRY_MAX_DELAY")); err == nil {
+		policy.MaxDelay = value
+	}
+	policy.MaxDelay = max(policy.MaxDelay, policy.BaseDelay)
+	return policy
+}
+
+// oauthEndpoint
+// GitHub Enterprise Server exposes the same OAuth endpoints as github.com on its own host
+func oauthEndpoint() oauth2.Endpoint {
+	enterpriseHost := os.Getenv("GITHUB_ENTERPRISE_HOST")
+	if enterpriseHost == "" {
+		return github.Endpoint
+	}
+
+	return oauth2.Endpoint{
+		AuthURL:  "https://" + enterpriseHost + "/login/oauth/authorize",
+		TokenURL: "https://" + enterpriseHost + "/login/oauth/access_token",
+	}
+}
+
 func main() {
 	logLevel := slog.LevelInfo
 	if os.Getenv("LOG_LEVEL") == "debug" {
@@ -100,7 +436,7 @@ func main() {
 		ClientSecret: os.Getenv("GITHUB_CLIENT_SECRET"),
 		RedirectURL:  os.Getenv("GITHUB_REDIRECT_URL"),
 		Scopes:       []string{"read:user", "user:email", "repo"},
-		Endpoint:     github.Endpoint,
+		Endpoint:     oauthEndpoint(),
 	}
 
 	slog.Info("GitHub OAuth configured",
@@ -0,0 +1,220 @@
+package anthropic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/octokerbs/chronocode/internal/adapters/llm"
+	"github.com/octokerbs/chronocode/internal/adapters/retry"
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+)
+
+const (
+	DefaultBaseURL   = "https://api.anthropic.com"
+	DefaultMaxTokens = 8192
+
+	apiVersion = "2023-06-01"
+	toolName   = "record_subcommits"
+)
+
+type Config struct {
+	// BaseURL of the API, DefaultBaseURL when empty. Point it at a proxy that speaks
+	// the Messages API to keep traffic inside the network
+	BaseURL string
+	APIKey  string
+	Model   string
+	// MaxTokens caps the answer, DefaultMaxTokens when zero
+	MaxTokens  int
+	HTTPClient *http.Client
+	// Retry applies to rate-limited and unavailable responses, the zero value never retries
+	Retry retry.Policy
+	// Prompts renders the analysis prompt, llm.DefaultPrompts when nil
+	Prompts *llm.Prompts
+}
+
+type Agent struct {
+	endpoint   string
+	config     Config
+	httpClient *http.Client
+}
+
+func NewAgent(config Config) (*Agent, error) {
+	if config.BaseURL == "" {
+		config.BaseURL = DefaultBaseURL
+	}
+
+	parsed, err := url.Parse(strings.TrimSuffix(config.BaseURL, "/"))
+	if err != nil || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid Anthropic base URL %q", config.BaseURL)
+	}
+
+	if config.Model == "" {
+		return nil, errors.New("missing model")
+	}
+
+	if config.MaxTokens <= 0 {
+		config.MaxTokens = DefaultMaxTokens
+	}
+
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 5 * time.Minute}
+	}
+
+	httpClient = config.Retry.Wrap(httpClient)
+
+	if config.Prompts == nil {
+		config.Prompts = llm.DefaultPrompts
+	}
+
+	slog.Info("Anthropic agent initialized", "base_url", parsed.String(), "model", config.Model, "max_tokens", config.MaxTokens)
+	return &Agent{endpoint: parsed.String() + "/v1/messages", config: config, httpClient: httpClient}, nil
+}
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type tool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"input_schema"`
+}
+
+type toolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+type messagesRequest struct {
+	Model      string     `json:"model"`
+	MaxTokens  int        `json:"max_tokens"`
+	Messages   []message  `json:"messages"`
+	Tools      []tool     `json:"tools"`
+	ToolChoice toolChoice `json:"tool_choice"`
+}
+
+type contentBlock struct {
+	Type  string          `json:"type"`
+	Name  string          `json:"name"`
+	Input json.RawMessage `json:"input"`
+}
+
+type messagesResponse struct {
+	Content    []contentBlock `json:"content"`
+	StopReason string         `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int64 `json:"input_tokens"`
+		OutputTokens int64 `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+type errorResponse struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (a *Agent) AnalyzeCommit(ctx context.Context, input agent.AnalysisInput) ([]agent.AnalysisResult, error) {
+	slog.Debug("Anthropic agent analyzing diff", "diff_length", len(input.Diff))
+
+	prompt, err := a.config.Prompts.Render(agent.PromptOverridesFrom(ctx), input)
+	if err != nil {
+		return nil, err
+	}
+
+	toolInput, err := a.recordSubcommits(ctx, prompt.Text+input.Diff, prompt.JSONSchema())
+	if err != nil {
+		slog.Error("Anthropic messages request failed", "error", err, "diff_length", len(input.Diff))
+		return nil, err
+	}
+
+	results, err := prompt.ParseAnalysis(toolInput)
+	if err != nil {
+		slog.Error("Failed to unmarshal Anthropic tool input", "error", err, "input_length", len(toolInput))
+		return nil, err
+	}
+
+	slog.Debug("Anthropic analysis completed", "subcommits_produced", len(results), "diff_length", len(input.Diff))
+	return results, nil
+}
+
+// recordSubcommits
+// Forces the model to call the record_subcommits tool, whose input schema is the
+// analysis answer, and returns the tool input
+func (a *Agent) recordSubcommits(ctx context.Context, prompt string, schema map[string]any) ([]byte, error) {
+	body, err := json.Marshal(messagesRequest{
+		Model:     a.config.Model,
+		MaxTokens: a.config.MaxTokens,
+		Messages:  []message{{Role: "user", Content: prompt}},
+		Tools: []tool{{
+			Name:        toolName,
+			Description: "Record the logical units of work found in the commit diff.",
+			InputSchema: schema,
+		}},
+		ToolChoice: toolChoice{Type: "tool", Name: toolName},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("anthropic-version", apiVersion)
+	if a.config.APIKey != "" {
+		req.Header.Set("x-api-key", a.config.APIKey)
+	}
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("anthropic responded %d: %s", resp.StatusCode, apiErrorMessage(resp.Body))
+	}
+
+	var response messagesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("decoding anthropic response: %w", err)
+	}
+
+	agent.RecordUsage(ctx, agent.Usage{PromptTokens: response.Usage.InputTokens, ResponseTokens: response.Usage.OutputTokens})
+
+	if response.StopReason == "max_tokens" {
+		return nil, errors.New("anthropic response was truncated by max tokens")
+	}
+
+	for _, block := range response.Content {
+		if block.Type == "tool_use" && block.Name == toolName {
+			slog.Debug("Anthropic tool input received", "input_length", len(block.Input))
+			return block.Input, nil
+		}
+	}
+	return nil, fmt.Errorf("anthropic response did not call %s", toolName)
+}
+
+func apiErrorMessage(body io.Reader) string {
+	raw, _ := io.ReadAll(io.LimitReader(body, 4096))
+
+	var parsed errorResponse
+	if err := json.Unmarshal(raw, &parsed); err == nil && parsed.Error.Message != "" {
+		return parsed.Error.Type + ": " + parsed.Error.Message
+	}
+	return strings.TrimSpace(string(raw))
+}
@@ -0,0 +1,146 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/octokerbs/chronocode/internal/adapters/llm"
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+const toolInput = `{"subcommits":[{"title":"Add main entrypoint","idea":"Bootstrap the binary","description":"Adds an empty main function","epic":"Setup","type":"FEATURE","files":["main.go"]}]}`
+
+type AnthropicAgentTestSuite struct {
+	suite.Suite
+	server   *httptest.Server
+	requests []*http.Request
+	bodies   []messagesRequest
+	respond  func(w http.ResponseWriter)
+}
+
+func TestAnthropicAgentTestSuite(t *testing.T) {
+	suite.Run(t, new(AnthropicAgentTestSuite))
+}
+
+func (s *AnthropicAgentTestSuite) SetupTest() {
+	s.requests = nil
+	s.bodies = nil
+	s.respondWith([]map[string]any{
+		{"type": "text", "text": "Recording the subcommits."},
+		{"type": "tool_use", "id": "toolu_01", "name": toolName, "input": json.RawMessage(toolInput)},
+	}, "tool_use")
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body messagesRequest
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		s.requests = append(s.requests, r)
+		s.bodies = append(s.bodies, body)
+		s.respond(w)
+	}))
+	s.T().Cleanup(s.server.Close)
+}
+
+func (s *AnthropicAgentTestSuite) respondWith(content []map[string]any, stopReason string) {
+	s.respond = func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id": "msg_01", "type": "message", "role": "assistant",
+			"content": content, "stop_reason": stopReason,
+			"usage": map[string]any{"input_tokens": 2048, "output_tokens": 120},
+		})
+	}
+}
+
+func (s *AnthropicAgentTestSuite) analyze(config Config) ([]agent.AnalysisResult, error) {
+	config.BaseURL = s.server.URL
+	config.HTTPClient = s.server.Client()
+	if config.Model == "" {
+		config.Model = "claude-sonnet-4-5"
+	}
+
+	a, err := NewAgent(config)
+	require.NoError(s.T(), err)
+	return a.AnalyzeCommit(context.Background(), agent.AnalysisInput{Diff: "+func main() {}"})
+}
+
+func (s *AnthropicAgentTestSuite) TestRequiresModel() {
+	_, err := NewAgent(Config{APIKey: "key"})
+	assert.NotNil(s.T(), err)
+}
+
+func (s *AnthropicAgentTestSuite) TestDefaults() {
+	a, err := NewAgent(Config{Model: "claude-sonnet-4-5"})
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), DefaultBaseURL+"/v1/messages", a.endpoint)
+	assert.Equal(s.T(), DefaultMaxTokens, a.config.MaxTokens)
+}
+
+func (s *AnthropicAgentTestSuite) TestAnalyzeCommitReturnsToolInput() {
+	results, err := s.analyze(Config{})
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), []agent.AnalysisResult{{
+		Title: "Add main entrypoint", Idea: "Bootstrap the binary", Description: "Adds an empty main function",
+		Epic: "Setup", ModificationType: "FEATURE", Files: []string{"main.go"}, PromptVersion: llm.DefaultPrompts.Version(),
+	}}, results)
+}
+
+func (s *AnthropicAgentTestSuite) TestForcesToolUseWithConfiguredModel() {
+	_, _ = s.analyze(Config{APIKey: "sk-ant", Model: "claude-haiku-4-5", MaxTokens: 2048})
+
+	require.Len(s.T(), s.requests, 1)
+	req := s.requests[0]
+	assert.Equal(s.T(), "/v1/messages", req.URL.Path)
+	assert.Equal(s.T(), "sk-ant", req.Header.Get("x-api-key"))
+	assert.Equal(s.T(), apiVersion, req.Header.Get("anthropic-version"))
+
+	body := s.bodies[0]
+	assert.Equal(s.T(), "claude-haiku-4-5", body.Model)
+	assert.Equal(s.T(), 2048, body.MaxTokens)
+	assert.Contains(s.T(), body.Messages[0].Content, "+func main() {}")
+	require.Len(s.T(), body.Tools, 1)
+	assert.Equal(s.T(), toolName, body.Tools[0].Name)
+	assert.Contains(s.T(), body.Tools[0].InputSchema["properties"], "subcommits")
+	assert.Equal(s.T(), toolChoice{Type: "tool", Name: toolName}, body.ToolChoice)
+}
+
+func (s *AnthropicAgentTestSuite) TestMissingToolCallIsAnError() {
+	s.respondWith([]map[string]any{{"type": "text", "text": "I'd rather not."}}, "end_turn")
+	_, err := s.analyze(Config{})
+
+	assert.ErrorContains(s.T(), err, toolName)
+}
+
+func (s *AnthropicAgentTestSuite) TestTruncatedAnswerIsAnError() {
+	s.respondWith([]map[string]any{{"type": "tool_use", "name": toolName, "input": map[string]any{}}}, "max_tokens")
+	_, err := s.analyze(Config{})
+
+	assert.ErrorContains(s.T(), err, "max tokens")
+}
+
+func (s *AnthropicAgentTestSuite) TestAPIErrorIsReturnedWithMessage() {
+	s.respond = func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"type":"error","error":{"type":"rate_limit_error","message":"Number of request tokens has exceeded your rate limit"}}`))
+	}
+	_, err := s.analyze(Config{})
+
+	assert.ErrorContains(s.T(), err, "rate_limit_error")
+}
+
+func (s *AnthropicAgentTestSuite) TestReportsTokenUsage() {
+	a, err := NewAgent(Config{BaseURL: s.server.URL, HTTPClient: s.server.Client(), Model: "claude-sonnet-4-5"})
+	require.NoError(s.T(), err)
+
+	ctx, meter := agent.WithUsageMeter(context.Background())
+	_, err = a.AnalyzeCommit(ctx, agent.AnalysisInput{Diff: "+func main() {}"})
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), agent.Usage{PromptTokens: 2048, ResponseTokens: 120}, meter.Usage())
+}
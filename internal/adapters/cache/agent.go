@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+)
+
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+\d+(?:,\d+)? @@`)
+
+// Agent
+// Serves analyses of diffs it has seen before from an agent.AnalysisCache and only
+// asks the wrapped agent about new ones
+type Agent struct {
+	agent     agent.Agent
+	cache     agent.AnalysisCache
+	namespace string
+}
+
+// NewAgent
+// namespace identifies what the cached results depend on besides the diff, the prompt
+// version and the models answering, so that changing either starts a fresh cache
+func NewAgent(a agent.Agent, cache agent.AnalysisCache, namespace string) *Agent {
+	slog.Info("Analysis cache enabled", "namespace", namespace)
+	return &Agent{agent: a, cache: cache, namespace: namespace}
+}
+
+// AnalyzeCommit
+// The cache is an optimization: failing to read or write it never fails the analysis.
+// Repositories with prompt overrides get answers of their own.
+func (a *Agent) AnalyzeCommit(ctx context.Context, input agent.AnalysisInput) ([]agent.AnalysisResult, error) {
+	namespace := a.namespace
+	if fingerprint := agent.PromptOverridesFrom(ctx).Fingerprint(); fingerprint != "" {
+		namespace += ";overrides=" + fingerprint
+	}
+	key := Key(namespace, input)
+
+	cached, ok, err := a.cache.GetAnalysis(ctx, key)
+	if err != nil {
+		slog.Warn("Failed to read analysis cache", "key", key, "error", err)
+	}
+	if ok {
+		slog.Debug("Analysis cache hit", "key", key, "subcommits", len(cached))
+		results := make([]agent.AnalysisResult, len(cached))
+		for i, result := range cached {
+			result.Cached = true
+			results[i] = result
+		}
+		return results, nil
+	}
+
+	results, err := a.agent.AnalyzeCommit(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	// An empty answer is more likely a model hiccup than the truth about the diff
+	if len(results) > 0 {
+		if err := a.cache.StoreAnalysis(context.WithoutCancel(ctx), key, results); err != nil {
+			slog.Warn("Failed to store analysis in cache", "key", key, "error", err)
+		}
+	}
+	return results, nil
+}
+
+// Key
+// SHA-256 of the namespace, the normalized diff and the texts that state the intent
+// of the commit. The SHA, author and parents are left out, they differ between a
+// commit and its cherry-picks without changing what the analysis should be.
+func Key(namespace string, input agent.AnalysisInput) string {
+	hash := sha256.New()
+	hash.Write([]byte(namespace))
+	hash.Write([]byte{0})
+	hash.Write([]byte(normalize(input.Diff)))
+	hash.Write([]byte{0})
+	hash.Write([]byte(normalizeText(input.Message)))
+	if input.PullRequest != nil {
+		hash.Write([]byte{0})
+		hash.Write([]byte(normalizeText(input.PullRequest.Title)))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// normalizeText
+// Drops line endings and surrounding whitespace, which editors and hosts change freely
+func normalizeText(text string) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// normalize
+// Keeps what a change does and drops where it happened to land: the text before the
+// first file, blob hashes, hunk line numbers, line endings and trailing whitespace
+// all differ between a commit and its cherry-picks
+func normalize(diff string) string {
+	_, files := codehost.SplitDiff(diff)
+	if len(files) > 0 {
+		var sections strings.Builder
+		for _, file := range files {
+			sections.WriteString(file.Text)
+		}
+		diff = sections.String()
+	}
+
+	var normalized strings.Builder
+	for _, line := range strings.Split(strings.ReplaceAll(diff, "\r\n", "\n"), "\n") {
+		line = strings.TrimRight(line, " \t")
+		switch {
+		case line == "", strings.HasPrefix(line, "index "):
+			continue
+		case hunkHeader.MatchString(line):
+			line = "@@"
+		}
+		normalized.WriteString(line)
+		normalized.WriteByte('\n')
+	}
+	return normalized.String()
+}
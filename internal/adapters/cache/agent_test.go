@@ -0,0 +1,132 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/octokerbs/chronocode/internal/adapters/memory"
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	original   = "File: main.go\nindex 1a2b3c4..5d6e7f8 100644\n@@ -10,6 +10,7 @@ func main() {\n+\tlog.Println(\"started\")\n"
+	cherryPick = "Subject: [PATCH] Log startup\n\nFile: main.go\nindex 9f8e7d6..c5b4a39 100644\n@@ -42,6 +42,7 @@ func main() {\n+\tlog.Println(\"started\")  \r\n"
+)
+
+type countingAgent struct {
+	calls   int
+	results []agent.AnalysisResult
+	err     error
+}
+
+func (a *countingAgent) AnalyzeCommit(ctx context.Context, input agent.AnalysisInput) ([]agent.AnalysisResult, error) {
+	a.calls++
+	return a.results, a.err
+}
+
+type failingCache struct{}
+
+func (failingCache) GetAnalysis(ctx context.Context, key string) ([]agent.AnalysisResult, bool, error) {
+	return nil, false, errors.New("connection refused")
+}
+
+func (failingCache) StoreAnalysis(ctx context.Context, key string, results []agent.AnalysisResult) error {
+	return errors.New("connection refused")
+}
+
+type CachingAgentTestSuite struct {
+	suite.Suite
+	inner *countingAgent
+	cache *memory.AnalysisCache
+}
+
+func TestCachingAgentTestSuite(t *testing.T) {
+	suite.Run(t, new(CachingAgentTestSuite))
+}
+
+func (s *CachingAgentTestSuite) SetupTest() {
+	s.inner = &countingAgent{results: []agent.AnalysisResult{{Title: "Log startup", ModificationType: "FEATURE", Files: []string{"main.go"}, Agent: "gemini"}}}
+	s.cache = memory.NewAnalysisCache()
+}
+
+func (s *CachingAgentTestSuite) TestFirstAnalysisIsNotCached() {
+	results, err := NewAgent(s.inner, s.cache, "v1").AnalyzeCommit(context.Background(), agent.AnalysisInput{Diff: original})
+
+	assert.Nil(s.T(), err)
+	assert.False(s.T(), results[0].Cached)
+	assert.Equal(s.T(), 1, s.inner.calls)
+}
+
+func (s *CachingAgentTestSuite) TestCherryPickedDiffIsServedFromCache() {
+	a := NewAgent(s.inner, s.cache, "v1")
+	_, _ = a.AnalyzeCommit(context.Background(), agent.AnalysisInput{Diff: original})
+	results, err := a.AnalyzeCommit(context.Background(), agent.AnalysisInput{Diff: cherryPick})
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 1, s.inner.calls)
+	assert.Equal(s.T(), []agent.AnalysisResult{{Title: "Log startup", ModificationType: "FEATURE", Files: []string{"main.go"}, Agent: "gemini", Cached: true}}, results)
+}
+
+func (s *CachingAgentTestSuite) TestDifferentChangesAreNotConfused() {
+	a := NewAgent(s.inner, s.cache, "v1")
+	_, _ = a.AnalyzeCommit(context.Background(), agent.AnalysisInput{Diff: original})
+	_, _ = a.AnalyzeCommit(context.Background(), agent.AnalysisInput{Diff: "File: main.go\n@@ -10,6 +10,7 @@\n+\tlog.Println(\"stopped\")\n"})
+
+	assert.Equal(s.T(), 2, s.inner.calls)
+}
+
+func (s *CachingAgentTestSuite) TestCommitMessageIsPartOfTheKey() {
+	a := NewAgent(s.inner, s.cache, "v1")
+	_, _ = a.AnalyzeCommit(context.Background(), agent.AnalysisInput{Diff: original, SHA: "a1", Author: "Ada <ada@example.com>", Message: "feat: log startup\r\n"})
+	_, _ = a.AnalyzeCommit(context.Background(), agent.AnalysisInput{Diff: cherryPick, SHA: "b2", Author: "Bob <bob@example.com>", Message: "feat: log startup"})
+	_, _ = a.AnalyzeCommit(context.Background(), agent.AnalysisInput{Diff: original, Message: "fix: log startup"})
+
+	assert.Equal(s.T(), 2, s.inner.calls)
+}
+
+func (s *CachingAgentTestSuite) TestNamespaceSeparatesPromptsAndModels() {
+	_, _ = NewAgent(s.inner, s.cache, "prompt=1;agents=gemini:flash").AnalyzeCommit(context.Background(), agent.AnalysisInput{Diff: original})
+	_, _ = NewAgent(s.inner, s.cache, "prompt=2;agents=gemini:flash").AnalyzeCommit(context.Background(), agent.AnalysisInput{Diff: original})
+
+	assert.Equal(s.T(), 2, s.inner.calls)
+}
+
+func (s *CachingAgentTestSuite) TestPromptOverridesSeparateRepositories() {
+	a := NewAgent(s.inner, s.cache, "v1")
+	glossary := agent.WithPromptOverrides(context.Background(), agent.PromptOverrides{Glossary: "Ledger: the accounting service"})
+
+	_, _ = a.AnalyzeCommit(context.Background(), agent.AnalysisInput{Diff: original})
+	_, _ = a.AnalyzeCommit(glossary, agent.AnalysisInput{Diff: original})
+	_, _ = a.AnalyzeCommit(glossary, agent.AnalysisInput{Diff: cherryPick})
+
+	assert.Equal(s.T(), 2, s.inner.calls)
+}
+
+func (s *CachingAgentTestSuite) TestFailedAnalysisIsNotCached() {
+	s.inner.err = errors.New("quota exhausted")
+	a := NewAgent(s.inner, s.cache, "v1")
+	_, err := a.AnalyzeCommit(context.Background(), agent.AnalysisInput{Diff: original})
+	_, _ = a.AnalyzeCommit(context.Background(), agent.AnalysisInput{Diff: original})
+
+	assert.ErrorContains(s.T(), err, "quota exhausted")
+	assert.Equal(s.T(), 2, s.inner.calls)
+}
+
+func (s *CachingAgentTestSuite) TestEmptyAnalysisIsNotCached() {
+	s.inner.results = nil
+	a := NewAgent(s.inner, s.cache, "v1")
+	_, _ = a.AnalyzeCommit(context.Background(), agent.AnalysisInput{Diff: original})
+	_, _ = a.AnalyzeCommit(context.Background(), agent.AnalysisInput{Diff: original})
+
+	assert.Equal(s.T(), 2, s.inner.calls)
+}
+
+func (s *CachingAgentTestSuite) TestUnavailableCacheDoesNotFailTheAnalysis() {
+	results, err := NewAgent(s.inner, failingCache{}, "v1").AnalyzeCommit(context.Background(), agent.AnalysisInput{Diff: original})
+
+	assert.Nil(s.T(), err)
+	assert.Len(s.T(), results, 1)
+}
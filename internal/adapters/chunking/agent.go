@@ -0,0 +1,198 @@
+package chunking
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+)
+
+// Limits
+// Sizes are in estimated tokens, see EstimateTokens
+type Limits struct {
+	// MaxChunkTokens bounds every request sent to the wrapped agent, 0 disables chunking
+	MaxChunkTokens int
+	// SummarizeFileTokens replaces the diff of larger files, typically vendored or
+	// generated code, by a short summary. 0 only summarizes files that cannot fit in
+	// a chunk on their own
+	SummarizeFileTokens int
+}
+
+var DefaultLimits = Limits{MaxChunkTokens: 60_000, SummarizeFileTokens: 20_000}
+
+// summaryLines of a summarized file diff are kept to show what kind of file it is
+const summaryLines = 20
+
+// Agent
+// Splits diffs over the limits by file into chunks, analyzes every chunk with the
+// wrapped agent and merges the subcommits of all chunks back into one set
+type Agent struct {
+	agent  agent.Agent
+	limits Limits
+}
+
+func NewAgent(a agent.Agent, limits Limits) *Agent {
+	return &Agent{agent: a, limits: limits}
+}
+
+// EstimateTokens
+// Rough token count of text, about four bytes per token for code and English
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// AnalyzeCommit
+// Every chunk is sent with the metadata of the whole commit, so each one still knows
+// what the commit is about and which other files it touches
+func (a *Agent) AnalyzeCommit(ctx context.Context, input agent.AnalysisInput) ([]agent.AnalysisResult, error) {
+	if a.limits.MaxChunkTokens <= 0 || EstimateTokens(input.Diff) <= a.limits.MaxChunkTokens && a.limits.SummarizeFileTokens <= 0 {
+		return a.agent.AnalyzeCommit(ctx, input)
+	}
+
+	chunks := a.chunk(input.Diff)
+	if len(chunks) == 1 {
+		input.Diff = chunks[0]
+		return a.agent.AnalyzeCommit(ctx, input)
+	}
+
+	slog.Info("Analyzing large diff in chunks", "diff_tokens", EstimateTokens(input.Diff), "chunks", len(chunks))
+
+	var merger merger
+	for i, chunk := range chunks {
+		chunkInput := input
+		chunkInput.Diff = chunk
+		results, err := a.agent.AnalyzeCommit(ctx, chunkInput)
+		if err != nil {
+			return nil, fmt.Errorf("chunk %d of %d: %w", i+1, len(chunks), err)
+		}
+		merger.add(i, results)
+	}
+
+	merged := merger.results()
+	slog.Debug("Chunk analyses merged", "chunks", len(chunks), "subcommits", len(merged))
+	return merged, nil
+}
+
+// chunk
+// Packs whole files into chunks of at most MaxChunkTokens, each starting with the
+// text preceding the first file (the commit message, when the diff carries it)
+func (a *Agent) chunk(diff string) []string {
+	preamble, files := codehost.SplitDiff(diff)
+	budget := a.limits.MaxChunkTokens - EstimateTokens(preamble)
+
+	var chunks []string
+	var current strings.Builder
+	for _, diffFile := range files {
+		file := diffFile.Text
+		tokens := EstimateTokens(file)
+		if (a.limits.SummarizeFileTokens > 0 && tokens > a.limits.SummarizeFileTokens) || tokens > budget {
+			file = summarize(file)
+			tokens = EstimateTokens(file)
+		}
+
+		if current.Len() > 0 && EstimateTokens(current.String())+tokens > budget {
+			chunks = append(chunks, preamble+current.String())
+			current.Reset()
+		}
+		current.WriteString(file)
+	}
+
+	if current.Len() > 0 || len(chunks) == 0 {
+		chunks = append(chunks, preamble+current.String())
+	}
+	return chunks
+}
+
+func summarize(file string) string {
+	lines := strings.SplitAfter(file, "\n")
+
+	var added, removed int
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+		case strings.HasPrefix(line, "+"):
+			added++
+		case strings.HasPrefix(line, "-"):
+			removed++
+		}
+	}
+
+	kept := strings.Join(lines[:min(summaryLines, len(lines))], "")
+	if !strings.HasSuffix(kept, "\n") {
+		kept += "\n"
+	}
+	return kept + fmt.Sprintf("[diff truncated: this file is too large to include, it adds %d and removes %d lines in total]\n\n", added, removed)
+}
+
+// merger
+// Chunks often describe the same work: a title repeated across chunks, or the rest of
+// a subcommit whose files were split. Results with the same title, or with the same
+// epic and type as a result of another chunk, are merged into one
+type merger struct {
+	entries []*entry
+}
+
+type entry struct {
+	result agent.AnalysisResult
+	chunks []int
+}
+
+func (m *merger) add(chunk int, results []agent.AnalysisResult) {
+	for _, result := range results {
+		if e := m.find(chunk, result); e != nil {
+			e.merge(chunk, result)
+			continue
+		}
+
+		result.Files = slices.Clone(result.Files)
+		m.entries = append(m.entries, &entry{result: result, chunks: []int{chunk}})
+	}
+}
+
+func (m *merger) find(chunk int, result agent.AnalysisResult) *entry {
+	title := normalize(result.Title)
+	for _, e := range m.entries {
+		if normalize(e.result.Title) == title {
+			return e
+		}
+	}
+
+	for _, e := range m.entries {
+		if !slices.Contains(e.chunks, chunk) && normalize(e.result.Epic) == normalize(result.Epic) && e.result.ModificationType == result.ModificationType {
+			return e
+		}
+	}
+	return nil
+}
+
+func (e *entry) merge(chunk int, result agent.AnalysisResult) {
+	for _, file := range result.Files {
+		if !slices.Contains(e.result.Files, file) {
+			e.result.Files = append(e.result.Files, file)
+		}
+	}
+
+	if result.Description != "" && !strings.Contains(e.result.Description, result.Description) {
+		e.result.Description = strings.TrimSpace(e.result.Description + "\n\n" + result.Description)
+	}
+
+	if !slices.Contains(e.chunks, chunk) {
+		e.chunks = append(e.chunks, chunk)
+	}
+}
+
+func (m *merger) results() []agent.AnalysisResult {
+	results := make([]agent.AnalysisResult, len(m.entries))
+	for i, e := range m.entries {
+		results[i] = e.result
+	}
+	return results
+}
+
+func normalize(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
@@ -0,0 +1,177 @@
+package chunking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// recordingAgent answers every diff with one subcommit per file found in it, titled
+// after the file unless title is set
+type recordingAgent struct {
+	diffs    []string
+	messages []string
+	title    string
+	err      error
+}
+
+func (r *recordingAgent) AnalyzeCommit(ctx context.Context, input agent.AnalysisInput) ([]agent.AnalysisResult, error) {
+	r.diffs = append(r.diffs, input.Diff)
+	r.messages = append(r.messages, input.Message)
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	_, files := codehost.SplitDiff(input.Diff)
+	var results []agent.AnalysisResult
+	for _, file := range files {
+		name := file.Path
+		title := r.title
+		if title == "" {
+			title = "Change " + name
+		}
+		results = append(results, agent.AnalysisResult{Title: title, Epic: "Vendor", ModificationType: "CHORE", Description: "Updates " + name, Files: []string{name}})
+	}
+	return results, nil
+}
+
+func fileDiff(name string, lines int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "File: %s\n@@ -0,0 +1,%d @@\n", name, lines)
+	for i := range lines {
+		fmt.Fprintf(&b, "+line %03d of %s\n", i, name)
+	}
+	return b.String() + "\n"
+}
+
+type ChunkingAgentTestSuite struct {
+	suite.Suite
+	inner *recordingAgent
+}
+
+func TestChunkingAgentTestSuite(t *testing.T) {
+	suite.Run(t, new(ChunkingAgentTestSuite))
+}
+
+func (s *ChunkingAgentTestSuite) SetupTest() {
+	s.inner = &recordingAgent{}
+}
+
+func (s *ChunkingAgentTestSuite) analyze(limits Limits, diff string) []agent.AnalysisResult {
+	results, err := NewAgent(s.inner, limits).AnalyzeCommit(context.Background(), agent.AnalysisInput{Diff: diff})
+	require.NoError(s.T(), err)
+	return results
+}
+
+func (s *ChunkingAgentTestSuite) TestSmallDiffIsSentUnchanged() {
+	diff := fileDiff("a.go", 3)
+	s.analyze(Limits{MaxChunkTokens: 1000}, diff)
+
+	assert.Equal(s.T(), []string{diff}, s.inner.diffs)
+}
+
+func (s *ChunkingAgentTestSuite) TestDisabledLimitsSendEverything() {
+	diff := fileDiff("a.go", 500)
+	s.analyze(Limits{}, diff)
+
+	assert.Equal(s.T(), []string{diff}, s.inner.diffs)
+}
+
+func (s *ChunkingAgentTestSuite) TestSplitsByFileWithinTokenBudget() {
+	diff := fileDiff("a.go", 20) + fileDiff("b.go", 20) + fileDiff("c.go", 20)
+	results := s.analyze(Limits{MaxChunkTokens: EstimateTokens(fileDiff("a.go", 20)) * 2}, diff)
+
+	require.Len(s.T(), s.inner.diffs, 2)
+	for _, chunk := range s.inner.diffs {
+		assert.LessOrEqual(s.T(), EstimateTokens(chunk), EstimateTokens(fileDiff("a.go", 20))*2)
+	}
+	assert.Equal(s.T(), fileDiff("a.go", 20)+fileDiff("b.go", 20), s.inner.diffs[0])
+	assert.Len(s.T(), results, 2)
+}
+
+func (s *ChunkingAgentTestSuite) TestEveryChunkKeepsThePreamble() {
+	diff := "feat: vendor the SDK\n\n" + fileDiff("a.go", 20) + fileDiff("b.go", 20)
+	s.analyze(Limits{MaxChunkTokens: EstimateTokens(fileDiff("a.go", 20)) + 20}, diff)
+
+	require.Len(s.T(), s.inner.diffs, 2)
+	for _, chunk := range s.inner.diffs {
+		assert.True(s.T(), strings.HasPrefix(chunk, "feat: vendor the SDK\n\nFile: "))
+	}
+}
+
+func (s *ChunkingAgentTestSuite) TestEveryChunkKeepsTheCommitMetadata() {
+	input := agent.AnalysisInput{Diff: fileDiff("a.go", 20) + fileDiff("b.go", 20), Message: "feat: vendor the SDK"}
+	_, err := NewAgent(s.inner, Limits{MaxChunkTokens: EstimateTokens(fileDiff("a.go", 20)) + 20}).AnalyzeCommit(context.Background(), input)
+
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{fileDiff("a.go", 20), fileDiff("b.go", 20)}, s.inner.diffs)
+	assert.Equal(s.T(), []string{"feat: vendor the SDK", "feat: vendor the SDK"}, s.inner.messages)
+}
+
+func (s *ChunkingAgentTestSuite) TestMergesSubcommitsAcrossChunks() {
+	s.inner.title = "Vendor the cloud SDK"
+	diff := fileDiff("vendor/a.go", 20) + fileDiff("vendor/b.go", 20) + fileDiff("vendor/c.go", 20)
+	results := s.analyze(Limits{MaxChunkTokens: EstimateTokens(fileDiff("vendor/a.go", 20)) + 10}, diff)
+
+	require.Len(s.T(), s.inner.diffs, 3)
+	require.Len(s.T(), results, 1)
+	assert.Equal(s.T(), []string{"vendor/a.go", "vendor/b.go", "vendor/c.go"}, results[0].Files)
+	assert.Contains(s.T(), results[0].Description, "Updates vendor/c.go")
+}
+
+func (s *ChunkingAgentTestSuite) TestMergesSameEpicAndTypeOnlyAcrossChunks() {
+	diff := fileDiff("a.go", 20) + fileDiff("b.go", 20) + fileDiff("c.go", 20)
+	results := s.analyze(Limits{MaxChunkTokens: EstimateTokens(fileDiff("a.go", 20))*2 + 10}, diff)
+
+	require.Len(s.T(), s.inner.diffs, 2)
+	require.Len(s.T(), results, 2)
+	assert.Equal(s.T(), []string{"a.go", "c.go"}, results[0].Files)
+	assert.Equal(s.T(), []string{"b.go"}, results[1].Files)
+}
+
+func (s *ChunkingAgentTestSuite) TestSummarizesVeryLargeFiles() {
+	diff := fileDiff("small.go", 5) + fileDiff("generated.pb.go", 400)
+	s.analyze(Limits{MaxChunkTokens: 100_000, SummarizeFileTokens: 500}, diff)
+
+	require.Len(s.T(), s.inner.diffs, 1)
+	sent := s.inner.diffs[0]
+	assert.Contains(s.T(), sent, fileDiff("small.go", 5))
+	assert.Contains(s.T(), sent, "File: generated.pb.go")
+	assert.Contains(s.T(), sent, "adds 400 and removes 0 lines")
+	assert.NotContains(s.T(), sent, "line 399 of generated.pb.go")
+}
+
+func (s *ChunkingAgentTestSuite) TestSummarizesFilesLargerThanAChunk() {
+	diff := fileDiff("a.go", 5) + fileDiff("huge.go", 400)
+	s.analyze(Limits{MaxChunkTokens: 300}, diff)
+
+	for _, chunk := range s.inner.diffs {
+		assert.LessOrEqual(s.T(), EstimateTokens(chunk), 300)
+	}
+}
+
+func (s *ChunkingAgentTestSuite) TestChunkFailureFailsTheCommit() {
+	s.inner.err = errors.New("quota exhausted")
+	_, err := NewAgent(s.inner, Limits{MaxChunkTokens: 50}).AnalyzeCommit(context.Background(), agent.AnalysisInput{Diff: fileDiff("a.go", 20) + fileDiff("b.go", 20)})
+
+	assert.ErrorContains(s.T(), err, "quota exhausted")
+	assert.Len(s.T(), s.inner.diffs, 1)
+}
+
+func (s *ChunkingAgentTestSuite) TestUnderstandsGitDiffHeaders() {
+	preamble, files := codehost.SplitDiff("message\n\ndiff --git a/a.go b/a.go\n+a\ndiff --git a/b.go b/b.go\n+b\n")
+
+	assert.Equal(s.T(), "message\n\n", preamble)
+	assert.Equal(s.T(), []codehost.DiffFile{
+		{Path: "a.go", Text: "diff --git a/a.go b/a.go\n+a\n"},
+		{Path: "b.go", Text: "diff --git a/b.go b/b.go\n+b\n"},
+	}, files)
+}
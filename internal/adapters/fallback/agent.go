@@ -0,0 +1,126 @@
+package fallback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+)
+
+var ErrAllAgentsUnavailable = errors.New("every agent in the chain is unavailable")
+
+type Member struct {
+	Name  string
+	Agent agent.Agent
+}
+
+// Agent
+// Asks each member in order until one answers. A member that fails threshold times in
+// a row is skipped for cooldown, after which it gets a single chance to recover
+// before being skipped again
+type Agent struct {
+	members   []*breaker
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+}
+
+type breaker struct {
+	Member
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+func NewAgent(threshold int, cooldown time.Duration, members ...Member) (*Agent, error) {
+	if len(members) == 0 {
+		return nil, errors.New("missing agents")
+	}
+
+	if threshold <= 0 {
+		return nil, errors.New("circuit breaker threshold must be positive")
+	}
+
+	breakers := make([]*breaker, len(members))
+	for i, m := range members {
+		if m.Agent == nil {
+			return nil, fmt.Errorf("missing agent %q", m.Name)
+		}
+		breakers[i] = &breaker{Member: m}
+	}
+
+	return &Agent{members: breakers, threshold: threshold, cooldown: cooldown, now: time.Now}, nil
+}
+
+func (a *Agent) AnalyzeCommit(ctx context.Context, input agent.AnalysisInput) ([]agent.AnalysisResult, error) {
+	var errs []error
+
+	for _, m := range a.members {
+		if !m.allow(a.now()) {
+			slog.Debug("Skipping agent with open circuit", "agent", m.Name)
+			continue
+		}
+
+		results, err := m.Agent.AnalyzeCommit(ctx, input)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+
+			if m.fail(a.now(), a.threshold, a.cooldown) {
+				slog.Warn("Agent circuit opened", "agent", m.Name, "cooldown", a.cooldown, "error", err)
+			} else {
+				slog.Warn("Agent failed, falling back", "agent", m.Name, "error", err)
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", m.Name, err))
+			continue
+		}
+
+		m.succeed()
+		for i := range results {
+			if results[i].Agent == "" {
+				results[i].Agent = m.Name
+			}
+		}
+		return results, nil
+	}
+
+	if len(errs) == 0 {
+		return nil, ErrAllAgentsUnavailable
+	}
+	return nil, errors.Join(errs...)
+}
+
+func (b *breaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return !now.Before(b.openUntil)
+}
+
+// fail
+// Records a failure and reports whether it opened the circuit
+func (b *breaker) fail(now time.Time, threshold int, cooldown time.Duration) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.failures < threshold {
+		return false
+	}
+
+	b.openUntil = now.Add(cooldown)
+	return true
+}
+
+func (b *breaker) succeed() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.openUntil = time.Time{}
+}
@@ -0,0 +1,145 @@
+package fallback
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type stubAgent struct {
+	calls int
+	err   error
+}
+
+func (s *stubAgent) AnalyzeCommit(ctx context.Context, input agent.AnalysisInput) ([]agent.AnalysisResult, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return []agent.AnalysisResult{{Title: "title", ModificationType: "FEATURE"}}, nil
+}
+
+type FallbackAgentTestSuite struct {
+	suite.Suite
+	primary   *stubAgent
+	secondary *stubAgent
+	agent     *Agent
+	now       time.Time
+}
+
+func TestFallbackAgentTestSuite(t *testing.T) {
+	suite.Run(t, new(FallbackAgentTestSuite))
+}
+
+func (s *FallbackAgentTestSuite) SetupTest() {
+	s.primary = &stubAgent{}
+	s.secondary = &stubAgent{}
+	s.now = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var err error
+	s.agent, err = NewAgent(2, time.Minute, Member{Name: "gemini", Agent: s.primary}, Member{Name: "heuristic", Agent: s.secondary})
+	require.NoError(s.T(), err)
+	s.agent.now = func() time.Time { return s.now }
+}
+
+func (s *FallbackAgentTestSuite) analyze() ([]agent.AnalysisResult, error) {
+	return s.agent.AnalyzeCommit(context.Background(), agent.AnalysisInput{Diff: "diff"})
+}
+
+func (s *FallbackAgentTestSuite) TestRequiresAgents() {
+	_, err := NewAgent(2, time.Minute)
+	assert.NotNil(s.T(), err)
+
+	_, err = NewAgent(0, time.Minute, Member{Name: "gemini", Agent: s.primary})
+	assert.NotNil(s.T(), err)
+}
+
+func (s *FallbackAgentTestSuite) TestPrimaryAnswersAndIsRecorded() {
+	results, err := s.analyze()
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), "gemini", results[0].Agent)
+	assert.Equal(s.T(), 0, s.secondary.calls)
+}
+
+func (s *FallbackAgentTestSuite) TestFallsBackWhenPrimaryFails() {
+	s.primary.err = errors.New("quota exhausted")
+	results, err := s.analyze()
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), "heuristic", results[0].Agent)
+	assert.Equal(s.T(), 1, s.primary.calls)
+}
+
+func (s *FallbackAgentTestSuite) TestOpenCircuitSkipsPrimaryUntilCooldown() {
+	s.primary.err = errors.New("quota exhausted")
+	_, _ = s.analyze()
+	_, _ = s.analyze()
+	_, _ = s.analyze()
+
+	assert.Equal(s.T(), 2, s.primary.calls)
+	assert.Equal(s.T(), 3, s.secondary.calls)
+
+	s.now = s.now.Add(time.Minute)
+	s.primary.err = nil
+	results, err := s.analyze()
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), "gemini", results[0].Agent)
+	assert.Equal(s.T(), 3, s.primary.calls)
+}
+
+func (s *FallbackAgentTestSuite) TestFailedRecoveryReopensCircuit() {
+	s.primary.err = errors.New("quota exhausted")
+	_, _ = s.analyze()
+	_, _ = s.analyze()
+
+	s.now = s.now.Add(time.Minute)
+	_, _ = s.analyze()
+	_, _ = s.analyze()
+
+	assert.Equal(s.T(), 3, s.primary.calls)
+}
+
+func (s *FallbackAgentTestSuite) TestSuccessResetsConsecutiveFailures() {
+	s.primary.err = errors.New("timeout")
+	_, _ = s.analyze()
+	s.primary.err = nil
+	_, _ = s.analyze()
+	s.primary.err = errors.New("timeout")
+	_, _ = s.analyze()
+	_, _ = s.analyze()
+
+	assert.Equal(s.T(), 4, s.primary.calls)
+}
+
+func (s *FallbackAgentTestSuite) TestErrorsWhenEveryAgentFails() {
+	s.primary.err = errors.New("quota exhausted")
+	s.secondary.err = errors.New("model not loaded")
+	_, err := s.analyze()
+
+	assert.ErrorContains(s.T(), err, "gemini: quota exhausted")
+	assert.ErrorContains(s.T(), err, "heuristic: model not loaded")
+
+	_, _ = s.analyze()
+	_, err = s.analyze()
+	assert.ErrorIs(s.T(), err, ErrAllAgentsUnavailable)
+}
+
+func (s *FallbackAgentTestSuite) TestCancellationIsNotAFailure() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.primary.err = context.Canceled
+
+	_, err := s.agent.AnalyzeCommit(ctx, agent.AnalysisInput{Diff: "diff"})
+
+	assert.ErrorIs(s.T(), err, context.Canceled)
+	assert.Equal(s.T(), 0, s.secondary.calls)
+	assert.Equal(s.T(), 0, s.agent.members[0].failures)
+}
@@ -2,20 +2,27 @@ package gemini
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"log/slog"
+	"net/http"
+	"time"
 
 	"github.com/google/generative-ai-go/genai"
+	"github.com/octokerbs/chronocode/internal/adapters/llm"
+	"github.com/octokerbs/chronocode/internal/adapters/retry"
 	"github.com/octokerbs/chronocode/internal/domain/agent"
+	"google.golang.org/api/googleapi"
 )
 
 type Agent struct {
 	client          *genai.Client
 	generativeModel *genai.GenerativeModel
+	prompts         *llm.Prompts
 }
 
-func NewAgent(client *genai.Client, model string) (*Agent, error) {
+// NewAgent
+// prompts renders the analysis prompt, llm.DefaultPrompts when nil
+func NewAgent(client *genai.Client, model string, prompts *llm.Prompts) (*Agent, error) {
 	if client == nil {
 		return nil, errors.New("missing gemini client")
 	}
@@ -27,134 +34,111 @@ func NewAgent(client *genai.Client, model string) (*Agent, error) {
 	generativeModel := client.GenerativeModel(model)
 	generativeModel.ResponseMIMEType = "application/json"
 
-	slog.Info("Gemini agent initialized", "model", model)
-	return &Agent{client: client, generativeModel: generativeModel}, nil
-}
-
-type subcommitResponse struct {
-	Title            string   `json:"title"`
-	Idea             string   `json:"idea"`
-	Description      string   `json:"description"`
-	Epic             string   `json:"epic"`
-	ModificationType string   `json:"type"`
-	Files            []string `json:"files"`
-}
+	if prompts == nil {
+		prompts = llm.DefaultPrompts
+	}
 
-type analysisResponse struct {
-	Subcommits []subcommitResponse `json:"subcommits"`
+	slog.Info("Gemini agent initialized", "model", model)
+	return &Agent{client: client, generativeModel: generativeModel, prompts: prompts}, nil
 }
 
-func (a *Agent) AnalyzeDiff(ctx context.Context, diff string) ([]agent.AnalysisResult, error) {
-	slog.Debug("Gemini analyzing diff", "diff_length", len(diff))
+func (a *Agent) AnalyzeCommit(ctx context.Context, input agent.AnalysisInput) ([]agent.AnalysisResult, error) {
+	slog.Debug("Gemini analyzing diff", "diff_length", len(input.Diff))
 
-	prompt := a.commitAnalysisPrompt() + diff
-
-	text, err := a.generateStructuredContent(ctx, prompt, a.analysisSchema())
+	prompt, err := a.prompts.Render(agent.PromptOverridesFrom(ctx), input)
 	if err != nil {
-		slog.Error("Gemini content generation failed", "error", err, "diff_length", len(diff))
 		return nil, err
 	}
 
-	var response analysisResponse
-	if err := json.Unmarshal(text, &response); err != nil {
-		slog.Error("Failed to unmarshal Gemini response", "error", err, "response_length", len(text))
+	text, err := a.generateStructuredContent(ctx, prompt.Text+input.Diff, analysisSchema(prompt))
+	if err != nil {
+		slog.Error("Gemini content generation failed", "error", err, "diff_length", len(input.Diff))
 		return nil, err
 	}
 
-	results := make([]agent.AnalysisResult, len(response.Subcommits))
-	for i, sc := range response.Subcommits {
-		results[i] = agent.AnalysisResult{
-			Title:            sc.Title,
-			Idea:             sc.Idea,
-			Description:      sc.Description,
-			Epic:             sc.Epic,
-			ModificationType: sc.ModificationType,
-			Files:            sc.Files,
-		}
+	results, err := prompt.ParseAnalysis(text)
+	if err != nil {
+		slog.Error("Failed to unmarshal Gemini response", "error", err, "response_length", len(text))
+		return nil, err
 	}
 
-	slog.Debug("Gemini analysis completed", "subcommits_produced", len(results), "diff_length", len(diff))
+	slog.Debug("Gemini analysis completed", "subcommits_produced", len(results), "diff_length", len(input.Diff))
 	return results, nil
 }
 
-func (a *Agent) analysisSchema() *genai.Schema {
+func analysisSchema(prompt llm.Prompt) *genai.Schema {
 	return &genai.Schema{
 		Type: genai.TypeObject,
 		Properties: map[string]*genai.Schema{
 			"subcommits": {
 				Type:        genai.TypeArray,
-				Items:       a.subcommitSchema(),
-				Description: "An array of logical units of work that make up this commit.",
+				Items:       subcommitSchema(prompt),
+				Description: prompt.SubcommitsDescription,
 			},
 		},
 		Required: []string{"subcommits"},
 	}
 }
 
-func (a *Agent) subcommitSchema() *genai.Schema {
+func subcommitSchema(prompt llm.Prompt) *genai.Schema {
 	return &genai.Schema{
 		Type: genai.TypeObject,
 		Properties: map[string]*genai.Schema{
 			"title": {
 				Type:        genai.TypeString,
-				Description: "A concise, specific title (5-10 words) that precisely captures what this logical unit of work accomplishes.",
+				Description: prompt.TitleDescription,
 			},
 			"idea": {
 				Type:        genai.TypeString,
-				Description: "A one-sentence thesis explaining the core motivation or reasoning behind this change.",
+				Description: prompt.IdeaDescription,
 			},
 			"description": {
 				Type:        genai.TypeString,
-				Description: "A technical explanation detailing implementation specifics and what problem it solves.",
+				Description: prompt.DescriptionDescription,
 			},
 			"epic": {
 				Type:        genai.TypeString,
-				Description: "A broad initiative or project area label this change belongs to (e.g. 'Authentication', 'Performance', 'CI/CD').",
+				Description: prompt.EpicDescription,
 			},
 			"type": {
 				Type:        genai.TypeString,
-				Description: "The primary category that best represents the nature of this change.",
-				Enum:        []string{"FEATURE", "BUG", "REFACTOR", "DOCS", "CHORE", "MILESTONE", "WARNING"},
+				Description: prompt.TypeDescription,
+				Enum:        prompt.ModificationTypes,
 			},
 			"files": {
 				Type: genai.TypeArray,
 				Items: &genai.Schema{
 					Type: genai.TypeString,
 				},
-				Description: "An array of file names that are directly related to this subcommit.",
+				Description: prompt.FilesDescription,
 			},
 		},
 		Required: []string{"title", "idea", "description", "epic", "type", "files"},
 	}
 }
 
-func (a *Agent) commitAnalysisPrompt() string {
-	return `You are a Commit Expert Analyzer specializing in code analysis and software development patterns.
-You will receive a Git Commit diff.
-Your task is to identify the logical units of work ("SubCommits") within this single commit.
-Each subcommit should have:
-- title: A concise title (5-10 words)
-- idea: A one-sentence thesis explaining the core motivation behind this change
-- description: A technical explanation of the implementation
-- epic: A broad initiative label (e.g. "Authentication", "Performance", "CI/CD")
-- type: One of FEATURE, BUG, REFACTOR, DOCS, CHORE, MILESTONE, WARNING
-- files: List of related file names
-
-Now extract the subcommits from the following diff:
-`
-}
-
+// generateStructuredContent
+// The schema carries the taxonomy of the repository being analyzed, so it is set on a
+// copy of the model: concurrent analyses of other repositories share the original
 func (a *Agent) generateStructuredContent(ctx context.Context, prompt string, schema *genai.Schema) ([]byte, error) {
-	a.generativeModel.ResponseSchema = schema
+	model := *a.generativeModel
+	model.ResponseSchema = schema
 
 	slog.Debug("Sending request to Gemini API", "prompt_length", len(prompt))
 
-	resp, err := a.generativeModel.GenerateContent(ctx, genai.Text(prompt))
+	resp, err := model.GenerateContent(ctx, genai.Text(prompt))
 	if err != nil {
 		slog.Error("Gemini API request failed", "error", err)
 		return nil, err
 	}
 
+	if resp.UsageMetadata != nil {
+		agent.RecordUsage(ctx, agent.Usage{
+			PromptTokens:   int64(resp.UsageMetadata.PromptTokenCount),
+			ResponseTokens: int64(resp.UsageMetadata.CandidatesTokenCount),
+		})
+	}
+
 	for _, part := range resp.Candidates[0].Content.Parts {
 		if text, ok := part.(genai.Text); ok {
 			slog.Debug("Gemini API response received", "response_length", len(text))
@@ -165,3 +149,20 @@ func (a *Agent) generateStructuredContent(ctx context.Context, prompt string, sc
 	slog.Error("Gemini API returned no text content in response")
 	return nil, errors.New("no text content in response")
 }
+
+// Retryable
+// Classifies Gemini API errors for retry.Agent: exhausted quotas and unavailable
+// backends are transient, everything else fails the commit right away
+func Retryable(err error) (bool, time.Duration) {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return false, 0
+	}
+
+	switch apiErr.Code {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true, retry.AdvisedWait(apiErr.Header, time.Now())
+	}
+	return false, 0
+}
@@ -0,0 +1,95 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/google/generative-ai-go/genai"
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/api/option"
+)
+
+type GeminiAgentTestSuite struct {
+	suite.Suite
+	server *httptest.Server
+	agent  *Agent
+}
+
+func TestGeminiAgentTestSuite(t *testing.T) {
+	suite.Run(t, new(GeminiAgentTestSuite))
+}
+
+// SetupTest
+// The fake answers every request with a subcommit typed with the first value of the
+// enum it was sent, as a model constrained by the schema would
+func (s *GeminiAgentTestSuite) SetupTest() {
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			GenerationConfig struct {
+				ResponseSchema struct {
+					Properties struct {
+						Subcommits struct {
+							Items struct {
+								Properties struct {
+									Type struct {
+										Enum []string `json:"enum"`
+									} `json:"type"`
+								} `json:"properties"`
+							} `json:"items"`
+						} `json:"subcommits"`
+					} `json:"properties"`
+				} `json:"responseSchema"`
+			} `json:"generationConfig"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		enum := body.GenerationConfig.ResponseSchema.Properties.Subcommits.Items.Properties.Type.Enum
+		answer := fmt.Sprintf(`{"subcommits":[{"title":"t","idea":"i","description":"d","epic":"e","type":%q,"files":["main.go"]}]}`, enum[0])
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"candidates": []map[string]any{{"content": map[string]any{"role": "model", "parts": []map[string]any{{"text": answer}}}}},
+		})
+	}))
+	s.T().Cleanup(s.server.Close)
+
+	client, err := genai.NewClient(context.Background(), option.WithAPIKey("test"), option.WithEndpoint(s.server.URL), option.WithHTTPClient(s.server.Client()))
+	require.NoError(s.T(), err)
+	s.T().Cleanup(func() { _ = client.Close() })
+
+	s.agent, err = NewAgent(client, "gemini-test", nil)
+	require.NoError(s.T(), err)
+}
+
+func (s *GeminiAgentTestSuite) TestConcurrentAnalysesKeepTheirOwnTaxonomy() {
+	taxonomies := [][]string{{"STORY", "DEFECT"}, {"EPIC", "TASK"}}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 20)
+	types := make([]string, 20)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ctx := agent.WithPromptOverrides(context.Background(), agent.PromptOverrides{ModificationTypes: taxonomies[i%2]})
+			results, err := s.agent.AnalyzeCommit(ctx, agent.AnalysisInput{Diff: "+func main() {}"})
+			errs[i] = err
+			if err == nil {
+				types[i] = results[0].ModificationType
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range errs {
+		assert.Nil(s.T(), errs[i])
+		assert.Equal(s.T(), taxonomies[i%2][0], types[i])
+	}
+}
@@ -0,0 +1,460 @@
+package gitea
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+)
+
+const commitsPageSize = 50
+
+type CodeHostFactory struct {
+	baseURL      *url.URL
+	serviceToken string
+	serviceRepos map[string]bool
+	httpClient   *http.Client
+}
+
+// NewCodeHostFactory
+// Talks to the Gitea or Forgejo instance at baseURL with the caller's own token for it.
+// Callers without one fall back to serviceToken, when set, which only reaches the
+// "owner/repo" serviceRepos: everyone signed in shares it.
+func NewCodeHostFactory(baseURL, serviceToken string, serviceRepos []string, httpClient *http.Client) (*CodeHostFactory, error) {
+	parsed, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return nil, fmt.Errorf("invalid Gitea base URL %q", baseURL)
+	}
+
+	allowed := make(map[string]bool, len(serviceRepos))
+	for _, fullName := range serviceRepos {
+		if fullName = strings.Trim(strings.TrimSpace(fullName), "/"); fullName != "" {
+			allowed[strings.ToLower(fullName)] = true
+		}
+	}
+	if serviceToken != "" && len(allowed) == 0 {
+		return nil, errors.New("a Gitea service token needs the repositories it may be used for")
+	}
+
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 60 * time.Second}
+	}
+
+	return &CodeHostFactory{baseURL: parsed, serviceToken: serviceToken, serviceRepos: allowed, httpClient: httpClient}, nil
+}
+
+// Host
+// The URL host repositories of this instance are served from
+func (f *CodeHostFactory) Host() string {
+	return f.baseURL.Host
+}
+
+// Create
+// accessToken must be the caller's Gitea token, never the one they signed in with
+func (f *CodeHostFactory) Create(ctx context.Context, accessToken string) (codehost.CodeHost, error) {
+	if accessToken != "" {
+		slog.Debug("Gitea code host client created", "base_url", f.baseURL.String())
+		return &CodeHost{baseURL: f.baseURL, token: accessToken, httpClient: f.httpClient}, nil
+	}
+
+	if f.serviceToken != "" {
+		slog.Debug("Gitea code host client created with service token", "base_url", f.baseURL.String(), "repos", len(f.serviceRepos))
+		return &CodeHost{baseURL: f.baseURL, token: f.serviceToken, allowedRepos: f.serviceRepos, httpClient: f.httpClient}, nil
+	}
+
+	slog.Warn("Gitea code host creation failed - empty access token")
+	return nil, codehost.ErrAccessDenied
+}
+
+// CodeHost
+// allowedRepos is nil when acting as the caller, who may reach whatever their token
+// reaches
+type CodeHost struct {
+	baseURL      *url.URL
+	token        string
+	allowedRepos map[string]bool
+	httpClient   *http.Client
+}
+
+type repository struct {
+	ID       int64  `json:"id"`
+	FullName string `json:"full_name"`
+	HTMLURL  string `json:"html_url"`
+}
+
+type commit struct {
+	SHA     string `json:"sha"`
+	Parents []struct {
+		SHA string `json:"sha"`
+	} `json:"parents"`
+	// Author is the account the commit email belongs to, null when there is none
+	Author *struct {
+		Login     string `json:"login"`
+		AvatarURL string `json:"avatar_url"`
+	} `json:"author"`
+	Commit struct {
+		Message string `json:"message"`
+		Author  struct {
+			Name  string `json:"name"`
+			Email string `json:"email"`
+		} `json:"author"`
+		Committer struct {
+			Date time.Time `json:"date"`
+		} `json:"committer"`
+	} `json:"commit"`
+}
+
+type pullRequest struct {
+	Number   int        `json:"number"`
+	Title    string     `json:"title"`
+	Body     string     `json:"body"`
+	HTMLURL  string     `json:"html_url"`
+	MergedAt *time.Time `json:"merged_at"`
+}
+
+func (c commit) reference() codehost.CommitReference {
+	ref := codehost.CommitReference{
+		SHA:         c.SHA,
+		CommittedAt: c.Commit.Committer.Date,
+		Message:     c.Commit.Message,
+		Author:      codehost.CommitAuthor{Name: c.Commit.Author.Name, Email: c.Commit.Author.Email},
+	}
+	if c.Author != nil {
+		ref.Author.Login = c.Author.Login
+		ref.Author.AvatarURL = c.Author.AvatarURL
+	}
+	for _, parent := range c.Parents {
+		ref.Parents = append(ref.Parents, parent.SHA)
+	}
+	return ref
+}
+
+type user struct {
+	ID        int64  `json:"id"`
+	Login     string `json:"login"`
+	FullName  string `json:"full_name"`
+	AvatarURL string `json:"avatar_url"`
+	Email     string `json:"email"`
+}
+
+type searchResults struct {
+	OK   bool         `json:"ok"`
+	Data []repository `json:"data"`
+}
+
+func (ch *CodeHost) CanAccessRepo(ctx context.Context, repoURL string) error {
+	owner, repoName, err := ch.repoPath(repoURL)
+	if err != nil {
+		slog.Warn("Gitea repo access check failed", "repo_url", repoURL, "error", err)
+		return err
+	}
+
+	slog.Debug("Checking Gitea repo access", "owner", owner, "repo", repoName)
+	var r repository
+	if _, err := ch.getJSON(ctx, repoEndpoint(owner, repoName), nil, &r); err != nil {
+		slog.Warn("Gitea repo access check failed", "owner", owner, "repo", repoName, "error", err)
+		return err
+	}
+
+	slog.Debug("Gitea repo access confirmed", "owner", owner, "repo", repoName)
+	return nil
+}
+
+func (ch *CodeHost) CreateRepoFromURL(ctx context.Context, repoURL string) (*repo.Repo, error) {
+	owner, repoName, err := ch.repoPath(repoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Debug("Fetching Gitea repo metadata", "owner", owner, "repo", repoName)
+	var r repository
+	if _, err := ch.getJSON(ctx, repoEndpoint(owner, repoName), nil, &r); err != nil {
+		slog.Error("Failed to fetch Gitea repo metadata", "owner", owner, "repo", repoName, "error", err)
+		return nil, err
+	}
+
+	id := codehost.HostRepoID(ch.baseURL.Host, r.ID)
+	slog.Info("Gitea repo metadata fetched", "repo_id", id, "gitea_id", r.ID, "full_name", r.FullName)
+	return repo.NewRepo(id, r.FullName, repoURL, "", time.Now()), nil
+}
+
+func (ch *CodeHost) GetAuthenticatedUser(ctx context.Context) (*codehost.UserProfile, error) {
+	if ch.allowedRepos != nil {
+		slog.Warn("Gitea service token does not stand for a user")
+		return nil, codehost.ErrAccessDenied
+	}
+
+	slog.Debug("Fetching authenticated Gitea user")
+	var u user
+	if _, err := ch.getJSON(ctx, "/user", nil, &u); err != nil {
+		slog.Error("Failed to fetch authenticated Gitea user", "error", err)
+		return nil, err
+	}
+
+	slog.Info("Authenticated Gitea user fetched", "login", u.Login, "user_id", u.ID)
+	return &codehost.UserProfile{ID: u.ID, Login: u.Login, Name: u.FullName, AvatarURL: u.AvatarURL, Email: u.Email}, nil
+}
+
+func (ch *CodeHost) SearchRepositories(ctx context.Context, query string) ([]codehost.RepoSearchResult, error) {
+	slog.Debug("Searching Gitea repositories", "query", query)
+	params := url.Values{
+		"q":     {query},
+		"limit": {"20"},
+		"sort":  {"updated"},
+		"order": {"desc"},
+	}
+
+	var found searchResults
+	if _, err := ch.getJSON(ctx, "/repos/search", params, &found); err != nil {
+		slog.Error("Failed to search Gitea repositories", "query", query, "error", err)
+		return nil, err
+	}
+	if !found.OK {
+		return nil, errors.New("gitea repository search failed")
+	}
+
+	results := make([]codehost.RepoSearchResult, 0, len(found.Data))
+	for _, r := range found.Data {
+		if ch.allowedRepos != nil && !ch.allowedRepos[strings.ToLower(r.FullName)] {
+			continue
+		}
+		results = append(results, codehost.RepoSearchResult{
+			ID:   codehost.HostRepoID(ch.baseURL.Host, r.ID),
+			Name: r.FullName,
+			URL:  r.HTMLURL,
+		})
+	}
+	slog.Info("Gitea repository search completed", "query", query, "matched", len(results))
+	return results, nil
+}
+
+func (ch *CodeHost) GetRepoCommitSHAsIntoChannel(ctx context.Context, r *repo.Repo, commits chan<- codehost.CommitReference) (string, error) {
+	owner, repoName, err := ch.repoPath(r.URL())
+	if err != nil {
+		return "", err
+	}
+
+	lastSHA := r.LastAnalyzedCommitSHA()
+	// Skip the per-commit stats, verification and file lists Gitea computes by default
+	params := url.Values{
+		"limit":        {strconv.Itoa(commitsPageSize)},
+		"stat":         {"false"},
+		"verification": {"false"},
+		"files":        {"false"},
+	}
+
+	slog.Info("Fetching commits from Gitea", "owner", owner, "repo", repoName, "last_analyzed_sha", lastSHA)
+
+	var headSHA string
+	var totalFetched, sentCount, mergeSkipped int
+	for page := 1; ; page++ {
+		params.Set("page", strconv.Itoa(page))
+
+		var pageCommits []commit
+		resp, err := ch.getJSON(ctx, repoEndpoint(owner, repoName)+"/commits", params, &pageCommits)
+		if err != nil {
+			slog.Error("Failed to fetch commits page from Gitea", "owner", owner, "repo", repoName, "page", page, "error", err)
+			return "", err
+		}
+
+		slog.Debug("Fetched commits page", "owner", owner, "repo", repoName, "page", page, "count", len(pageCommits))
+
+		for _, c := range pageCommits {
+			totalFetched++
+
+			if c.SHA == lastSHA {
+				slog.Info("Reached last analyzed commit, stopping fetch", "last_sha", lastSHA, "total_fetched", totalFetched, "sent", sentCount, "merge_skipped", mergeSkipped)
+				return headSHA, nil
+			}
+
+			if len(c.Parents) > 1 {
+				mergeSkipped++
+				continue
+			}
+
+			if headSHA == "" {
+				headSHA = c.SHA
+			}
+			select {
+			case commits <- c.reference():
+				sentCount++
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+		}
+
+		if !hasNextPage(resp, len(pageCommits)) {
+			break
+		}
+	}
+
+	slog.Info("Commit fetch completed", "owner", owner, "repo", repoName, "total_fetched", totalFetched, "sent", sentCount, "merge_skipped", mergeSkipped, "head_sha", headSHA)
+	return headSHA, nil
+}
+
+// GetCommitDiff
+// The file stats are counted from the git diff, Gitea only reports them per commit
+func (ch *CodeHost) GetCommitDiff(ctx context.Context, r *repo.Repo, commitSHA string) (*codehost.CommitDiff, error) {
+	owner, repoName, err := ch.repoPath(r.URL())
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Debug("Fetching commit diff", "owner", owner, "repo", repoName, "commit_sha", commitSHA)
+
+	var c commit
+	params := url.Values{"stat": {"false"}, "verification": {"false"}, "files": {"false"}}
+	if _, err := ch.getJSON(ctx, repoEndpoint(owner, repoName)+"/git/commits/"+url.PathEscape(commitSHA), params, &c); err != nil {
+		slog.Error("Failed to fetch commit from Gitea", "owner", owner, "repo", repoName, "commit_sha", commitSHA, "error", err)
+		return nil, err
+	}
+
+	resp, err := ch.get(ctx, repoEndpoint(owner, repoName)+"/git/commits/"+url.PathEscape(commitSHA)+".diff", nil)
+	if err != nil {
+		slog.Error("Failed to fetch commit diff from Gitea", "owner", owner, "repo", repoName, "commit_sha", commitSHA, "error", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	text, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &codehost.CommitDiff{CommitReference: c.reference(), Text: string(text), Files: codehost.DiffStats(string(text))}
+	slog.Debug("Commit diff fetched", "commit_sha", commitSHA, "files_count", len(diff.Files), "diff_length", len(diff.Text))
+	return diff, nil
+}
+
+// GetCommitPullRequest
+// Gitea answers with the pull request that merged the commit and 404 when there is
+// none. Access was checked before the commit was listed, so a denial here is read as
+// no pull request rather than failing the commit.
+func (ch *CodeHost) GetCommitPullRequest(ctx context.Context, r *repo.Repo, commitSHA string) (*codehost.PullRequest, error) {
+	owner, repoName, err := ch.repoPath(r.URL())
+	if err != nil {
+		return nil, err
+	}
+
+	var pr pullRequest
+	_, err = ch.getJSON(ctx, repoEndpoint(owner, repoName)+"/commits/"+url.PathEscape(commitSHA)+"/pull", nil, &pr)
+	if errors.Is(err, codehost.ErrAccessDenied) {
+		return nil, nil
+	}
+	if err != nil {
+		slog.Error("Failed to fetch pull request of commit from Gitea", "owner", owner, "repo", repoName, "commit_sha", commitSHA, "error", err)
+		return nil, err
+	}
+
+	pullRequest := &codehost.PullRequest{Number: pr.Number, Title: pr.Title, Body: pr.Body, URL: pr.HTMLURL}
+	if pr.MergedAt != nil {
+		pullRequest.MergedAt = *pr.MergedAt
+	}
+	return pullRequest, nil
+}
+
+// hasNextPage
+// Newer Gitea and Forgejo releases announce further pages through Link and
+// X-HasMore; older ones only through a full page
+func hasNextPage(resp *http.Response, pageLen int) bool {
+	if hasMore := resp.Header.Get("X-HasMore"); hasMore != "" {
+		return hasMore == "true"
+	}
+	if link := resp.Header.Get("Link"); link != "" {
+		return strings.Contains(link, `rel="next"`)
+	}
+	return pageLen == commitsPageSize
+}
+
+func (ch *CodeHost) getJSON(ctx context.Context, endpoint string, params url.Values, out any) (*http.Response, error) {
+	resp, err := ch.get(ctx, endpoint, params)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp, fmt.Errorf("decoding gitea %s response: %w", endpoint, err)
+	}
+	return resp, nil
+}
+
+// get
+// Issues an authenticated GET against the v1 API. The caller closes the body.
+// 401, 403 and 404 are reported as codehost.ErrAccessDenied, since Gitea hides
+// private repositories behind 404.
+func (ch *CodeHost) get(ctx context.Context, endpoint string, params url.Values) (*http.Response, error) {
+	target := ch.baseURL.String() + "/api/v1" + endpoint
+	if len(params) > 0 {
+		target += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "token "+ch.token)
+
+	resp, err := ch.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusNotFound:
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: gitea responded %d", codehost.ErrAccessDenied, resp.StatusCode)
+	case resp.StatusCode >= 300:
+		resp.Body.Close()
+		return nil, fmt.Errorf("gitea %s responded %d", endpoint, resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// repoPath
+// The owner and name of the repository at repoURL, codehost.ErrInvalidRepoURL when it
+// is not a repository of this instance and codehost.ErrAccessDenied when the service
+// token may not be used for it
+func (ch *CodeHost) repoPath(repoURL string) (owner, repoName string, err error) {
+	owner, repoName, err = ch.parseRepoURL(repoURL)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %v", codehost.ErrInvalidRepoURL, err)
+	}
+	if ch.allowedRepos != nil && !ch.allowedRepos[strings.ToLower(owner+"/"+repoName)] {
+		return "", "", fmt.Errorf("%w: %s/%s is not a service token repository", codehost.ErrAccessDenied, owner, repoName)
+	}
+	return owner, repoName, nil
+}
+
+func (ch *CodeHost) parseRepoURL(repoURL string) (owner, repoName string, err error) {
+	parsed, err := url.Parse(repoURL)
+	if err != nil {
+		return "", "", err
+	}
+
+	if !strings.EqualFold(parsed.Host, ch.baseURL.Host) {
+		return "", "", fmt.Errorf("url '%s' is not on %s", repoURL, ch.baseURL.Host)
+	}
+
+	path := strings.TrimPrefix(parsed.Path, strings.TrimSuffix(ch.baseURL.Path, "/"))
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("url '%s' has invalid path", repoURL)
+	}
+
+	return parts[0], strings.TrimSuffix(parts[1], ".git"), nil
+}
+
+func repoEndpoint(owner, repoName string) string {
+	return "/repos/" + url.PathEscape(owner) + "/" + url.PathEscape(repoName)
+}
@@ -0,0 +1,316 @@
+package gitea
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+const validToken = "gitea-valid"
+
+// fakeGitea serves octo/timeline with five commits, newest first, in pages of
+// pageSize. c3 is a merge commit.
+type fakeGitea struct {
+	pageSize    int
+	linkHeaders bool
+	commitPages int
+}
+
+type fakeCommit struct {
+	SHA     string
+	Parents []string
+	Date    time.Time
+}
+
+var fakeCommits = func() []fakeCommit {
+	date := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	return []fakeCommit{
+		{"c5", []string{"c4"}, date.Add(4 * time.Hour)},
+		{"c4", []string{"c3"}, date.Add(3 * time.Hour)},
+		{"c3", []string{"c2", "b1"}, date.Add(2 * time.Hour)},
+		{"c2", []string{"c1"}, date.Add(time.Hour)},
+		{"c1", nil, date},
+	}
+}()
+
+func (f *fakeGitea) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "token "+validToken {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/api/v1/user":
+		writeJSON(w, map[string]any{"id": 3, "login": "octo", "full_name": "Octo Cat", "avatar_url": "https://gitea.example/avatar", "email": "octo@example.com"})
+	case "/api/v1/repos/search":
+		data := []map[string]any{}
+		if strings.Contains("octo/timeline", r.URL.Query().Get("q")) {
+			data = append(data, map[string]any{"id": 9, "full_name": "octo/timeline", "html_url": "https://gitea.example/octo/timeline"})
+		}
+		writeJSON(w, map[string]any{"ok": true, "data": data})
+	case "/api/v1/repos/octo/timeline":
+		writeJSON(w, map[string]any{"id": 9, "full_name": "octo/timeline", "html_url": "https://gitea.example/octo/timeline"})
+	case "/api/v1/repos/octo/timeline/commits":
+		f.writeCommitsPage(w, r)
+	case "/api/v1/repos/octo/timeline/git/commits/c5":
+		writeJSON(w, map[string]any{
+			"sha":     "c5",
+			"parents": []map[string]string{{"sha": "c4"}},
+			"author":  map[string]any{"login": "octo", "avatar_url": "https://gitea.example/avatar"},
+			"commit":  map[string]any{"message": "Add entrypoint", "author": map[string]any{"name": "Octo Cat", "email": "octo@example.com"}},
+		})
+	case "/api/v1/repos/octo/timeline/commits/c5/pull":
+		writeJSON(w, map[string]any{"number": 3, "title": "Add entrypoint", "html_url": "https://gitea.example/octo/timeline/pulls/3", "merged_at": "2025-01-16T08:00:00Z"})
+	case "/api/v1/repos/octo/timeline/git/commits/c5.diff":
+		_, _ = fmt.Fprint(w, "diff --git a/main.go b/main.go\nnew file mode 100644\n--- /dev/null\n+++ b/main.go\n@@ -0,0 +1 @@\n+func main() {}\n")
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeGitea) writeCommitsPage(w http.ResponseWriter, r *http.Request) {
+	f.commitPages++
+	if r.URL.Query().Get("stat") != "false" || r.URL.Query().Get("files") != "false" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	start := min((page-1)*f.pageSize, len(fakeCommits))
+	end := min(start+f.pageSize, len(fakeCommits))
+
+	if f.linkHeaders && end < len(fakeCommits) {
+		w.Header().Set("Link", fmt.Sprintf(`<%s?page=%d>; rel="next"`, r.URL.Path, page+1))
+	}
+	if !f.linkHeaders {
+		w.Header().Set("X-HasMore", strconv.FormatBool(end < len(fakeCommits)))
+	}
+
+	var body []map[string]any
+	for _, c := range fakeCommits[start:end] {
+		var parents []map[string]string
+		for _, p := range c.Parents {
+			parents = append(parents, map[string]string{"sha": p})
+		}
+		body = append(body, map[string]any{
+			"sha":     c.SHA,
+			"parents": parents,
+			"commit":  map[string]any{"committer": map[string]any{"date": c.Date}},
+		})
+	}
+	writeJSON(w, body)
+}
+
+func writeJSON(w http.ResponseWriter, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+type GiteaCodeHostTestSuite struct {
+	suite.Suite
+	fake     *fakeGitea
+	server   *httptest.Server
+	factory  *CodeHostFactory
+	codeHost codehost.CodeHost
+	repoURL  string
+}
+
+func TestGiteaCodeHostTestSuite(t *testing.T) {
+	suite.Run(t, new(GiteaCodeHostTestSuite))
+}
+
+func (s *GiteaCodeHostTestSuite) SetupTest() {
+	s.fake = &fakeGitea{pageSize: 2}
+	s.server = httptest.NewServer(s.fake)
+	s.T().Cleanup(s.server.Close)
+
+	var err error
+	s.factory, err = NewCodeHostFactory(s.server.URL, "", nil, s.server.Client())
+	require.NoError(s.T(), err)
+	s.codeHost, err = s.factory.Create(context.Background(), validToken)
+	require.NoError(s.T(), err)
+	s.repoURL = s.server.URL + "/octo/timeline"
+}
+
+func (s *GiteaCodeHostTestSuite) collectCommits(r *repo.Repo) ([]string, string, error) {
+	commits := make(chan codehost.CommitReference, 100)
+	headSHA, err := s.codeHost.GetRepoCommitSHAsIntoChannel(context.Background(), r, commits)
+	close(commits)
+
+	var shas []string
+	for ref := range commits {
+		assert.False(s.T(), ref.CommittedAt.IsZero())
+		shas = append(shas, ref.SHA)
+	}
+	return shas, headSHA, err
+}
+
+func (s *GiteaCodeHostTestSuite) TestFactoryRequiresBaseURL() {
+	_, err := NewCodeHostFactory("", "", nil, nil)
+	assert.NotNil(s.T(), err)
+}
+
+func (s *GiteaCodeHostTestSuite) TestCannotCreateWithoutToken() {
+	_, err := s.factory.Create(context.Background(), "")
+	assert.True(s.T(), errors.Is(err, codehost.ErrAccessDenied))
+}
+
+func (s *GiteaCodeHostTestSuite) TestFactoryRejectsServiceTokenWithoutRepos() {
+	_, err := NewCodeHostFactory(s.server.URL, validToken, nil, s.server.Client())
+	assert.NotNil(s.T(), err)
+}
+
+func (s *GiteaCodeHostTestSuite) TestCallerTokenIsPreferredToServiceToken() {
+	factory, _ := NewCodeHostFactory(s.server.URL, "gitea-service", []string{"octo/timeline"}, s.server.Client())
+	codeHost, _ := factory.Create(context.Background(), validToken)
+
+	assert.Nil(s.T(), codeHost.CanAccessRepo(context.Background(), s.repoURL))
+}
+
+func (s *GiteaCodeHostTestSuite) TestServiceTokenOnlyReachesItsRepos() {
+	factory, _ := NewCodeHostFactory(s.server.URL, validToken, []string{"Octo/Timeline"}, s.server.Client())
+	codeHost, err := factory.Create(context.Background(), "")
+	require.NoError(s.T(), err)
+
+	assert.Nil(s.T(), codeHost.CanAccessRepo(context.Background(), s.repoURL))
+
+	factory, _ = NewCodeHostFactory(s.server.URL, validToken, []string{"octo/other"}, s.server.Client())
+	codeHost, _ = factory.Create(context.Background(), "")
+
+	err = codeHost.CanAccessRepo(context.Background(), s.repoURL)
+	assert.True(s.T(), errors.Is(err, codehost.ErrAccessDenied))
+	_, err = codeHost.GetCommitDiff(context.Background(), repo.NewRepo(1, "octo/timeline", s.repoURL, "", time.Time{}), "c5")
+	assert.True(s.T(), errors.Is(err, codehost.ErrAccessDenied))
+	results, _ := codeHost.SearchRepositories(context.Background(), "timeline")
+	assert.Empty(s.T(), results)
+	_, err = codeHost.GetAuthenticatedUser(context.Background())
+	assert.True(s.T(), errors.Is(err, codehost.ErrAccessDenied))
+}
+
+func (s *GiteaCodeHostTestSuite) TestCanAccessRepo() {
+	assert.Nil(s.T(), s.codeHost.CanAccessRepo(context.Background(), s.repoURL))
+	assert.Nil(s.T(), s.codeHost.CanAccessRepo(context.Background(), s.repoURL+".git"))
+}
+
+func (s *GiteaCodeHostTestSuite) TestUnknownRepoIsAccessDenied() {
+	err := s.codeHost.CanAccessRepo(context.Background(), s.server.URL+"/octo/private")
+	assert.True(s.T(), errors.Is(err, codehost.ErrAccessDenied))
+}
+
+func (s *GiteaCodeHostTestSuite) TestInvalidTokenIsAccessDenied() {
+	codeHost, _ := s.factory.Create(context.Background(), "wrong")
+	err := codeHost.CanAccessRepo(context.Background(), s.repoURL)
+
+	assert.True(s.T(), errors.Is(err, codehost.ErrAccessDenied))
+}
+
+func (s *GiteaCodeHostTestSuite) TestURLOnAnotherHostIsInvalid() {
+	err := s.codeHost.CanAccessRepo(context.Background(), "https://github.com/octo/timeline")
+	assert.True(s.T(), errors.Is(err, codehost.ErrInvalidRepoURL))
+}
+
+func (s *GiteaCodeHostTestSuite) TestCreateRepoFromURL() {
+	r, err := s.codeHost.CreateRepoFromURL(context.Background(), s.repoURL)
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), "octo/timeline", r.Name())
+	assert.NotEqual(s.T(), int64(9), r.ID())
+	assert.Positive(s.T(), r.ID())
+}
+
+func (s *GiteaCodeHostTestSuite) TestSendsAllPagesSkippingMerges() {
+	shas, headSHA, err := s.collectCommits(repo.NewRepo(1, "octo/timeline", s.repoURL, "", time.Time{}))
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), "c5", headSHA)
+	assert.Equal(s.T(), []string{"c5", "c4", "c2", "c1"}, shas)
+	assert.Equal(s.T(), 3, s.fake.commitPages)
+}
+
+func (s *GiteaCodeHostTestSuite) TestFollowsLinkHeaderPagination() {
+	s.fake.linkHeaders = true
+	shas, _, err := s.collectCommits(repo.NewRepo(1, "octo/timeline", s.repoURL, "", time.Time{}))
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), []string{"c5", "c4", "c2", "c1"}, shas)
+}
+
+func (s *GiteaCodeHostTestSuite) TestStopsAtLastAnalyzedCommitWithoutFetchingFurtherPages() {
+	shas, headSHA, err := s.collectCommits(repo.NewRepo(1, "octo/timeline", s.repoURL, "c4", time.Time{}))
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), "c5", headSHA)
+	assert.Equal(s.T(), []string{"c5"}, shas)
+	assert.Equal(s.T(), 1, s.fake.commitPages)
+}
+
+func (s *GiteaCodeHostTestSuite) TestNothingNewSinceLastAnalyzedCommit() {
+	shas, headSHA, err := s.collectCommits(repo.NewRepo(1, "octo/timeline", s.repoURL, "c5", time.Time{}))
+
+	assert.Nil(s.T(), err)
+	assert.Empty(s.T(), shas)
+	assert.Equal(s.T(), "", headSHA)
+}
+
+func (s *GiteaCodeHostTestSuite) TestCommitDiff() {
+	diff, err := s.codeHost.GetCommitDiff(context.Background(), repo.NewRepo(1, "octo/timeline", s.repoURL, "", time.Time{}), "c5")
+
+	assert.Nil(s.T(), err)
+	assert.Contains(s.T(), diff.Text, "+func main() {}")
+	assert.Equal(s.T(), "Add entrypoint", diff.Message)
+	assert.Equal(s.T(), codehost.CommitAuthor{Name: "Octo Cat", Email: "octo@example.com", Login: "octo", AvatarURL: "https://gitea.example/avatar"}, diff.Author)
+	assert.Equal(s.T(), []string{"c4"}, diff.Parents)
+	assert.Equal(s.T(), []codehost.FileStat{{Path: "main.go", Status: codehost.FileAdded, Additions: 1}}, diff.Files)
+}
+
+func (s *GiteaCodeHostTestSuite) TestCommitPullRequest() {
+	r := repo.NewRepo(1, "octo/timeline", s.repoURL, "", time.Time{})
+	pr, err := s.codeHost.GetCommitPullRequest(context.Background(), r, "c5")
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), &codehost.PullRequest{Number: 3, Title: "Add entrypoint", URL: "https://gitea.example/octo/timeline/pulls/3", MergedAt: time.Date(2025, 1, 16, 8, 0, 0, 0, time.UTC)}, pr)
+
+	pr, err = s.codeHost.GetCommitPullRequest(context.Background(), r, "c4")
+	assert.Nil(s.T(), err)
+	assert.Nil(s.T(), pr)
+}
+
+func (s *GiteaCodeHostTestSuite) TestAuthenticatedUser() {
+	profile, err := s.codeHost.GetAuthenticatedUser(context.Background())
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), "octo", profile.Login)
+	assert.Equal(s.T(), "Octo Cat", profile.Name)
+}
+
+func (s *GiteaCodeHostTestSuite) TestSearchRepositories() {
+	results, err := s.codeHost.SearchRepositories(context.Background(), "timeline")
+
+	assert.Nil(s.T(), err)
+	assert.Len(s.T(), results, 1)
+	assert.Equal(s.T(), "octo/timeline", results[0].Name)
+
+	results, _ = s.codeHost.SearchRepositories(context.Background(), "unrelated")
+	assert.Empty(s.T(), results)
+}
+
+func (s *GiteaCodeHostTestSuite) TestStopsSendingWhenCancelled() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := s.codeHost.GetRepoCommitSHAsIntoChannel(ctx, repo.NewRepo(1, "octo/timeline", s.repoURL, "", time.Time{}), make(chan codehost.CommitReference))
+	assert.True(s.T(), errors.Is(err, context.DeadlineExceeded))
+}
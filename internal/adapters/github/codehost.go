@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"net/url"
 	"strings"
 	"time"
@@ -15,10 +16,55 @@ import (
 	"golang.org/x/oauth2"
 )
 
-type CodeHostFactory struct{}
+const PublicHost = "github.com"
 
-func NewGithubCodeHostFactory() *CodeHostFactory {
-	return &CodeHostFactory{}
+type CodeHostFactory struct {
+	host       string
+	apiURL     string
+	uploadURL  string
+	httpClient *http.Client
+}
+
+// NewGithubCodeHostFactory
+// httpClient carries the requests underneath the OAuth token, nil for http.DefaultClient
+func NewGithubCodeHostFactory(httpClient *http.Client) *CodeHostFactory {
+	return &CodeHostFactory{host: PublicHost, httpClient: httpClient}
+}
+
+// NewGithubEnterpriseCodeHostFactory
+// Serves repositories of the GitHub Enterprise Server at host. apiURL and uploadURL
+// default to the standard https://host/api/v3/ and https://host/api/uploads/.
+func NewGithubEnterpriseCodeHostFactory(host, apiURL, uploadURL string, httpClient *http.Client) (*CodeHostFactory, error) {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if host == "" || strings.Contains(host, "/") {
+		return nil, fmt.Errorf("invalid GitHub Enterprise host %q", host)
+	}
+
+	if apiURL == "" {
+		apiURL = "https://" + host + "/api/v3/"
+	}
+	if uploadURL == "" {
+		uploadURL = "https://" + host + "/api/uploads/"
+	}
+	for _, endpoint := range []string{apiURL, uploadURL} {
+		if parsed, err := url.Parse(endpoint); err != nil || parsed.Host == "" {
+			return nil, fmt.Errorf("invalid GitHub Enterprise API URL %q", endpoint)
+		}
+	}
+
+	return &CodeHostFactory{host: host, apiURL: apiURL, uploadURL: uploadURL, httpClient: httpClient}, nil
+}
+
+// Host
+// The URL host repositories of this factory are served from
+func (f *CodeHostFactory) Host() string {
+	return f.host
+}
+
+// IsEnterprise
+// Whether the factory serves a GitHub Enterprise Server rather than github.com
+func (f *CodeHostFactory) IsEnterprise() bool {
+	return f.apiURL != ""
 }
 
 func (f *CodeHostFactory) Create(ctx context.Context, accessToken string) (codehost.CodeHost, error) {
@@ -27,20 +73,35 @@ func (f *CodeHostFactory) Create(ctx context.Context, accessToken string) (codeh
 		return nil, codehost.ErrAccessDenied
 	}
 
+	if f.httpClient != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, f.httpClient)
+	}
+
 	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: accessToken})
 	tc := oauth2.NewClient(ctx, ts)
-	client := github.NewClient(tc)
 
-	slog.Debug("GitHub code host client created")
-	return &CodeHost{client: client}, nil
+	if !f.IsEnterprise() {
+		slog.Debug("GitHub code host client created")
+		return &CodeHost{client: github.NewClient(tc), host: f.host}, nil
+	}
+
+	client, err := github.NewEnterpriseClient(f.apiURL, f.uploadURL, tc)
+	if err != nil {
+		slog.Error("Failed to create GitHub Enterprise client", "host", f.host, "error", err)
+		return nil, err
+	}
+
+	slog.Debug("GitHub Enterprise code host client created", "host", f.host, "api_url", f.apiURL)
+	return &CodeHost{client: client, host: f.host}, nil
 }
 
 type CodeHost struct {
 	client *github.Client
+	host   string
 }
 
 func (ch *CodeHost) CanAccessRepo(ctx context.Context, repoURL string) error {
-	owner, repoName, err := parseRepoURL(repoURL)
+	owner, repoName, err := ch.parseRepoURL(repoURL)
 	if err != nil {
 		slog.Warn("Invalid repo URL for access check", "repo_url", repoURL, "error", err)
 		return codehost.ErrInvalidRepoURL
@@ -62,7 +123,7 @@ func (ch *CodeHost) CanAccessRepo(ctx context.Context, repoURL string) error {
 }
 
 func (ch *CodeHost) CreateRepoFromURL(ctx context.Context, repoURL string) (*repo.Repo, error) {
-	owner, repoName, err := parseRepoURL(repoURL)
+	owner, repoName, err := ch.parseRepoURL(repoURL)
 	if err != nil {
 		return nil, codehost.ErrInvalidRepoURL
 	}
@@ -74,8 +135,9 @@ func (ch *CodeHost) CreateRepoFromURL(ctx context.Context, repoURL string) (*rep
 		return nil, err
 	}
 
-	slog.Info("GitHub repo metadata fetched", "repo_id", *ghRepo.ID, "full_name", *ghRepo.FullName)
-	return repo.NewRepo(*ghRepo.ID, *ghRepo.FullName, repoURL, "", time.Now()), nil
+	id := ch.repoID(*ghRepo.ID)
+	slog.Info("GitHub repo metadata fetched", "repo_id", id, "full_name", *ghRepo.FullName)
+	return repo.NewRepo(id, *ghRepo.FullName, repoURL, "", time.Now()), nil
 }
 
 func (ch *CodeHost) GetAuthenticatedUser(ctx context.Context) (*codehost.UserProfile, error) {
@@ -125,7 +187,7 @@ func (ch *CodeHost) SearchRepositories(ctx context.Context, query string) ([]cod
 			continue
 		}
 		results = append(results, codehost.RepoSearchResult{
-			ID:   int64(*r.ID),
+			ID:   ch.repoID(*r.ID),
 			Name: *r.FullName,
 			URL:  *r.HTMLURL,
 		})
@@ -135,7 +197,7 @@ func (ch *CodeHost) SearchRepositories(ctx context.Context, query string) ([]cod
 }
 
 func (ch *CodeHost) GetRepoCommitSHAsIntoChannel(ctx context.Context, r *repo.Repo, commits chan<- codehost.CommitReference) (string, error) {
-	owner, repoName, err := parseRepoURL(r.URL())
+	owner, repoName, err := ch.parseRepoURL(r.URL())
 	if err != nil {
 		return "", codehost.ErrInvalidRepoURL
 	}
@@ -176,16 +238,17 @@ func (ch *CodeHost) GetRepoCommitSHAsIntoChannel(ctx context.Context, r *repo.Re
 				continue
 			}
 
-			ref := codehost.CommitReference{SHA: *commit.SHA}
-			if commit.Commit != nil && commit.Commit.Committer != nil && commit.Commit.Committer.Date != nil {
-				ref.CommittedAt = *commit.Commit.Committer.Date
-			}
+			ref := commitReference(commit)
 
 			if headSHA == "" {
 				headSHA = ref.SHA
 			}
-			sentCount++
-			commits <- ref
+			select {
+			case commits <- ref:
+				sentCount++
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
 		}
 
 		if resp.NextPage == 0 {
@@ -198,10 +261,10 @@ func (ch *CodeHost) GetRepoCommitSHAsIntoChannel(ctx context.Context, r *repo.Re
 	return headSHA, nil
 }
 
-func (ch *CodeHost) GetCommitDiff(ctx context.Context, r *repo.Repo, commitSHA string) (string, error) {
-	owner, repoName, err := parseRepoURL(r.URL())
+func (ch *CodeHost) GetCommitDiff(ctx context.Context, r *repo.Repo, commitSHA string) (*codehost.CommitDiff, error) {
+	owner, repoName, err := ch.parseRepoURL(r.URL())
 	if err != nil {
-		return "", codehost.ErrInvalidRepoURL
+		return nil, codehost.ErrInvalidRepoURL
 	}
 
 	slog.Debug("Fetching commit diff", "owner", owner, "repo", repoName, "commit_sha", commitSHA)
@@ -209,28 +272,100 @@ func (ch *CodeHost) GetCommitDiff(ctx context.Context, r *repo.Repo, commitSHA s
 	commit, _, err := ch.client.Repositories.GetCommit(ctx, owner, repoName, commitSHA)
 	if err != nil {
 		slog.Error("Failed to fetch commit diff from GitHub", "owner", owner, "repo", repoName, "commit_sha", commitSHA, "error", err)
-		return "", err
+		return nil, err
 	}
 
-	var diff string
+	diff := &codehost.CommitDiff{CommitReference: commitReference(commit)}
 	for _, file := range commit.Files {
 		if file.Patch != nil {
-			diff += fmt.Sprintf("File: %s\n%s\n\n", *file.Filename, *file.Patch)
+			diff.Text += fmt.Sprintf("File: %s\n%s\n\n", file.GetFilename(), file.GetPatch())
 		}
+		diff.Files = append(diff.Files, codehost.FileStat{
+			Path:      file.GetFilename(),
+			Status:    fileStatus(file.GetStatus()),
+			Additions: file.GetAdditions(),
+			Deletions: file.GetDeletions(),
+		})
 	}
 
-	slog.Debug("Commit diff fetched", "commit_sha", commitSHA, "files_count", len(commit.Files), "diff_length", len(diff))
+	slog.Debug("Commit diff fetched", "commit_sha", commitSHA, "files_count", len(commit.Files), "diff_length", len(diff.Text))
 	return diff, nil
 }
 
-func parseRepoURL(repoURL string) (owner, repoName string, err error) {
+// GetCommitPullRequest
+// go-github v17 predates "list pull requests associated with a commit", so the request
+// is built by hand. Older Enterprise servers still need the preview media type.
+func (ch *CodeHost) GetCommitPullRequest(ctx context.Context, r *repo.Repo, commitSHA string) (*codehost.PullRequest, error) {
+	owner, repoName, err := ch.parseRepoURL(r.URL())
+	if err != nil {
+		return nil, codehost.ErrInvalidRepoURL
+	}
+
+	req, err := ch.client.NewRequest(http.MethodGet, fmt.Sprintf("repos/%s/%s/commits/%s/pulls", owner, repoName, url.PathEscape(commitSHA)), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github.groot-preview+json")
+
+	var pulls []*github.PullRequest
+	if _, err := ch.client.Do(ctx, req, &pulls); err != nil {
+		slog.Error("Failed to fetch pull requests of commit from GitHub", "owner", owner, "repo", repoName, "commit_sha", commitSHA, "error", err)
+		return nil, err
+	}
+
+	pullRequests := make([]codehost.PullRequest, len(pulls))
+	for i, pull := range pulls {
+		pullRequests[i] = codehost.PullRequest{
+			Number:   pull.GetNumber(),
+			Title:    pull.GetTitle(),
+			Body:     pull.GetBody(),
+			URL:      pull.GetHTMLURL(),
+			MergedAt: pull.GetMergedAt(),
+		}
+	}
+	return codehost.PreferredPullRequest(pullRequests), nil
+}
+
+func commitReference(commit *github.RepositoryCommit) codehost.CommitReference {
+	ref := codehost.CommitReference{SHA: commit.GetSHA()}
+	if commit.Commit != nil {
+		ref.CommittedAt = commit.Commit.GetCommitter().GetDate()
+		ref.Message = commit.Commit.GetMessage()
+		ref.Author = codehost.CommitAuthor{Name: commit.Commit.GetAuthor().GetName(), Email: commit.Commit.GetAuthor().GetEmail()}
+	}
+	// The account GitHub matched the commit email to, nil for unknown emails
+	if commit.Author != nil {
+		ref.Author.Login = commit.Author.GetLogin()
+		ref.Author.AvatarURL = commit.Author.GetAvatarURL()
+	}
+	for _, parent := range commit.Parents {
+		ref.Parents = append(ref.Parents, parent.GetSHA())
+	}
+	return ref
+}
+
+// fileStatus
+// GitHub also reports copied, changed and unchanged files, which are modifications here
+func fileStatus(status string) codehost.FileStatus {
+	switch status {
+	case "added":
+		return codehost.FileAdded
+	case "removed":
+		return codehost.FileRemoved
+	case "renamed":
+		return codehost.FileRenamed
+	}
+	return codehost.FileModified
+}
+
+func (ch *CodeHost) parseRepoURL(repoURL string) (owner, repoName string, err error) {
 	parsed, err := url.Parse(repoURL)
 	if err != nil {
 		return "", "", err
 	}
 
-	if parsed.Host != "github.com" {
-		return "", "", fmt.Errorf("url '%s' is not github.com", repoURL)
+	if !strings.EqualFold(parsed.Host, ch.host) {
+		return "", "", fmt.Errorf("url '%s' is not %s", repoURL, ch.host)
 	}
 
 	parts := strings.Split(strings.TrimPrefix(parsed.Path, "/"), "/")
@@ -240,3 +375,13 @@ func parseRepoURL(repoURL string) (owner, repoName string, err error) {
 
 	return parts[0], strings.TrimSuffix(parts[1], ".git"), nil
 }
+
+// repoID
+// Repository IDs of an enterprise server are sequential per instance and would collide
+// with github.com ones, so they are namespaced by host. github.com IDs are kept as is.
+func (ch *CodeHost) repoID(id int64) int64 {
+	if ch.host == PublicHost {
+		return id
+	}
+	return codehost.HostRepoID(ch.host, id)
+}
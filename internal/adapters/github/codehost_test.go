@@ -0,0 +1,204 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/octokerbs/chronocode/internal/adapters/retry"
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// fakeEnterprise answers the v3 API under /api/v3/ like a GitHub Enterprise Server
+func fakeEnterprise() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v3/repos/platform/api", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer ghes-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": 12, "full_name": "platform/api"})
+	})
+	mux.HandleFunc("GET /api/v3/repos/platform/api/commits", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode([]map[string]any{
+			{"sha": "c5", "parents": []map[string]string{{"sha": "c4"}}, "commit": map[string]any{"committer": map[string]any{"date": "2025-01-15T10:00:00Z"}}},
+			{"sha": "c4", "commit": map[string]any{"committer": map[string]any{"date": "2025-01-14T10:00:00Z"}}},
+		})
+	})
+	mux.HandleFunc("GET /api/v3/repos/platform/api/commits/c5", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"sha":     "c5",
+			"parents": []map[string]string{{"sha": "c4"}},
+			"author":  map[string]any{"login": "octocat", "avatar_url": "https://avatars.example/octocat"},
+			"commit": map[string]any{
+				"message":   "Add entrypoint",
+				"author":    map[string]any{"name": "Octo Cat", "email": "octo@example.com"},
+				"committer": map[string]any{"date": "2025-01-15T10:00:00Z"},
+			},
+			"files": []map[string]any{{"filename": "main.go", "status": "added", "additions": 1, "deletions": 0, "patch": "@@ -0,0 +1 @@\n+func main() {}"}},
+		})
+	})
+	mux.HandleFunc("GET /api/v3/repos/platform/api/commits/c5/pulls", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode([]map[string]any{
+			{"number": 7, "title": "Try entrypoint", "html_url": "https://ghes.example/platform/api/pull/7"},
+			{"number": 8, "title": "Add entrypoint", "body": "Bootstraps the service", "html_url": "https://ghes.example/platform/api/pull/8", "merged_at": "2025-01-16T08:00:00Z"},
+		})
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	return mux
+}
+
+// injectFailures answers the first requests with failures, one per request, before
+// handing over to next
+func injectFailures(next http.Handler, failures ...func(w http.ResponseWriter)) http.Handler {
+	var requests atomic.Int32
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if n := int(requests.Add(1)); n <= len(failures) {
+			failures[n-1](w)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+type GithubEnterpriseCodeHostTestSuite struct {
+	suite.Suite
+	server   *httptest.Server
+	host     string
+	codeHost codehost.CodeHost
+}
+
+func TestGithubEnterpriseCodeHostTestSuite(t *testing.T) {
+	suite.Run(t, new(GithubEnterpriseCodeHostTestSuite))
+}
+
+func (s *GithubEnterpriseCodeHostTestSuite) SetupTest() {
+	s.server = httptest.NewServer(fakeEnterprise())
+	s.T().Cleanup(s.server.Close)
+
+	serverURL, _ := url.Parse(s.server.URL)
+	s.host = serverURL.Host
+
+	factory, err := NewGithubEnterpriseCodeHostFactory(s.host, s.server.URL+"/api/v3/", s.server.URL+"/api/uploads/", nil)
+	require.NoError(s.T(), err)
+	s.codeHost, err = factory.Create(context.Background(), "ghes-token")
+	require.NoError(s.T(), err)
+}
+
+func (s *GithubEnterpriseCodeHostTestSuite) TestPublicFactoryIsNotEnterprise() {
+	factory := NewGithubCodeHostFactory(nil)
+
+	assert.False(s.T(), factory.IsEnterprise())
+	assert.Equal(s.T(), PublicHost, factory.Host())
+}
+
+func (s *GithubEnterpriseCodeHostTestSuite) TestEnterpriseURLsDefaultToHost() {
+	factory, err := NewGithubEnterpriseCodeHostFactory("GitHub.Corp.Example", "", "", nil)
+
+	assert.Nil(s.T(), err)
+	assert.True(s.T(), factory.IsEnterprise())
+	assert.Equal(s.T(), "github.corp.example", factory.Host())
+	assert.Equal(s.T(), "https://github.corp.example/api/v3/", factory.apiURL)
+	assert.Equal(s.T(), "https://github.corp.example/api/uploads/", factory.uploadURL)
+}
+
+func (s *GithubEnterpriseCodeHostTestSuite) TestEnterpriseFactoryRejectsInvalidHost() {
+	_, err := NewGithubEnterpriseCodeHostFactory("", "", "", nil)
+	assert.NotNil(s.T(), err)
+
+	_, err = NewGithubEnterpriseCodeHostFactory("https://github.corp.example", "", "", nil)
+	assert.NotNil(s.T(), err)
+}
+
+func (s *GithubEnterpriseCodeHostTestSuite) TestCanAccessEnterpriseRepo() {
+	assert.Nil(s.T(), s.codeHost.CanAccessRepo(context.Background(), "http://"+s.host+"/platform/api"))
+}
+
+func (s *GithubEnterpriseCodeHostTestSuite) TestMissingEnterpriseRepoIsAccessDenied() {
+	err := s.codeHost.CanAccessRepo(context.Background(), "http://"+s.host+"/platform/secret")
+	assert.True(s.T(), errors.Is(err, codehost.ErrAccessDenied))
+}
+
+func (s *GithubEnterpriseCodeHostTestSuite) TestGithubDotComURLIsInvalidOnEnterprise() {
+	err := s.codeHost.CanAccessRepo(context.Background(), "https://github.com/platform/api")
+	assert.True(s.T(), errors.Is(err, codehost.ErrInvalidRepoURL))
+}
+
+func (s *GithubEnterpriseCodeHostTestSuite) TestEnterpriseRepoIDsAreNamespacedByHost() {
+	r, err := s.codeHost.CreateRepoFromURL(context.Background(), "http://"+s.host+"/platform/api")
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), "platform/api", r.Name())
+	assert.NotEqual(s.T(), int64(12), r.ID())
+	assert.Positive(s.T(), r.ID())
+}
+
+func (s *GithubEnterpriseCodeHostTestSuite) TestGithubDotComRepoIDsAreKept() {
+	ch := &CodeHost{host: PublicHost}
+	assert.Equal(s.T(), int64(12), ch.repoID(12))
+}
+
+func (s *GithubEnterpriseCodeHostTestSuite) TestCommitDiffCarriesAuthorAccount() {
+	r := repo.NewRepo(12, "platform/api", "http://"+s.host+"/platform/api", "", time.Time{})
+	diff, err := s.codeHost.GetCommitDiff(context.Background(), r, "c5")
+
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "Add entrypoint", diff.Message)
+	assert.Equal(s.T(), []string{"c4"}, diff.Parents)
+	assert.Equal(s.T(), codehost.CommitAuthor{Name: "Octo Cat", Email: "octo@example.com", Login: "octocat", AvatarURL: "https://avatars.example/octocat"}, diff.Author)
+	assert.Equal(s.T(), []codehost.FileStat{{Path: "main.go", Status: codehost.FileAdded, Additions: 1}}, diff.Files)
+	assert.Equal(s.T(), "File: main.go\n@@ -0,0 +1 @@\n+func main() {}\n\n", diff.Text)
+}
+
+func (s *GithubEnterpriseCodeHostTestSuite) TestCommitPullRequestIsTheMergedOne() {
+	r := repo.NewRepo(12, "platform/api", "http://"+s.host+"/platform/api", "", time.Time{})
+	pr, err := s.codeHost.GetCommitPullRequest(context.Background(), r, "c5")
+
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), &codehost.PullRequest{
+		Number: 8, Title: "Add entrypoint", Body: "Bootstraps the service",
+		URL: "https://ghes.example/platform/api/pull/8", MergedAt: time.Date(2025, 1, 16, 8, 0, 0, 0, time.UTC),
+	}, pr)
+}
+
+func (s *GithubEnterpriseCodeHostTestSuite) TestRetriesUnavailableAndSecondaryRateLimit() {
+	server := httptest.NewServer(injectFailures(fakeEnterprise(),
+		func(w http.ResponseWriter) { w.WriteHeader(http.StatusBadGateway) },
+		func(w http.ResponseWriter) {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusForbidden)
+		},
+	))
+	s.T().Cleanup(server.Close)
+	serverURL, _ := url.Parse(server.URL)
+
+	policy := retry.Policy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+	factory, _ := NewGithubEnterpriseCodeHostFactory(serverURL.Host, server.URL+"/api/v3/", server.URL+"/api/uploads/", policy.Wrap(server.Client()))
+	codeHost, _ := factory.Create(context.Background(), "ghes-token")
+
+	assert.Nil(s.T(), codeHost.CanAccessRepo(context.Background(), "http://"+serverURL.Host+"/platform/api"))
+}
+
+func (s *GithubEnterpriseCodeHostTestSuite) TestStopsSendingWhenCancelled() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := s.codeHost.GetRepoCommitSHAsIntoChannel(ctx, repo.NewRepo(12, "platform/api", "http://"+s.host+"/platform/api", "", time.Time{}), make(chan codehost.CommitReference))
+	assert.True(s.T(), errors.Is(err, context.DeadlineExceeded))
+}
@@ -0,0 +1,448 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+)
+
+const DefaultBaseURL = "https://gitlab.com"
+
+type CodeHostFactory struct {
+	baseURL         *url.URL
+	serviceToken    string
+	serviceProjects map[string]bool
+	httpClient      *http.Client
+}
+
+// NewCodeHostFactory
+// Talks to the GitLab instance at baseURL with the caller's own GitLab token. Callers
+// without one fall back to serviceToken, when set, which only reaches serviceProjects:
+// everyone signed in shares it, so it must not open every project it can see.
+func NewCodeHostFactory(baseURL, serviceToken string, serviceProjects []string, httpClient *http.Client) (*CodeHostFactory, error) {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	parsed, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return nil, fmt.Errorf("invalid GitLab base URL %q", baseURL)
+	}
+
+	allowed := make(map[string]bool, len(serviceProjects))
+	for _, projectPath := range serviceProjects {
+		if projectPath = strings.Trim(strings.TrimSpace(projectPath), "/"); projectPath != "" {
+			allowed[strings.ToLower(projectPath)] = true
+		}
+	}
+	if serviceToken != "" && len(allowed) == 0 {
+		return nil, errors.New("a GitLab service token needs the projects it may be used for")
+	}
+
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 60 * time.Second}
+	}
+
+	return &CodeHostFactory{baseURL: parsed, serviceToken: serviceToken, serviceProjects: allowed, httpClient: httpClient}, nil
+}
+
+// Host
+// The URL host repositories of this instance are served from
+func (f *CodeHostFactory) Host() string {
+	return f.baseURL.Host
+}
+
+// Create
+// accessToken must be the caller's GitLab token, never the one they signed in with
+func (f *CodeHostFactory) Create(ctx context.Context, accessToken string) (codehost.CodeHost, error) {
+	if accessToken != "" {
+		slog.Debug("GitLab code host client created", "base_url", f.baseURL.String())
+		return &CodeHost{baseURL: f.baseURL, token: accessToken, httpClient: f.httpClient}, nil
+	}
+
+	if f.serviceToken != "" {
+		slog.Debug("GitLab code host client created with service token", "base_url", f.baseURL.String(), "projects", len(f.serviceProjects))
+		return &CodeHost{baseURL: f.baseURL, token: f.serviceToken, allowedProjects: f.serviceProjects, httpClient: f.httpClient}, nil
+	}
+
+	slog.Warn("GitLab code host creation failed - empty access token")
+	return nil, codehost.ErrAccessDenied
+}
+
+// CodeHost
+// allowedProjects is nil when acting as the caller, who may reach whatever their
+// token reaches
+type CodeHost struct {
+	baseURL         *url.URL
+	token           string
+	allowedProjects map[string]bool
+	httpClient      *http.Client
+}
+
+type project struct {
+	ID                int64  `json:"id"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	WebURL            string `json:"web_url"`
+}
+
+type commit struct {
+	ID            string    `json:"id"`
+	ParentIDs     []string  `json:"parent_ids"`
+	CommittedDate time.Time `json:"committed_date"`
+	Message       string    `json:"message"`
+	AuthorName    string    `json:"author_name"`
+	AuthorEmail   string    `json:"author_email"`
+}
+
+// reference
+// GitLab commits only carry the author's name and email, not the account behind them
+func (c commit) reference() codehost.CommitReference {
+	return codehost.CommitReference{
+		SHA:         c.ID,
+		CommittedAt: c.CommittedDate,
+		Message:     c.Message,
+		Author:      codehost.CommitAuthor{Name: c.AuthorName, Email: c.AuthorEmail},
+		Parents:     c.ParentIDs,
+	}
+}
+
+type mergeRequest struct {
+	IID         int        `json:"iid"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	WebURL      string     `json:"web_url"`
+	MergedAt    *time.Time `json:"merged_at"`
+}
+
+type fileDiff struct {
+	OldPath     string `json:"old_path"`
+	NewPath     string `json:"new_path"`
+	Diff        string `json:"diff"`
+	NewFile     bool   `json:"new_file"`
+	RenamedFile bool   `json:"renamed_file"`
+	DeletedFile bool   `json:"deleted_file"`
+}
+
+// stat
+// GitLab reports what happened to the file but not its line counts
+func (f fileDiff) stat() codehost.FileStat {
+	stat := codehost.FileStat{Path: f.NewPath, Status: codehost.FileModified}
+	switch {
+	case f.NewFile:
+		stat.Status = codehost.FileAdded
+	case f.DeletedFile:
+		stat.Status = codehost.FileRemoved
+	case f.RenamedFile:
+		stat.Status = codehost.FileRenamed
+		stat.PreviousPath = f.OldPath
+	}
+	if counted := codehost.DiffStats("File: " + f.NewPath + "\n" + f.Diff); len(counted) == 1 {
+		stat.Additions, stat.Deletions = counted[0].Additions, counted[0].Deletions
+	}
+	return stat
+}
+
+type user struct {
+	ID          int64  `json:"id"`
+	Username    string `json:"username"`
+	Name        string `json:"name"`
+	AvatarURL   string `json:"avatar_url"`
+	PublicEmail string `json:"public_email"`
+}
+
+func (ch *CodeHost) CanAccessRepo(ctx context.Context, repoURL string) error {
+	projectPath, err := ch.projectPath(repoURL)
+	if err != nil {
+		slog.Warn("GitLab project access check failed", "repo_url", repoURL, "error", err)
+		return err
+	}
+
+	slog.Debug("Checking GitLab project access", "project", projectPath)
+	var p project
+	if _, err := ch.get(ctx, projectEndpoint(projectPath), nil, &p); err != nil {
+		slog.Warn("GitLab project access check failed", "project", projectPath, "error", err)
+		return err
+	}
+
+	slog.Debug("GitLab project access confirmed", "project", projectPath)
+	return nil
+}
+
+func (ch *CodeHost) CreateRepoFromURL(ctx context.Context, repoURL string) (*repo.Repo, error) {
+	projectPath, err := ch.projectPath(repoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Debug("Fetching GitLab project metadata", "project", projectPath)
+	var p project
+	if _, err := ch.get(ctx, projectEndpoint(projectPath), nil, &p); err != nil {
+		slog.Error("Failed to fetch GitLab project metadata", "project", projectPath, "error", err)
+		return nil, err
+	}
+
+	id := codehost.HostRepoID(ch.baseURL.Host, p.ID)
+	slog.Info("GitLab project metadata fetched", "repo_id", id, "project_id", p.ID, "full_name", p.PathWithNamespace)
+	return repo.NewRepo(id, p.PathWithNamespace, repoURL, "", time.Now()), nil
+}
+
+func (ch *CodeHost) GetAuthenticatedUser(ctx context.Context) (*codehost.UserProfile, error) {
+	if ch.allowedProjects != nil {
+		slog.Warn("GitLab service token does not stand for a user")
+		return nil, codehost.ErrAccessDenied
+	}
+
+	slog.Debug("Fetching authenticated GitLab user")
+	var u user
+	if _, err := ch.get(ctx, "/user", nil, &u); err != nil {
+		slog.Error("Failed to fetch authenticated GitLab user", "error", err)
+		return nil, err
+	}
+
+	slog.Info("Authenticated GitLab user fetched", "login", u.Username, "user_id", u.ID)
+	return &codehost.UserProfile{ID: u.ID, Login: u.Username, Name: u.Name, AvatarURL: u.AvatarURL, Email: u.PublicEmail}, nil
+}
+
+func (ch *CodeHost) SearchRepositories(ctx context.Context, query string) ([]codehost.RepoSearchResult, error) {
+	slog.Debug("Searching GitLab projects", "query", query)
+	params := url.Values{
+		"membership": {"true"},
+		"order_by":   {"last_activity_at"},
+		"per_page":   {"20"},
+	}
+	if query != "" {
+		params.Set("search", query)
+	}
+
+	var projects []project
+	if _, err := ch.get(ctx, "/projects", params, &projects); err != nil {
+		slog.Error("Failed to search GitLab projects", "query", query, "error", err)
+		return nil, err
+	}
+
+	results := make([]codehost.RepoSearchResult, 0, len(projects))
+	for _, p := range projects {
+		if ch.allowedProjects != nil && !ch.allowedProjects[strings.ToLower(p.PathWithNamespace)] {
+			continue
+		}
+		results = append(results, codehost.RepoSearchResult{
+			ID:   codehost.HostRepoID(ch.baseURL.Host, p.ID),
+			Name: p.PathWithNamespace,
+			URL:  p.WebURL,
+		})
+	}
+	slog.Info("GitLab project search completed", "query", query, "matched", len(results))
+	return results, nil
+}
+
+func (ch *CodeHost) GetRepoCommitSHAsIntoChannel(ctx context.Context, r *repo.Repo, commits chan<- codehost.CommitReference) (string, error) {
+	projectPath, err := ch.projectPath(r.URL())
+	if err != nil {
+		return "", err
+	}
+
+	lastSHA := r.LastAnalyzedCommitSHA()
+	params := url.Values{"per_page": {"100"}}
+
+	slog.Info("Fetching commits from GitLab", "project", projectPath, "last_analyzed_sha", lastSHA)
+
+	var headSHA string
+	var totalFetched, sentCount, mergeSkipped int
+	page := "1"
+	for page != "" {
+		params.Set("page", page)
+
+		var pageCommits []commit
+		resp, err := ch.get(ctx, projectEndpoint(projectPath)+"/repository/commits", params, &pageCommits)
+		if err != nil {
+			slog.Error("Failed to fetch commits page from GitLab", "project", projectPath, "page", page, "error", err)
+			return "", err
+		}
+
+		slog.Debug("Fetched commits page", "project", projectPath, "page", page, "count", len(pageCommits))
+
+		for _, c := range pageCommits {
+			totalFetched++
+
+			if c.ID == lastSHA {
+				slog.Info("Reached last analyzed commit, stopping fetch", "last_sha", lastSHA, "total_fetched", totalFetched, "sent", sentCount, "merge_skipped", mergeSkipped)
+				return headSHA, nil
+			}
+
+			if len(c.ParentIDs) > 1 {
+				mergeSkipped++
+				continue
+			}
+
+			if headSHA == "" {
+				headSHA = c.ID
+			}
+			select {
+			case commits <- c.reference():
+				sentCount++
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+		}
+
+		page = resp.Header.Get("X-Next-Page")
+	}
+
+	slog.Info("Commit fetch completed", "project", projectPath, "total_fetched", totalFetched, "sent", sentCount, "merge_skipped", mergeSkipped, "head_sha", headSHA)
+	return headSHA, nil
+}
+
+func (ch *CodeHost) GetCommitDiff(ctx context.Context, r *repo.Repo, commitSHA string) (*codehost.CommitDiff, error) {
+	projectPath, err := ch.projectPath(r.URL())
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Debug("Fetching commit diff", "project", projectPath, "commit_sha", commitSHA)
+
+	var c commit
+	if _, err := ch.get(ctx, projectEndpoint(projectPath)+"/repository/commits/"+url.PathEscape(commitSHA), nil, &c); err != nil {
+		slog.Error("Failed to fetch commit from GitLab", "project", projectPath, "commit_sha", commitSHA, "error", err)
+		return nil, err
+	}
+
+	diff := &codehost.CommitDiff{CommitReference: c.reference()}
+	var filesCount int
+	params := url.Values{"per_page": {"100"}}
+	page := "1"
+	for page != "" {
+		params.Set("page", page)
+
+		var files []fileDiff
+		resp, err := ch.get(ctx, projectEndpoint(projectPath)+"/repository/commits/"+url.PathEscape(commitSHA)+"/diff", params, &files)
+		if err != nil {
+			slog.Error("Failed to fetch commit diff from GitLab", "project", projectPath, "commit_sha", commitSHA, "error", err)
+			return nil, err
+		}
+
+		for _, file := range files {
+			filesCount++
+			if file.Diff != "" {
+				diff.Text += fmt.Sprintf("File: %s\n%s\n\n", file.NewPath, file.Diff)
+			}
+			diff.Files = append(diff.Files, file.stat())
+		}
+
+		page = resp.Header.Get("X-Next-Page")
+	}
+
+	slog.Debug("Commit diff fetched", "commit_sha", commitSHA, "files_count", filesCount, "diff_length", len(diff.Text))
+	return diff, nil
+}
+
+func (ch *CodeHost) GetCommitPullRequest(ctx context.Context, r *repo.Repo, commitSHA string) (*codehost.PullRequest, error) {
+	projectPath, err := ch.projectPath(r.URL())
+	if err != nil {
+		return nil, err
+	}
+
+	var mergeRequests []mergeRequest
+	if _, err := ch.get(ctx, projectEndpoint(projectPath)+"/repository/commits/"+url.PathEscape(commitSHA)+"/merge_requests", nil, &mergeRequests); err != nil {
+		slog.Error("Failed to fetch merge requests of commit from GitLab", "project", projectPath, "commit_sha", commitSHA, "error", err)
+		return nil, err
+	}
+
+	pullRequests := make([]codehost.PullRequest, len(mergeRequests))
+	for i, mr := range mergeRequests {
+		pullRequests[i] = codehost.PullRequest{Number: mr.IID, Title: mr.Title, Body: mr.Description, URL: mr.WebURL}
+		if mr.MergedAt != nil {
+			pullRequests[i].MergedAt = *mr.MergedAt
+		}
+	}
+	return codehost.PreferredPullRequest(pullRequests), nil
+}
+
+// get
+// Issues an authenticated GET against the v4 API and decodes the JSON body into out.
+// 401, 403 and 404 are reported as codehost.ErrAccessDenied, since GitLab hides
+// private projects behind 404.
+func (ch *CodeHost) get(ctx context.Context, endpoint string, params url.Values, out any) (*http.Response, error) {
+	target := ch.baseURL.String() + "/api/v4" + endpoint
+	if len(params) > 0 {
+		target += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+ch.token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := ch.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusNotFound:
+		return resp, fmt.Errorf("%w: gitlab responded %d", codehost.ErrAccessDenied, resp.StatusCode)
+	case resp.StatusCode >= 300:
+		return resp, fmt.Errorf("gitlab %s responded %d", endpoint, resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp, fmt.Errorf("decoding gitlab %s response: %w", endpoint, err)
+	}
+	return resp, nil
+}
+
+// projectPath
+// The path of the project at repoURL, codehost.ErrInvalidRepoURL when it is not a
+// project of this instance and codehost.ErrAccessDenied when the service token may not
+// be used for it
+func (ch *CodeHost) projectPath(repoURL string) (string, error) {
+	projectPath, err := ch.parseRepoURL(repoURL)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", codehost.ErrInvalidRepoURL, err)
+	}
+	if ch.allowedProjects != nil && !ch.allowedProjects[strings.ToLower(projectPath)] {
+		return "", fmt.Errorf("%w: %s is not a service token project", codehost.ErrAccessDenied, projectPath)
+	}
+	return projectPath, nil
+}
+
+// parseRepoURL
+// Returns the project path, which may include nested groups, of a project URL on this
+// instance. Trailing ".git" and "/-/..." suffixes (tree, merge_requests...) are ignored.
+func (ch *CodeHost) parseRepoURL(repoURL string) (string, error) {
+	parsed, err := url.Parse(repoURL)
+	if err != nil {
+		return "", err
+	}
+
+	if !strings.EqualFold(parsed.Host, ch.baseURL.Host) {
+		return "", fmt.Errorf("url '%s' is not on %s", repoURL, ch.baseURL.Host)
+	}
+
+	path := strings.TrimPrefix(parsed.Path, strings.TrimSuffix(ch.baseURL.Path, "/"))
+	path, _, _ = strings.Cut(path, "/-/")
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+
+	parts := strings.Split(path, "/")
+	if len(parts) < 2 || slices.Contains(parts, "") {
+		return "", fmt.Errorf("url '%s' has invalid path", repoURL)
+	}
+
+	return path, nil
+}
+
+func projectEndpoint(projectPath string) string {
+	return "/projects/" + url.PathEscape(projectPath)
+}
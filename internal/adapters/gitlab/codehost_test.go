@@ -0,0 +1,330 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	validToken  = "glpat-valid"
+	projectPath = "platform/backend/api"
+)
+
+// fakeGitLab serves a single project with five commits, newest first, split in
+// pages of two. c3 is a merge commit.
+type fakeGitLab struct {
+	commits []commit
+}
+
+func newFakeGitLab() *fakeGitLab {
+	date := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	return &fakeGitLab{commits: []commit{
+		{ID: "c5", ParentIDs: []string{"c4"}, CommittedDate: date.Add(4 * time.Hour), Message: "Fix login redirect\n\nCloses #12", AuthorName: "Jane Doe", AuthorEmail: "jane@example.com"},
+		{ID: "c4", ParentIDs: []string{"c3"}, CommittedDate: date.Add(3 * time.Hour)},
+		{ID: "c3", ParentIDs: []string{"c2", "b1"}, CommittedDate: date.Add(2 * time.Hour)},
+		{ID: "c2", ParentIDs: []string{"c1"}, CommittedDate: date.Add(time.Hour)},
+		{ID: "c1", CommittedDate: date},
+	}}
+}
+
+func (f *fakeGitLab) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+validToken {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	projectPrefix := "/api/v4/projects/" + strings.ReplaceAll(projectPath, "/", "%2F")
+	path := r.URL.EscapedPath()
+
+	switch {
+	case path == "/api/v4/user":
+		writeJSON(w, user{ID: 7, Username: "jdoe", Name: "Jane Doe", AvatarURL: "https://gitlab.example/avatar.png"})
+	case path == "/api/v4/projects":
+		projects := []project{{ID: 42, PathWithNamespace: projectPath, WebURL: "https://gitlab.example/" + projectPath}}
+		if !strings.Contains(projectPath, r.URL.Query().Get("search")) {
+			projects = nil
+		}
+		writeJSON(w, projects)
+	case path == projectPrefix:
+		writeJSON(w, project{ID: 42, PathWithNamespace: projectPath, WebURL: "https://gitlab.example/" + projectPath})
+	case path == projectPrefix+"/repository/commits":
+		f.writeCommitsPage(w, r)
+	case path == projectPrefix+"/repository/commits/c5":
+		writeJSON(w, f.commits[0])
+	case path == projectPrefix+"/repository/commits/c5/diff":
+		writeJSON(w, []fileDiff{
+			{OldPath: "main.go", NewPath: "main.go", Diff: "@@ -1 +1 @@\n-old\n+new\n"},
+			{OldPath: "logo.png", NewPath: "image.png", RenamedFile: true},
+		})
+	case path == projectPrefix+"/repository/commits/c5/merge_requests":
+		mergedAt := time.Date(2025, 3, 2, 9, 0, 0, 0, time.UTC)
+		writeJSON(w, []mergeRequest{
+			{IID: 8, Title: "Draft: login rework", WebURL: "https://gitlab.example/" + projectPath + "/-/merge_requests/8"},
+			{IID: 9, Title: "Fix login redirect", Description: "Closes #12", WebURL: "https://gitlab.example/" + projectPath + "/-/merge_requests/9", MergedAt: &mergedAt},
+		})
+	case path == projectPrefix+"/repository/commits/c4/merge_requests":
+		writeJSON(w, []mergeRequest{})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeGitLab) writeCommitsPage(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	const perPage = 2
+
+	start := (page - 1) * perPage
+	end := min(start+perPage, len(f.commits))
+	if end < len(f.commits) {
+		w.Header().Set("X-Next-Page", strconv.Itoa(page+1))
+	}
+	writeJSON(w, f.commits[start:end])
+}
+
+func writeJSON(w http.ResponseWriter, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+type GitLabCodeHostTestSuite struct {
+	suite.Suite
+	server   *httptest.Server
+	factory  *CodeHostFactory
+	codeHost codehost.CodeHost
+	repoURL  string
+}
+
+func TestGitLabCodeHostTestSuite(t *testing.T) {
+	suite.Run(t, new(GitLabCodeHostTestSuite))
+}
+
+func (s *GitLabCodeHostTestSuite) SetupTest() {
+	s.server = httptest.NewServer(newFakeGitLab())
+	s.T().Cleanup(s.server.Close)
+
+	var err error
+	s.factory, err = NewCodeHostFactory(s.server.URL, "", nil, s.server.Client())
+	require.NoError(s.T(), err)
+	s.codeHost, err = s.factory.Create(context.Background(), validToken)
+	require.NoError(s.T(), err)
+	s.repoURL = s.server.URL + "/" + projectPath
+}
+
+func (s *GitLabCodeHostTestSuite) collectCommits(r *repo.Repo) ([]codehost.CommitReference, string, error) {
+	commits := make(chan codehost.CommitReference, 100)
+	headSHA, err := s.codeHost.GetRepoCommitSHAsIntoChannel(context.Background(), r, commits)
+	close(commits)
+
+	var refs []codehost.CommitReference
+	for ref := range commits {
+		refs = append(refs, ref)
+	}
+	return refs, headSHA, err
+}
+
+func (s *GitLabCodeHostTestSuite) TestFactoryRejectsInvalidBaseURL() {
+	_, err := NewCodeHostFactory("gitlab.example", "", nil, nil)
+	assert.NotNil(s.T(), err)
+}
+
+func (s *GitLabCodeHostTestSuite) TestFactoryDefaultsToGitLabDotCom() {
+	factory, err := NewCodeHostFactory("", "", nil, nil)
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), "gitlab.com", factory.Host())
+}
+
+func (s *GitLabCodeHostTestSuite) TestCannotCreateWithoutToken() {
+	_, err := s.factory.Create(context.Background(), "")
+	assert.True(s.T(), errors.Is(err, codehost.ErrAccessDenied))
+}
+
+func (s *GitLabCodeHostTestSuite) TestFactoryRejectsServiceTokenWithoutProjects() {
+	_, err := NewCodeHostFactory(s.server.URL, validToken, []string{" "}, s.server.Client())
+	assert.NotNil(s.T(), err)
+}
+
+func (s *GitLabCodeHostTestSuite) TestCallerTokenIsPreferredToServiceToken() {
+	factory, _ := NewCodeHostFactory(s.server.URL, "glpat-service", []string{projectPath}, s.server.Client())
+	codeHost, err := factory.Create(context.Background(), validToken)
+
+	assert.Nil(s.T(), err)
+	assert.Nil(s.T(), codeHost.CanAccessRepo(context.Background(), s.repoURL))
+}
+
+func (s *GitLabCodeHostTestSuite) TestServiceTokenOnlyReachesItsProjects() {
+	factory, _ := NewCodeHostFactory(s.server.URL, validToken, []string{"Platform/Backend/API/"}, s.server.Client())
+	codeHost, err := factory.Create(context.Background(), "")
+	require.NoError(s.T(), err)
+
+	assert.Nil(s.T(), codeHost.CanAccessRepo(context.Background(), s.repoURL))
+
+	factory, _ = NewCodeHostFactory(s.server.URL, validToken, []string{"platform/web"}, s.server.Client())
+	codeHost, _ = factory.Create(context.Background(), "")
+
+	err = codeHost.CanAccessRepo(context.Background(), s.repoURL)
+	assert.True(s.T(), errors.Is(err, codehost.ErrAccessDenied))
+	_, err = codeHost.GetCommitDiff(context.Background(), repo.NewRepo(1, projectPath, s.repoURL, "", time.Time{}), "c5")
+	assert.True(s.T(), errors.Is(err, codehost.ErrAccessDenied))
+	results, _ := codeHost.SearchRepositories(context.Background(), "backend")
+	assert.Empty(s.T(), results)
+	_, err = codeHost.GetAuthenticatedUser(context.Background())
+	assert.True(s.T(), errors.Is(err, codehost.ErrAccessDenied))
+}
+
+func (s *GitLabCodeHostTestSuite) TestCanAccessNestedGroupProject() {
+	assert.Nil(s.T(), s.codeHost.CanAccessRepo(context.Background(), s.repoURL))
+	assert.Nil(s.T(), s.codeHost.CanAccessRepo(context.Background(), s.repoURL+".git"))
+	assert.Nil(s.T(), s.codeHost.CanAccessRepo(context.Background(), s.repoURL+"/-/tree/main"))
+}
+
+func (s *GitLabCodeHostTestSuite) TestUnknownProjectIsAccessDenied() {
+	err := s.codeHost.CanAccessRepo(context.Background(), s.server.URL+"/platform/secret")
+	assert.True(s.T(), errors.Is(err, codehost.ErrAccessDenied))
+}
+
+func (s *GitLabCodeHostTestSuite) TestInvalidTokenIsAccessDenied() {
+	codeHost, _ := s.factory.Create(context.Background(), "wrong")
+	err := codeHost.CanAccessRepo(context.Background(), s.repoURL)
+
+	assert.True(s.T(), errors.Is(err, codehost.ErrAccessDenied))
+}
+
+func (s *GitLabCodeHostTestSuite) TestURLOnAnotherHostIsInvalid() {
+	err := s.codeHost.CanAccessRepo(context.Background(), "https://github.com/octokerbs/chronocode")
+	assert.True(s.T(), errors.Is(err, codehost.ErrInvalidRepoURL))
+
+	err = s.codeHost.CanAccessRepo(context.Background(), s.server.URL+"/lonely")
+	assert.True(s.T(), errors.Is(err, codehost.ErrInvalidRepoURL))
+}
+
+func (s *GitLabCodeHostTestSuite) TestCreateRepoFromURL() {
+	r, err := s.codeHost.CreateRepoFromURL(context.Background(), s.repoURL)
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), projectPath, r.Name())
+	assert.Equal(s.T(), s.repoURL, r.URL())
+	assert.NotEqual(s.T(), int64(42), r.ID())
+	assert.Positive(s.T(), r.ID())
+}
+
+func (s *GitLabCodeHostTestSuite) TestSendsAllPagesSkippingMerges() {
+	r := repo.NewRepo(1, projectPath, s.repoURL, "", time.Time{})
+	refs, headSHA, err := s.collectCommits(r)
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), "c5", headSHA)
+	assert.Equal(s.T(), []string{"c5", "c4", "c2", "c1"}, shas(refs))
+	assert.False(s.T(), refs[0].CommittedAt.IsZero())
+}
+
+func (s *GitLabCodeHostTestSuite) TestStopsAtLastAnalyzedCommit() {
+	r := repo.NewRepo(1, projectPath, s.repoURL, "c2", time.Time{})
+	refs, headSHA, err := s.collectCommits(r)
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), "c5", headSHA)
+	assert.Equal(s.T(), []string{"c5", "c4"}, shas(refs))
+}
+
+func (s *GitLabCodeHostTestSuite) TestNothingNewSinceLastAnalyzedCommit() {
+	r := repo.NewRepo(1, projectPath, s.repoURL, "c5", time.Time{})
+	refs, headSHA, err := s.collectCommits(r)
+
+	assert.Nil(s.T(), err)
+	assert.Empty(s.T(), refs)
+	assert.Equal(s.T(), "", headSHA)
+}
+
+func (s *GitLabCodeHostTestSuite) TestCommitDiffSkipsBinaryFiles() {
+	r := repo.NewRepo(1, projectPath, s.repoURL, "", time.Time{})
+	diff, err := s.codeHost.GetCommitDiff(context.Background(), r, "c5")
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), "File: main.go\n@@ -1 +1 @@\n-old\n+new\n\n\n", diff.Text)
+}
+
+func (s *GitLabCodeHostTestSuite) TestCommitDiffCarriesMetadataAndFileStats() {
+	r := repo.NewRepo(1, projectPath, s.repoURL, "", time.Time{})
+	diff, err := s.codeHost.GetCommitDiff(context.Background(), r, "c5")
+
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), "Fix login redirect\n\nCloses #12", diff.Message)
+	assert.Equal(s.T(), codehost.CommitAuthor{Name: "Jane Doe", Email: "jane@example.com"}, diff.Author)
+	assert.Equal(s.T(), []string{"c4"}, diff.Parents)
+	assert.Equal(s.T(), []codehost.FileStat{
+		{Path: "main.go", Status: codehost.FileModified, Additions: 1, Deletions: 1},
+		{Path: "image.png", PreviousPath: "logo.png", Status: codehost.FileRenamed},
+	}, diff.Files)
+}
+
+func (s *GitLabCodeHostTestSuite) TestCommitPullRequestIsTheMergedMergeRequest() {
+	r := repo.NewRepo(1, projectPath, s.repoURL, "", time.Time{})
+	pr, err := s.codeHost.GetCommitPullRequest(context.Background(), r, "c5")
+
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), &codehost.PullRequest{
+		Number: 9, Title: "Fix login redirect", Body: "Closes #12",
+		URL:      "https://gitlab.example/" + projectPath + "/-/merge_requests/9",
+		MergedAt: time.Date(2025, 3, 2, 9, 0, 0, 0, time.UTC),
+	}, pr)
+}
+
+func (s *GitLabCodeHostTestSuite) TestCommitPushedDirectlyHasNoPullRequest() {
+	r := repo.NewRepo(1, projectPath, s.repoURL, "", time.Time{})
+	pr, err := s.codeHost.GetCommitPullRequest(context.Background(), r, "c4")
+
+	assert.Nil(s.T(), err)
+	assert.Nil(s.T(), pr)
+}
+
+func (s *GitLabCodeHostTestSuite) TestAuthenticatedUser() {
+	profile, err := s.codeHost.GetAuthenticatedUser(context.Background())
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), "jdoe", profile.Login)
+	assert.Equal(s.T(), "Jane Doe", profile.Name)
+}
+
+func (s *GitLabCodeHostTestSuite) TestSearchRepositories() {
+	results, err := s.codeHost.SearchRepositories(context.Background(), "backend")
+
+	assert.Nil(s.T(), err)
+	assert.Len(s.T(), results, 1)
+	assert.Equal(s.T(), projectPath, results[0].Name)
+
+	results, _ = s.codeHost.SearchRepositories(context.Background(), "frontend")
+	assert.Empty(s.T(), results)
+}
+
+func shas(refs []codehost.CommitReference) []string {
+	var result []string
+	for _, ref := range refs {
+		result = append(result, ref.SHA)
+	}
+	return result
+}
+
+func (s *GitLabCodeHostTestSuite) TestStopsSendingWhenCancelled() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := s.codeHost.GetRepoCommitSHAsIntoChannel(ctx, repo.NewRepo(1, projectPath, s.repoURL, "", time.Time{}), make(chan codehost.CommitReference))
+	assert.True(s.T(), errors.Is(err, context.DeadlineExceeded))
+}
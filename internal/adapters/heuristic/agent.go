@@ -0,0 +1,362 @@
+package heuristic
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+)
+
+// Agent
+// Derives subcommits from the diff alone, without a model. Files are grouped by kind
+// (docs, CI, build) and by directory, each group becomes one subcommit classified from
+// the conventional-commit prefix of the commit message, when the code host or the diff
+// carries one, or from the files and the shape of the change
+type Agent struct{}
+
+func NewAgent() *Agent {
+	return &Agent{}
+}
+
+type fileKind int
+
+const (
+	kindSource fileKind = iota
+	kindTest
+	kindDocs
+	kindCI
+	kindBuild
+)
+
+type fileChange struct {
+	path    string
+	kind    fileKind
+	added   int
+	removed int
+	created bool
+	deleted bool
+}
+
+type group struct {
+	key   string
+	epic  string
+	kind  fileKind
+	files []*fileChange
+}
+
+var (
+	gitDiffHeader = regexp.MustCompile(`^diff --git a/(.+) b/(.+)$`)
+	// Conventional Commits subject: type(scope)!: description, optionally after the
+	// [PATCH] tag of git format-patch
+	conventionalSubject = regexp.MustCompile(`^(?:\[[^\]]*\]\s*)?(\w+)(?:\(([^)]*)\))?(!)?:\s*(.+)$`)
+)
+
+func (a *Agent) AnalyzeCommit(ctx context.Context, input agent.AnalysisInput) ([]agent.AnalysisResult, error) {
+	preamble, files := parseDiff(input.Diff)
+	if input.Message != "" {
+		preamble = input.Message
+	}
+	prefix, scope, subject := conventionalPrefix(preamble)
+	taxonomy := agent.PromptOverridesFrom(ctx).Taxonomy()
+
+	groups := groupFiles(files)
+	results := make([]agent.AnalysisResult, 0, len(groups))
+	for _, g := range groups {
+		modificationType := g.classify(prefix)
+
+		title := g.title()
+		if subject != "" && len(groups) == 1 {
+			title = subject
+		}
+
+		epic := g.epic
+		if scope != "" {
+			epic = scope
+		}
+
+		results = append(results, agent.AnalysisResult{
+			Title:            title,
+			Idea:             g.idea(modificationType),
+			Description:      g.description(),
+			Epic:             epic,
+			ModificationType: classifyInto(taxonomy, prefix, modificationType),
+			Files:            g.paths(),
+		})
+	}
+	return results, nil
+}
+
+// parseDiff
+// Understands both the "File: <path>" blocks built by the API code hosts and plain
+// git diffs. Anything before the first file is returned as the preamble
+func parseDiff(diff string) (string, []*fileChange) {
+	var preamble strings.Builder
+	var files []*fileChange
+	var current *fileChange
+
+	for _, line := range strings.Split(diff, "\n") {
+		if name, ok := strings.CutPrefix(line, "File: "); ok {
+			current = &fileChange{path: strings.TrimSpace(name)}
+			files = append(files, current)
+			continue
+		}
+		if match := gitDiffHeader.FindStringSubmatch(line); match != nil {
+			current = &fileChange{path: match[2]}
+			files = append(files, current)
+			continue
+		}
+
+		if current == nil {
+			preamble.WriteString(line + "\n")
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "new file mode"), strings.HasPrefix(line, "@@ -0,0 "):
+			current.created = true
+		case strings.HasPrefix(line, "deleted file mode"), strings.Contains(line, " +0,0 @@"):
+			current.deleted = true
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+		case strings.HasPrefix(line, "+"):
+			current.added++
+		case strings.HasPrefix(line, "-"):
+			current.removed++
+		}
+	}
+
+	for _, file := range files {
+		file.kind = kindOf(file.path)
+	}
+	return preamble.String(), files
+}
+
+func conventionalPrefix(preamble string) (prefix, scope, subject string) {
+	for _, line := range strings.Split(preamble, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "From ") || strings.HasPrefix(line, "From:") || strings.HasPrefix(line, "Date:") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "Subject:"))
+
+		match := conventionalSubject.FindStringSubmatch(line)
+		if match == nil {
+			return "", "", ""
+		}
+		prefix = strings.ToLower(match[1])
+		if match[3] != "" {
+			prefix += "!"
+		}
+		return prefix, match[2], match[4]
+	}
+	return "", "", ""
+}
+
+func kindOf(filePath string) fileKind {
+	lower := strings.ToLower(filePath)
+	base := path.Base(lower)
+	ext := path.Ext(lower)
+
+	switch {
+	case strings.HasPrefix(lower, ".github/"), strings.HasPrefix(lower, ".circleci/"),
+		base == ".gitlab-ci.yml", base == "jenkinsfile", base == ".travis.yml", strings.HasPrefix(lower, ".gitea/workflows/"):
+		return kindCI
+	case strings.HasSuffix(base, "_test.go"), strings.Contains(base, ".test."), strings.Contains(base, ".spec."),
+		strings.HasPrefix(base, "test_"), hasDirectory(lower, "test", "tests", "__tests__", "testdata"):
+		return kindTest
+	case ext == ".md", ext == ".rst", ext == ".adoc", base == "license", base == "changelog", hasDirectory(lower, "docs", "doc"):
+		return kindDocs
+	case base == "go.mod", base == "go.sum", base == "package.json", base == "package-lock.json", base == "yarn.lock",
+		base == "pnpm-lock.yaml", base == "cargo.toml", base == "cargo.lock", base == "requirements.txt", base == "pyproject.toml",
+		base == "dockerfile", base == "makefile", base == "docker-compose.yml", base == ".gitignore", base == ".env.example":
+		return kindBuild
+	}
+	return kindSource
+}
+
+func hasDirectory(filePath string, names ...string) bool {
+	dirs := strings.Split(path.Dir(filePath), "/")
+	return slices.ContainsFunc(dirs, func(dir string) bool { return slices.Contains(names, dir) })
+}
+
+// groupFiles
+// Docs, CI and build files each form a single group, code and its tests are grouped
+// by their first two directories so a change to one module stays together
+func groupFiles(files []*fileChange) []*group {
+	var groups []*group
+	byKey := make(map[string]*group)
+
+	for _, file := range files {
+		key, epic := groupKey(file)
+
+		g, ok := byKey[key]
+		if !ok {
+			g = &group{key: key, epic: epic, kind: file.kind}
+			byKey[key] = g
+			groups = append(groups, g)
+		}
+		if g.kind != file.kind {
+			g.kind = kindSource
+		}
+		g.files = append(g.files, file)
+	}
+	return groups
+}
+
+func groupKey(file *fileChange) (key, epic string) {
+	switch file.kind {
+	case kindDocs:
+		return "docs", "Documentation"
+	case kindCI:
+		return "ci", "CI/CD"
+	case kindBuild:
+		return "build", "Build"
+	}
+
+	dirs := strings.Split(path.Dir(file.path), "/")
+	if dirs[0] == "." {
+		return ".", "Root"
+	}
+	epic = dirs[0]
+	if len(dirs) > 2 {
+		dirs = dirs[:2]
+	}
+	return strings.Join(dirs, "/"), epic
+}
+
+var prefixTypes = map[string]string{
+	"feat":     "FEATURE",
+	"fix":      "BUG",
+	"refactor": "REFACTOR",
+	"perf":     "REFACTOR",
+	"style":    "REFACTOR",
+	"docs":     "DOCS",
+	"chore":    "CHORE",
+	"ci":       "CHORE",
+	"build":    "CHORE",
+	"test":     "CHORE",
+	"revert":   "CHORE",
+	"release":  "MILESTONE",
+}
+
+func (g *group) classify(prefix string) string {
+	switch g.kind {
+	case kindDocs:
+		return "DOCS"
+	case kindCI, kindBuild, kindTest:
+		return "CHORE"
+	}
+
+	if strings.HasSuffix(prefix, "!") {
+		return "WARNING"
+	}
+	if modificationType, ok := prefixTypes[prefix]; ok {
+		return modificationType
+	}
+
+	added, removed := g.lines()
+	switch {
+	case g.all(func(f *fileChange) bool { return f.created }):
+		return "FEATURE"
+	case added+removed <= 10 && removed > 0:
+		return "BUG"
+	case added >= 3*removed:
+		return "FEATURE"
+	default:
+		return "REFACTOR"
+	}
+}
+
+// classifyInto
+// Fits the classification into the taxonomy of the repository. Custom taxonomies may
+// name the conventional-commit type itself, such as PERF or TEST
+func classifyInto(taxonomy []string, prefix, modificationType string) string {
+	if named := strings.ToUpper(strings.TrimSuffix(prefix, "!")); named != "" && slices.Contains(taxonomy, named) {
+		return named
+	}
+	return agent.CoerceModificationType(taxonomy, modificationType)
+}
+
+func (g *group) lines() (added, removed int) {
+	for _, file := range g.files {
+		added += file.added
+		removed += file.removed
+	}
+	return added, removed
+}
+
+func (g *group) all(predicate func(*fileChange) bool) bool {
+	return !slices.ContainsFunc(g.files, func(f *fileChange) bool { return !predicate(f) })
+}
+
+func (g *group) paths() []string {
+	paths := make([]string, len(g.files))
+	for i, file := range g.files {
+		paths[i] = file.path
+	}
+	return paths
+}
+
+func (g *group) title() string {
+	verb := "Update"
+	switch {
+	case g.all(func(f *fileChange) bool { return f.created }):
+		verb = "Add"
+	case g.all(func(f *fileChange) bool { return f.deleted }):
+		verb = "Remove"
+	}
+
+	var subject string
+	switch {
+	case len(g.files) == 1:
+		subject = g.files[0].path
+	case g.kind == kindDocs:
+		subject = "documentation"
+	case g.kind == kindCI:
+		subject = "CI configuration"
+	case g.kind == kindBuild:
+		subject = "build configuration"
+	case g.kind == kindTest:
+		subject = "tests in " + g.key
+	default:
+		subject = g.key
+	}
+	return verb + " " + subject
+}
+
+var ideas = map[string]string{
+	"FEATURE":   "Introduce new functionality in %s.",
+	"BUG":       "Correct existing behaviour in %s.",
+	"REFACTOR":  "Restructure existing code in %s.",
+	"DOCS":      "Keep the documentation of %s up to date.",
+	"CHORE":     "Routine maintenance of %s.",
+	"MILESTONE": "Mark a release of %s.",
+	"WARNING":   "Make a breaking change to %s.",
+}
+
+func (g *group) idea(modificationType string) string {
+	return fmt.Sprintf(ideas[modificationType], g.scope())
+}
+
+func (g *group) scope() string {
+	switch {
+	case g.kind == kindDocs:
+		return "the project"
+	case g.kind == kindCI:
+		return "the CI pipeline"
+	case g.kind == kindBuild:
+		return "the build"
+	case g.key == ".":
+		return "the repository root"
+	}
+	return g.key
+}
+
+func (g *group) description() string {
+	added, removed := g.lines()
+	return fmt.Sprintf("Touches %d file(s) under %s, adding %d and removing %d line(s): %s.",
+		len(g.files), g.scope(), added, removed, strings.Join(g.paths(), ", "))
+}
@@ -0,0 +1,126 @@
+package heuristic
+
+import (
+	"context"
+	"testing"
+
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type HeuristicAgentTestSuite struct {
+	suite.Suite
+	agent *Agent
+}
+
+func TestHeuristicAgentTestSuite(t *testing.T) {
+	suite.Run(t, new(HeuristicAgentTestSuite))
+}
+
+func (s *HeuristicAgentTestSuite) SetupTest() {
+	s.agent = NewAgent()
+}
+
+func (s *HeuristicAgentTestSuite) analyze(diff string) []agent.AnalysisResult {
+	results, err := s.agent.AnalyzeCommit(context.Background(), agent.AnalysisInput{Diff: diff})
+	require.NoError(s.T(), err)
+	return results
+}
+
+func (s *HeuristicAgentTestSuite) TestGroupsFilesByKindAndDirectory() {
+	diff := "File: internal/adapters/ollama/agent.go\n@@ -0,0 +1,3 @@\n+package ollama\n+\n+type Agent struct{}\n\n" +
+		"File: internal/adapters/ollama/agent_test.go\n@@ -0,0 +1,1 @@\n+package ollama\n\n" +
+		"File: README.md\n@@ -1,1 +1,2 @@\n # Chronocode\n+Runs offline with Ollama.\n\n" +
+		"File: .github/workflows/ci.yml\n@@ -3,1 +3,1 @@\n-go: 1.22\n+go: 1.23\n\n"
+
+	results := s.analyze(diff)
+
+	require.Len(s.T(), results, 3)
+	assert.Equal(s.T(), "Add internal/adapters", results[0].Title)
+	assert.Equal(s.T(), "internal", results[0].Epic)
+	assert.Equal(s.T(), "FEATURE", results[0].ModificationType)
+	assert.Equal(s.T(), []string{"internal/adapters/ollama/agent.go", "internal/adapters/ollama/agent_test.go"}, results[0].Files)
+
+	assert.Equal(s.T(), "DOCS", results[1].ModificationType)
+	assert.Equal(s.T(), "Documentation", results[1].Epic)
+	assert.Equal(s.T(), []string{"README.md"}, results[1].Files)
+
+	assert.Equal(s.T(), "CHORE", results[2].ModificationType)
+	assert.Equal(s.T(), "CI/CD", results[2].Epic)
+}
+
+func (s *HeuristicAgentTestSuite) TestCommitMessageOfTheCodeHostIsClassified() {
+	input := agent.AnalysisInput{Diff: "File: main.go\n@@ -10,6 +10,7 @@\n+\tlog.Println(\"started\")\n", Message: "fix(server): log startup\n\nBody."}
+	results, err := s.agent.AnalyzeCommit(context.Background(), input)
+
+	require.NoError(s.T(), err)
+	require.Len(s.T(), results, 1)
+	assert.Equal(s.T(), "log startup", results[0].Title)
+	assert.Equal(s.T(), "server", results[0].Epic)
+	assert.Equal(s.T(), "BUG", results[0].ModificationType)
+}
+
+func (s *HeuristicAgentTestSuite) TestParsesGitDiffs() {
+	diff := "diff --git a/cmd/main.go b/cmd/main.go\nindex 1..2 100644\n--- a/cmd/main.go\n+++ b/cmd/main.go\n@@ -10,2 +10,2 @@\n-\tport := 80\n+\tport := 8080\n" +
+		"diff --git a/legacy/old.go b/legacy/old.go\ndeleted file mode 100644\n--- a/legacy/old.go\n+++ /dev/null\n@@ -1,2 +0,0 @@\n-package legacy\n-\n"
+
+	results := s.analyze(diff)
+
+	require.Len(s.T(), results, 2)
+	assert.Equal(s.T(), "Update cmd/main.go", results[0].Title)
+	assert.Equal(s.T(), "BUG", results[0].ModificationType)
+	assert.Equal(s.T(), "Remove legacy/old.go", results[1].Title)
+	assert.Equal(s.T(), "legacy", results[1].Epic)
+	assert.Contains(s.T(), results[1].Description, "removing 2 line(s)")
+}
+
+func (s *HeuristicAgentTestSuite) TestBalancedChangeIsRefactor() {
+	diff := "File: internal/domain/repo/repo.go\n@@ -1,20 +1,20 @@\n"
+	for range 20 {
+		diff += "-old line\n+new line\n"
+	}
+
+	results := s.analyze(diff)
+
+	assert.Equal(s.T(), "REFACTOR", results[0].ModificationType)
+	assert.Equal(s.T(), "internal", results[0].Epic)
+}
+
+func (s *HeuristicAgentTestSuite) TestConventionalCommitPrefixClassifiesCode() {
+	diff := "fix(auth): refresh expired tokens before retrying\n\nFile: internal/ports/http/auth/session.go\n@@ -1,3 +1,40 @@\n" +
+		"+line\n+line\n+line\n+line\n+line\n+line\n+line\n+line\n+line\n+line\n+line\n+line\n"
+
+	results := s.analyze(diff)
+
+	require.Len(s.T(), results, 1)
+	assert.Equal(s.T(), "BUG", results[0].ModificationType)
+	assert.Equal(s.T(), "auth", results[0].Epic)
+	assert.Equal(s.T(), "refresh expired tokens before retrying", results[0].Title)
+}
+
+func (s *HeuristicAgentTestSuite) TestBreakingChangeIsWarning() {
+	results := s.analyze("Subject: [PATCH] feat!: drop the v1 API\n\nFile: api/v1.go\n@@ -1,1 +0,0 @@\n-package api\n")
+
+	assert.Equal(s.T(), "WARNING", results[0].ModificationType)
+}
+
+func (s *HeuristicAgentTestSuite) TestPrefixDoesNotOverrideDocs() {
+	results := s.analyze("feat: document the new agent\n\nFile: docs/agents.md\n@@ -0,0 +1,1 @@\n+# Agents\n")
+
+	assert.Equal(s.T(), "DOCS", results[0].ModificationType)
+}
+
+func (s *HeuristicAgentTestSuite) TestClassifiesIntoRepositoryTaxonomy() {
+	ctx := agent.WithPromptOverrides(context.Background(), agent.PromptOverrides{ModificationTypes: []string{"PERF", "FEATURE", "INFRA"}})
+	perf, _ := s.agent.AnalyzeCommit(ctx, agent.AnalysisInput{Diff: "perf: cache compiled templates\n\nFile: llm/prompts.go\n@@ -1,1 +1,1 @@\n-a\n+b\n"})
+	docs, _ := s.agent.AnalyzeCommit(ctx, agent.AnalysisInput{Diff: "File: README.md\n@@ -1,1 +1,2 @@\n # Chronocode\n+More.\n"})
+
+	assert.Equal(s.T(), "PERF", perf[0].ModificationType)
+	assert.Equal(s.T(), "PERF", docs[0].ModificationType, "types outside the taxonomy fall back to its first type")
+}
+
+func (s *HeuristicAgentTestSuite) TestEmptyDiffHasNoSubcommits() {
+	assert.Empty(s.T(), s.analyze(""))
+}
@@ -0,0 +1,99 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+)
+
+// The answer schema and parsing shared by every model-backed agent.Agent, the prompt
+// itself comes from the templates of Prompts
+
+// JSONSchema
+// JSON Schema of the analysis answer, in the subset accepted by OpenAI strict
+// structured outputs (every property required, no additional properties)
+func (p Prompt) JSONSchema() map[string]any {
+	str := func(description string) map[string]any {
+		return map[string]any{"type": "string", "description": description}
+	}
+
+	subcommit := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"title":       str(p.TitleDescription),
+			"idea":        str(p.IdeaDescription),
+			"description": str(p.DescriptionDescription),
+			"epic":        str(p.EpicDescription),
+			"type":        map[string]any{"type": "string", "description": p.TypeDescription, "enum": p.ModificationTypes},
+			"files":       map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": p.FilesDescription},
+		},
+		"required":             []string{"title", "idea", "description", "epic", "type", "files"},
+		"additionalProperties": false,
+	}
+
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"subcommits": map[string]any{"type": "array", "items": subcommit, "description": p.SubcommitsDescription},
+		},
+		"required":             []string{"subcommits"},
+		"additionalProperties": false,
+	}
+}
+
+type subcommitResponse struct {
+	Title            string   `json:"title"`
+	Idea             string   `json:"idea"`
+	Description      string   `json:"description"`
+	Epic             string   `json:"epic"`
+	ModificationType string   `json:"type"`
+	Files            []string `json:"files"`
+}
+
+type analysisResponse struct {
+	Subcommits []subcommitResponse `json:"subcommits"`
+}
+
+// ParseAnalysis
+// Decodes an answer that follows JSONSchema, stamping the results with the prompt version.
+// A subcommit typed outside the taxonomy of the prompt fails the whole answer.
+func (p Prompt) ParseAnalysis(text []byte) ([]agent.AnalysisResult, error) {
+	results, err := p.DecodeAnalysis(text)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, result := range results {
+		if !slices.Contains(p.ModificationTypes, result.ModificationType) {
+			return nil, fmt.Errorf("analysis response uses unknown modification type %q", result.ModificationType)
+		}
+	}
+	return results, nil
+}
+
+// DecodeAnalysis
+// ParseAnalysis without the taxonomy check, for agents that repair answers themselves.
+// Types are upper-cased, models asked for FEATURE sometimes answer "feature".
+func (p Prompt) DecodeAnalysis(text []byte) ([]agent.AnalysisResult, error) {
+	var response analysisResponse
+	if err := json.Unmarshal(text, &response); err != nil {
+		return nil, fmt.Errorf("decoding analysis response: %w", err)
+	}
+
+	results := make([]agent.AnalysisResult, len(response.Subcommits))
+	for i, sc := range response.Subcommits {
+		results[i] = agent.AnalysisResult{
+			Title:            sc.Title,
+			Idea:             sc.Idea,
+			Description:      sc.Description,
+			Epic:             sc.Epic,
+			ModificationType: strings.ToUpper(strings.TrimSpace(sc.ModificationType)),
+			Files:            sc.Files,
+			PromptVersion:    p.Version,
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,190 @@
+package llm
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+)
+
+// TemplateFile is the name LoadPrompts looks for in a template directory
+const TemplateFile = "commit_analysis.tmpl"
+
+//go:embed templates/commit_analysis.tmpl
+var defaultTemplate string
+
+// templateNames must all be defined by a prompt template
+var templateNames = []string{"version", "prompt", "title", "idea", "description", "epic", "type", "files", "subcommits"}
+
+// DefaultPrompts
+// The prompt templates built into the binary
+var DefaultPrompts = mustParsePrompts(defaultTemplate)
+
+// Prompts
+// A parsed prompt template, see templates/commit_analysis.tmpl for what it defines
+type Prompts struct {
+	template *template.Template
+	version  string
+}
+
+// Prompt
+// The prompt and answer schema descriptions rendered for one repository
+type Prompt struct {
+	// Version is the template version, followed by the fingerprint of the
+	// repository overrides when there are any
+	Version string
+	// Text precedes the diff and describes the commit when anything besides the diff
+	// is known about it
+	Text                   string
+	ModificationTypes      []string
+	TitleDescription       string
+	IdeaDescription        string
+	DescriptionDescription string
+	EpicDescription        string
+	TypeDescription        string
+	FilesDescription       string
+	SubcommitsDescription  string
+}
+
+type promptData struct {
+	ModificationTypes []string
+	Glossary          string
+	TitleStyle        string
+	Instructions      string
+	Commit            *commitData
+}
+
+type commitData struct {
+	SHA         string
+	Message     string
+	Author      string
+	Parents     []string
+	Files       []agent.ChangedFile
+	PullRequest *agent.PullRequest
+}
+
+// sampleCommit has every field set, ParsePrompts renders it to check templates
+var sampleCommit = agent.AnalysisInput{
+	SHA: "-", Message: "-", Author: "-", Parents: []string{"-"},
+	Files:       []agent.ChangedFile{{Path: "-", PreviousPath: "-", Status: "renamed", Additions: 1, Deletions: 1}},
+	PullRequest: &agent.PullRequest{Number: 1, Title: "-", Body: "-", URL: "-"},
+}
+
+// LoadPrompts
+// Reads TemplateFile from dir, DefaultPrompts when dir is empty
+func LoadPrompts(dir string) (*Prompts, error) {
+	if dir == "" {
+		return DefaultPrompts, nil
+	}
+
+	text, err := os.ReadFile(filepath.Join(dir, TemplateFile))
+	if err != nil {
+		return nil, err
+	}
+	return ParsePrompts(string(text))
+}
+
+// ParsePrompts
+// Fails unless text defines every template with a non-empty version and renders with
+// and without overrides and commit metadata, so a broken template is caught at startup
+func ParsePrompts(text string) (*Prompts, error) {
+	tmpl, err := template.New(TemplateFile).Funcs(template.FuncMap{"join": strings.Join}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parsing prompt template: %w", err)
+	}
+
+	for _, name := range templateNames {
+		if tmpl.Lookup(name) == nil {
+			return nil, fmt.Errorf("prompt template does not define %q", name)
+		}
+	}
+
+	prompts := &Prompts{template: tmpl}
+	version, err := prompts.execute("version", promptData{})
+	if err != nil {
+		return nil, err
+	}
+	prompts.version = strings.TrimSpace(version)
+	if prompts.version == "" {
+		return nil, errors.New("prompt template has an empty version")
+	}
+
+	if _, err := prompts.Render(agent.PromptOverrides{}, agent.AnalysisInput{}); err != nil {
+		return nil, err
+	}
+	overrides := agent.PromptOverrides{Glossary: "-", TitleStyle: "-", Instructions: "-", ModificationTypes: []string{"-"}}
+	if _, err := prompts.Render(overrides, sampleCommit); err != nil {
+		return nil, err
+	}
+	return prompts, nil
+}
+
+func mustParsePrompts(text string) *Prompts {
+	prompts, err := ParsePrompts(text)
+	if err != nil {
+		panic(err)
+	}
+	return prompts
+}
+
+func (p *Prompts) Version() string {
+	return p.version
+}
+
+// Render
+// The prompt with the overrides of a repository for one commit. The version only
+// depends on the overrides: commit metadata fills the prompt without changing its wording.
+func (p *Prompts) Render(overrides agent.PromptOverrides, input agent.AnalysisInput) (Prompt, error) {
+	data := promptData{
+		ModificationTypes: overrides.Taxonomy(),
+		Glossary:          overrides.Glossary,
+		TitleStyle:        overrides.TitleStyle,
+		Instructions:      overrides.Instructions,
+	}
+	if input.HasMetadata() {
+		data.Commit = &commitData{
+			SHA:         input.SHA,
+			Message:     strings.TrimSpace(input.Message),
+			Author:      input.Author,
+			Parents:     input.Parents,
+			Files:       input.Files,
+			PullRequest: input.PullRequest,
+		}
+	}
+
+	prompt := Prompt{Version: p.version, ModificationTypes: data.ModificationTypes}
+	if fingerprint := overrides.Fingerprint(); fingerprint != "" {
+		prompt.Version += "+" + fingerprint
+	}
+
+	for name, field := range map[string]*string{
+		"prompt":      &prompt.Text,
+		"title":       &prompt.TitleDescription,
+		"idea":        &prompt.IdeaDescription,
+		"description": &prompt.DescriptionDescription,
+		"epic":        &prompt.EpicDescription,
+		"type":        &prompt.TypeDescription,
+		"files":       &prompt.FilesDescription,
+		"subcommits":  &prompt.SubcommitsDescription,
+	} {
+		text, err := p.execute(name, data)
+		if err != nil {
+			return Prompt{}, err
+		}
+		*field = text
+	}
+	return prompt, nil
+}
+
+func (p *Prompts) execute(name string, data promptData) (string, error) {
+	var text strings.Builder
+	if err := p.template.ExecuteTemplate(&text, name, data); err != nil {
+		return "", fmt.Errorf("rendering prompt template %q: %w", name, err)
+	}
+	return text.String(), nil
+}
@@ -0,0 +1,133 @@
+package llm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+const customTemplate = `{{define "version"}}acme-3{{end}}
+{{define "prompt"}}Split this diff into subcommits typed {{join .ModificationTypes "|"}}.{{with .Glossary}} Terms: {{.}}{{end}}
+{{end}}
+{{define "title"}}Title{{end}}
+{{define "idea"}}Idea{{end}}
+{{define "description"}}Description{{end}}
+{{define "epic"}}Epic{{end}}
+{{define "type"}}Type{{end}}
+{{define "files"}}Files{{end}}
+{{define "subcommits"}}Subcommits{{end}}`
+
+type PromptsTestSuite struct {
+	suite.Suite
+}
+
+func TestPromptsTestSuite(t *testing.T) {
+	suite.Run(t, new(PromptsTestSuite))
+}
+
+func (s *PromptsTestSuite) TestDefaultPromptWithoutOverrides() {
+	prompt, err := DefaultPrompts.Render(agent.PromptOverrides{}, agent.AnalysisInput{})
+
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "2", prompt.Version)
+	assert.True(s.T(), strings.HasPrefix(prompt.Text, "You are a Commit Expert Analyzer"))
+	assert.True(s.T(), strings.HasSuffix(prompt.Text, "names\n\nNow extract the subcommits from the following diff:\n"))
+	assert.Contains(s.T(), prompt.Text, "- type: One of FEATURE, BUG, REFACTOR, DOCS, CHORE, MILESTONE, WARNING\n")
+	assert.Equal(s.T(), "An array of logical units of work that make up this commit.", prompt.SubcommitsDescription)
+}
+
+func (s *PromptsTestSuite) TestCommitMetadataPrecedesTheDiff() {
+	input := agent.AnalysisInput{
+		Diff: "diff", SHA: "c5", Message: "feat: log startup\n\nOperators asked for it.\n", Author: "Ada <ada@example.com>",
+		Files:       []agent.ChangedFile{{Path: "cmd/main.go", PreviousPath: "main.go", Status: "renamed", Additions: 3, Deletions: 1}},
+		PullRequest: &agent.PullRequest{Number: 42, Title: "Startup logging"},
+	}
+	prompt, err := DefaultPrompts.Render(agent.PromptOverrides{}, input)
+
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), DefaultPrompts.Version(), prompt.Version)
+	assert.Contains(s.T(), prompt.Text, "\nCommit message:\nfeat: log startup\n\nOperators asked for it.\n\nAuthor: Ada <ada@example.com>\n")
+	assert.Contains(s.T(), prompt.Text, "\nMerged through pull request #42: Startup logging\n")
+	assert.True(s.T(), strings.HasSuffix(prompt.Text, "\n- cmd/main.go (renamed from main.go, +3 -1)\n\nNow extract the subcommits from the following diff:\n"))
+}
+
+func (s *PromptsTestSuite) TestOverridesAreRenderedAndVersioned() {
+	overrides := agent.PromptOverrides{Glossary: "Ledger: the accounting service", TitleStyle: "Imperative mood, no trailing period"}
+	prompt, err := DefaultPrompts.Render(overrides, agent.AnalysisInput{})
+
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "2+"+overrides.Fingerprint(), prompt.Version)
+	assert.Contains(s.T(), prompt.Text, "\nLedger: the accounting service\n")
+	assert.Contains(s.T(), prompt.TitleDescription, "Imperative mood, no trailing period")
+	assert.NotContains(s.T(), prompt.Text, "Additional instructions")
+}
+
+func (s *PromptsTestSuite) TestResultsCarryThePromptVersion() {
+	prompt, _ := DefaultPrompts.Render(agent.PromptOverrides{Instructions: "Mention ticket numbers"}, agent.AnalysisInput{})
+	results, err := prompt.ParseAnalysis([]byte(`{"subcommits":[{"title":"Add login","type":"FEATURE","files":["login.go"]}]}`))
+
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), prompt.Version, results[0].PromptVersion)
+}
+
+func (s *PromptsTestSuite) TestLoadsTemplatesFromDirectory() {
+	dir := s.T().TempDir()
+	require.NoError(s.T(), os.WriteFile(filepath.Join(dir, TemplateFile), []byte(customTemplate), 0o644))
+
+	prompts, err := LoadPrompts(dir)
+	require.NoError(s.T(), err)
+	prompt, _ := prompts.Render(agent.PromptOverrides{Glossary: "PR: pull request"}, agent.AnalysisInput{})
+
+	assert.Equal(s.T(), "acme-3", prompts.Version())
+	assert.Equal(s.T(), "Split this diff into subcommits typed FEATURE|BUG|REFACTOR|DOCS|CHORE|MILESTONE|WARNING. Terms: PR: pull request\n", prompt.Text)
+}
+
+func (s *PromptsTestSuite) TestEmptyDirectoryUsesDefaultPrompts() {
+	prompts, err := LoadPrompts("")
+
+	require.NoError(s.T(), err)
+	assert.Same(s.T(), DefaultPrompts, prompts)
+}
+
+func (s *PromptsTestSuite) TestRejectsIncompleteTemplates() {
+	_, err := ParsePrompts(`{{define "version"}}2{{end}}{{define "prompt"}}Analyze{{end}}`)
+	assert.ErrorContains(s.T(), err, `"title"`)
+}
+
+func (s *PromptsTestSuite) TestRejectsTemplatesUsingUnknownFields() {
+	_, err := ParsePrompts(strings.Replace(customTemplate, "{{.}}", "{{.Owner}}", 1))
+	assert.NotNil(s.T(), err)
+}
+
+func (s *PromptsTestSuite) TestRejectsTemplatesWithoutVersion() {
+	_, err := ParsePrompts(strings.Replace(customTemplate, "acme-3", " ", 1))
+	assert.ErrorContains(s.T(), err, "empty version")
+}
+
+func (s *PromptsTestSuite) TestCustomTaxonomyReachesPromptAndSchema() {
+	overrides := agent.PromptOverrides{ModificationTypes: []string{"SECURITY", "PERF", "DEPS"}}
+	prompt, err := DefaultPrompts.Render(overrides, agent.AnalysisInput{})
+
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "2+"+overrides.Fingerprint(), prompt.Version)
+	assert.Contains(s.T(), prompt.Text, "- type: One of SECURITY, PERF, DEPS\n")
+	subcommit := prompt.JSONSchema()["properties"].(map[string]any)["subcommits"].(map[string]any)["items"].(map[string]any)
+	assert.Equal(s.T(), []string{"SECURITY", "PERF", "DEPS"}, subcommit["properties"].(map[string]any)["type"].(map[string]any)["enum"])
+}
+
+func (s *PromptsTestSuite) TestAnswersOutsideTheTaxonomyAreRejected() {
+	prompt, _ := DefaultPrompts.Render(agent.PromptOverrides{ModificationTypes: []string{"SECURITY", "PERF"}}, agent.AnalysisInput{})
+
+	results, err := prompt.ParseAnalysis([]byte(`{"subcommits":[{"title":"Escape output","type":"security","files":[]}]}`))
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "SECURITY", results[0].ModificationType)
+
+	_, err = prompt.ParseAnalysis([]byte(`{"subcommits":[{"title":"Add login","type":"FEATURE","files":[]}]}`))
+	assert.ErrorContains(s.T(), err, `"FEATURE"`)
+}
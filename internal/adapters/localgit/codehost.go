@@ -0,0 +1,288 @@
+package localgit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+)
+
+var commitSHAPattern = regexp.MustCompile(`^[0-9a-f]{4,64}$`)
+
+// commitFormat prints what parseCommit reads. Fields are NUL-separated and commits
+// end with a record separator, since messages span lines.
+const commitFormat = "--format=%H%x00%ct%x00%P%x00%an%x00%ae%x00%B%x1e"
+
+type CodeHostFactory struct {
+	root string
+}
+
+// NewCodeHostFactory
+// Serves repositories cloned under root, bare or with a working tree, addressed as
+// file:// URLs. Paths outside root are denied. Local repositories need no access token.
+func NewCodeHostFactory(root string) (*CodeHostFactory, error) {
+	if root == "" {
+		return nil, errors.New("localgit root directory is required")
+	}
+
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CodeHostFactory{root: filepath.Clean(absRoot)}, nil
+}
+
+func (f *CodeHostFactory) Create(ctx context.Context, accessToken string) (codehost.CodeHost, error) {
+	slog.Debug("Local git code host created", "root", f.root)
+	return &CodeHost{root: f.root}, nil
+}
+
+type CodeHost struct {
+	root string
+}
+
+func (ch *CodeHost) CanAccessRepo(ctx context.Context, repoURL string) error {
+	path, err := ch.repoPath(repoURL)
+	if err != nil {
+		slog.Warn("Invalid repo URL for access check", "repo_url", repoURL, "error", err)
+		return err
+	}
+
+	if _, err := ch.git(ctx, path, "rev-parse", "--git-dir"); err != nil {
+		slog.Warn("Local path is not a git repository", "path", path, "error", err)
+		return codehost.ErrAccessDenied
+	}
+
+	slog.Debug("Local git repo access confirmed", "path", path)
+	return nil
+}
+
+func (ch *CodeHost) CreateRepoFromURL(ctx context.Context, repoURL string) (*repo.Repo, error) {
+	path, err := ch.repoPath(repoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := ch.git(ctx, path, "rev-parse", "--git-dir"); err != nil {
+		slog.Error("Local path is not a git repository", "path", path, "error", err)
+		return nil, codehost.ErrAccessDenied
+	}
+
+	name := strings.TrimSuffix(filepath.Base(path), ".git")
+	slog.Info("Local git repo resolved", "repo_id", repoID(path), "name", name, "path", path)
+	return repo.NewRepo(repoID(path), name, repoURL, "", time.Now()), nil
+}
+
+// GetAuthenticatedUser
+// Local repositories have no accounts, every caller is the same local user
+func (ch *CodeHost) GetAuthenticatedUser(ctx context.Context) (*codehost.UserProfile, error) {
+	return &codehost.UserProfile{Login: "local", Name: "Local repositories"}, nil
+}
+
+// SearchRepositories
+// Lists the git repositories directly under root whose name contains query
+func (ch *CodeHost) SearchRepositories(ctx context.Context, query string) ([]codehost.RepoSearchResult, error) {
+	entries, err := os.ReadDir(ch.root)
+	if err != nil {
+		slog.Error("Failed to list local repositories", "root", ch.root, "error", err)
+		return nil, err
+	}
+
+	var results []codehost.RepoSearchResult
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if query != "" && !strings.Contains(strings.ToLower(entry.Name()), strings.ToLower(query)) {
+			continue
+		}
+
+		path := filepath.Join(ch.root, entry.Name())
+		if _, err := ch.git(ctx, path, "rev-parse", "--git-dir"); err != nil {
+			continue
+		}
+
+		results = append(results, codehost.RepoSearchResult{
+			ID:   repoID(path),
+			Name: strings.TrimSuffix(entry.Name(), ".git"),
+			URL:  (&url.URL{Scheme: "file", Path: path}).String(),
+		})
+	}
+
+	slog.Info("Local repository search completed", "query", query, "matched", len(results))
+	return results, nil
+}
+
+func (ch *CodeHost) GetRepoCommitSHAsIntoChannel(ctx context.Context, r *repo.Repo, commits chan<- codehost.CommitReference) (string, error) {
+	path, err := ch.repoPath(r.URL())
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := ch.git(ctx, path, "rev-parse", "--verify", "--quiet", "HEAD^{commit}"); err != nil {
+		slog.Info("Local git repo has no commits", "path", path)
+		return "", nil
+	}
+
+	revision := "HEAD"
+	lastSHA := r.LastAnalyzedCommitSHA()
+	if lastSHA != "" {
+		if commitSHAPattern.MatchString(lastSHA) {
+			if _, err := ch.git(ctx, path, "cat-file", "-e", lastSHA+"^{commit}"); err == nil {
+				revision = lastSHA + "..HEAD"
+			}
+		}
+		if revision == "HEAD" {
+			slog.Warn("Last analyzed commit not found in local repo, walking full history", "path", path, "last_analyzed_sha", lastSHA)
+		}
+	}
+
+	slog.Info("Fetching commits from local git repo", "path", path, "last_analyzed_sha", lastSHA)
+
+	out, err := ch.git(ctx, path, "log", "--no-merges", commitFormat, revision, "--")
+	if err != nil {
+		slog.Error("Failed to list local git commits", "path", path, "error", err)
+		return "", err
+	}
+
+	var headSHA string
+	var sentCount int
+	for _, record := range strings.Split(out, "\x1e") {
+		if strings.TrimSpace(record) == "" {
+			continue
+		}
+
+		ref, err := parseCommit(record)
+		if err != nil {
+			return "", err
+		}
+
+		if headSHA == "" {
+			headSHA = ref.SHA
+		}
+
+		select {
+		case commits <- ref:
+			sentCount++
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+
+	slog.Info("Commit fetch completed", "path", path, "sent", sentCount, "head_sha", headSHA)
+	return headSHA, nil
+}
+
+func (ch *CodeHost) GetCommitDiff(ctx context.Context, r *repo.Repo, commitSHA string) (*codehost.CommitDiff, error) {
+	path, err := ch.repoPath(r.URL())
+	if err != nil {
+		return nil, err
+	}
+
+	if !commitSHAPattern.MatchString(commitSHA) {
+		return nil, fmt.Errorf("invalid commit sha %q", commitSHA)
+	}
+
+	slog.Debug("Fetching commit diff", "path", path, "commit_sha", commitSHA)
+
+	out, err := ch.git(ctx, path, "show", commitFormat, "--patch", "--no-color", "--no-ext-diff", commitSHA, "--")
+	if err != nil {
+		slog.Error("Failed to fetch commit diff from local git repo", "path", path, "commit_sha", commitSHA, "error", err)
+		return nil, err
+	}
+
+	record, text, _ := strings.Cut(out, "\x1e")
+	ref, err := parseCommit(record)
+	if err != nil {
+		return nil, err
+	}
+	text = strings.TrimLeft(text, "\n")
+
+	diff := &codehost.CommitDiff{CommitReference: ref, Text: text, Files: codehost.DiffStats(text)}
+	slog.Debug("Commit diff fetched", "commit_sha", commitSHA, "files_count", len(diff.Files), "diff_length", len(diff.Text))
+	return diff, nil
+}
+
+// GetCommitPullRequest
+// Plain git repositories know nothing about pull requests
+func (ch *CodeHost) GetCommitPullRequest(ctx context.Context, r *repo.Repo, commitSHA string) (*codehost.PullRequest, error) {
+	if _, err := ch.repoPath(r.URL()); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// parseCommit
+// Reads a commit printed with commitFormat
+func parseCommit(record string) (codehost.CommitReference, error) {
+	fields := strings.SplitN(strings.TrimPrefix(record, "\n"), "\x00", 6)
+	if len(fields) != 6 {
+		return codehost.CommitReference{}, fmt.Errorf("unexpected git log record %q", record)
+	}
+
+	seconds, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return codehost.CommitReference{}, fmt.Errorf("unexpected commit timestamp %q: %w", fields[1], err)
+	}
+
+	return codehost.CommitReference{
+		SHA:         fields[0],
+		CommittedAt: time.Unix(seconds, 0).UTC(),
+		Message:     strings.TrimSpace(fields[5]),
+		Author:      codehost.CommitAuthor{Name: fields[3], Email: fields[4]},
+		Parents:     strings.Fields(fields[2]),
+	}, nil
+}
+
+// repoPath
+// Resolves a file:// URL to a path inside root
+func (ch *CodeHost) repoPath(repoURL string) (string, error) {
+	parsed, err := url.Parse(repoURL)
+	if err != nil || parsed.Scheme != "file" || (parsed.Host != "" && parsed.Host != "localhost") || parsed.Path == "" {
+		return "", codehost.ErrInvalidRepoURL
+	}
+
+	path := filepath.Clean(parsed.Path)
+	if path != ch.root && !strings.HasPrefix(path, ch.root+string(filepath.Separator)) {
+		slog.Warn("Local repo path is outside the configured root", "path", path, "root", ch.root)
+		return "", codehost.ErrAccessDenied
+	}
+
+	return path, nil
+}
+
+func (ch *CodeHost) git(ctx context.Context, dir string, args ...string) (string, error) {
+	// Mirrors are usually owned by another user, which git refuses to read otherwise
+	args = append([]string{"-c", "safe.directory=" + dir, "-C", dir}, args...)
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "LC_ALL=C")
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[4], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// repoID
+// Repositories on disk have no host-assigned ID, so it is derived from the path
+func repoID(path string) int64 {
+	return codehost.HashRepoID(path)
+}
@@ -0,0 +1,249 @@
+package localgit
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type LocalGitCodeHostTestSuite struct {
+	suite.Suite
+	root     string
+	workTree string
+	bare     string
+	empty    string
+	shas     []string
+	codeHost codehost.CodeHost
+}
+
+func TestLocalGitCodeHostTestSuite(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	suite.Run(t, new(LocalGitCodeHostTestSuite))
+}
+
+func (s *LocalGitCodeHostTestSuite) SetupTest() {
+	s.root = s.T().TempDir()
+	s.workTree = filepath.Join(s.root, "project")
+	s.bare = filepath.Join(s.root, "mirror.git")
+	s.empty = filepath.Join(s.root, "empty")
+
+	s.gitIn(s.root, "init", "-q", "-b", "main", s.workTree)
+	s.commitFile("main.go", "package main\n", "Add main")
+	s.commitFile("README.md", "# project\n", "Add readme")
+
+	s.gitIn(s.workTree, "checkout", "-q", "-b", "feature")
+	s.commitFile("feature.go", "package main\n\nfunc feature() {}\n", "Add feature")
+	s.gitIn(s.workTree, "checkout", "-q", "main")
+	s.commitFile("main.go", "package main\n\nfunc main() {}\n", "Fill main")
+	s.gitIn(s.workTree, "merge", "-q", "--no-ff", "-m", "Merge feature", "feature")
+
+	s.shas = strings.Fields(s.gitIn(s.workTree, "log", "--no-merges", "--format=%H"))
+	s.gitIn(s.root, "clone", "-q", "--bare", s.workTree, s.bare)
+	s.gitIn(s.root, "init", "-q", s.empty)
+
+	factory, err := NewCodeHostFactory(s.root)
+	require.NoError(s.T(), err)
+	s.codeHost, err = factory.Create(context.Background(), "")
+	require.NoError(s.T(), err)
+}
+
+func (s *LocalGitCodeHostTestSuite) gitIn(dir string, args ...string) string {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com",
+		"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1",
+	)
+	out, err := cmd.CombinedOutput()
+	require.NoError(s.T(), err, string(out))
+	return string(out)
+}
+
+func (s *LocalGitCodeHostTestSuite) commitFile(name, content, message string) {
+	require.NoError(s.T(), os.WriteFile(filepath.Join(s.workTree, name), []byte(content), 0o644))
+	s.gitIn(s.workTree, "add", name)
+	s.gitIn(s.workTree, "commit", "-q", "-m", message)
+}
+
+func fileURL(path string) string {
+	return "file://" + path
+}
+
+func (s *LocalGitCodeHostTestSuite) collectCommits(r *repo.Repo) ([]codehost.CommitReference, string, error) {
+	commits := make(chan codehost.CommitReference, 100)
+	headSHA, err := s.codeHost.GetRepoCommitSHAsIntoChannel(context.Background(), r, commits)
+	close(commits)
+
+	var refs []codehost.CommitReference
+	for ref := range commits {
+		refs = append(refs, ref)
+	}
+	return refs, headSHA, err
+}
+
+func (s *LocalGitCodeHostTestSuite) TestFactoryRequiresRoot() {
+	_, err := NewCodeHostFactory("")
+	assert.NotNil(s.T(), err)
+}
+
+func (s *LocalGitCodeHostTestSuite) TestCanAccessWorkTreeAndBareRepos() {
+	assert.Nil(s.T(), s.codeHost.CanAccessRepo(context.Background(), fileURL(s.workTree)))
+	assert.Nil(s.T(), s.codeHost.CanAccessRepo(context.Background(), fileURL(s.bare)))
+}
+
+func (s *LocalGitCodeHostTestSuite) TestCannotAccessNonFileURL() {
+	err := s.codeHost.CanAccessRepo(context.Background(), "https://github.com/octokerbs/chronocode")
+	assert.True(s.T(), errors.Is(err, codehost.ErrInvalidRepoURL))
+}
+
+func (s *LocalGitCodeHostTestSuite) TestCannotAccessPathOutsideRoot() {
+	outside := s.T().TempDir()
+	s.gitIn(outside, "init", "-q")
+
+	err := s.codeHost.CanAccessRepo(context.Background(), fileURL(outside))
+	assert.True(s.T(), errors.Is(err, codehost.ErrAccessDenied))
+
+	err = s.codeHost.CanAccessRepo(context.Background(), fileURL(s.root+"/../"+filepath.Base(outside)))
+	assert.True(s.T(), errors.Is(err, codehost.ErrAccessDenied))
+}
+
+func (s *LocalGitCodeHostTestSuite) TestCannotAccessDirectoryThatIsNotARepo() {
+	plain := filepath.Join(s.root, "plain")
+	require.NoError(s.T(), os.Mkdir(plain, 0o755))
+
+	err := s.codeHost.CanAccessRepo(context.Background(), fileURL(plain))
+	assert.True(s.T(), errors.Is(err, codehost.ErrAccessDenied))
+}
+
+func (s *LocalGitCodeHostTestSuite) TestCreateRepoFromURLUsesDirectoryName() {
+	r, err := s.codeHost.CreateRepoFromURL(context.Background(), fileURL(s.bare))
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), "mirror", r.Name())
+	assert.Equal(s.T(), fileURL(s.bare), r.URL())
+	assert.NotZero(s.T(), r.ID())
+}
+
+func (s *LocalGitCodeHostTestSuite) TestRepoIDIsStablePerPath() {
+	first, _ := s.codeHost.CreateRepoFromURL(context.Background(), fileURL(s.bare))
+	second, _ := s.codeHost.CreateRepoFromURL(context.Background(), fileURL(s.bare))
+	other, _ := s.codeHost.CreateRepoFromURL(context.Background(), fileURL(s.workTree))
+
+	assert.Equal(s.T(), first.ID(), second.ID())
+	assert.NotEqual(s.T(), first.ID(), other.ID())
+}
+
+func (s *LocalGitCodeHostTestSuite) TestSendsNonMergeCommitsNewestFirst() {
+	r, _ := s.codeHost.CreateRepoFromURL(context.Background(), fileURL(s.bare))
+	refs, headSHA, err := s.collectCommits(r)
+
+	assert.Nil(s.T(), err)
+	assert.Len(s.T(), refs, 4)
+	assert.Equal(s.T(), s.shas[0], headSHA)
+	for i, ref := range refs {
+		assert.Equal(s.T(), s.shas[i], ref.SHA)
+		assert.False(s.T(), ref.CommittedAt.IsZero())
+	}
+}
+
+func (s *LocalGitCodeHostTestSuite) TestStopsAtLastAnalyzedCommit() {
+	oldest := s.shas[len(s.shas)-1]
+	r := repo.NewRepo(1, "mirror", fileURL(s.bare), oldest, time.Time{})
+	refs, headSHA, err := s.collectCommits(r)
+
+	assert.Nil(s.T(), err)
+	assert.Len(s.T(), refs, 3)
+	assert.Equal(s.T(), s.shas[0], headSHA)
+	for _, ref := range refs {
+		assert.NotEqual(s.T(), oldest, ref.SHA)
+	}
+}
+
+func (s *LocalGitCodeHostTestSuite) TestNothingNewSinceLastAnalyzedCommit() {
+	head := strings.TrimSpace(s.gitIn(s.workTree, "rev-parse", "HEAD"))
+	r := repo.NewRepo(1, "mirror", fileURL(s.bare), head, time.Time{})
+	refs, headSHA, err := s.collectCommits(r)
+
+	assert.Nil(s.T(), err)
+	assert.Empty(s.T(), refs)
+	assert.Equal(s.T(), "", headSHA)
+}
+
+func (s *LocalGitCodeHostTestSuite) TestUnknownLastAnalyzedCommitWalksFullHistory() {
+	r := repo.NewRepo(1, "mirror", fileURL(s.bare), "0123456789abcdef0123456789abcdef01234567", time.Time{})
+	refs, _, err := s.collectCommits(r)
+
+	assert.Nil(s.T(), err)
+	assert.Len(s.T(), refs, 4)
+}
+
+func (s *LocalGitCodeHostTestSuite) TestEmptyRepoHasNoCommits() {
+	r := repo.NewRepo(1, "empty", fileURL(s.empty), "", time.Time{})
+	refs, headSHA, err := s.collectCommits(r)
+
+	assert.Nil(s.T(), err)
+	assert.Empty(s.T(), refs)
+	assert.Equal(s.T(), "", headSHA)
+}
+
+func (s *LocalGitCodeHostTestSuite) TestCommitDiffContainsChanges() {
+	r := repo.NewRepo(1, "mirror", fileURL(s.bare), "", time.Time{})
+	diff, err := s.codeHost.GetCommitDiff(context.Background(), r, s.shas[0])
+
+	assert.Nil(s.T(), err)
+	assert.True(s.T(), strings.HasPrefix(diff.Text, "diff --git a/main.go b/main.go"))
+	assert.Contains(s.T(), diff.Text, "+func main() {}")
+}
+
+func (s *LocalGitCodeHostTestSuite) TestCommitDiffCarriesMetadataAndFileStats() {
+	r := repo.NewRepo(1, "mirror", fileURL(s.bare), "", time.Time{})
+	diff, err := s.codeHost.GetCommitDiff(context.Background(), r, s.shas[0])
+
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), s.shas[0], diff.SHA)
+	assert.Equal(s.T(), "Fill main", diff.Message)
+	assert.Equal(s.T(), codehost.CommitAuthor{Name: "Test", Email: "test@example.com"}, diff.Author)
+	assert.Len(s.T(), diff.Parents, 1)
+	assert.Equal(s.T(), []codehost.FileStat{{Path: "main.go", Status: codehost.FileModified, Additions: 2}}, diff.Files)
+}
+
+func (s *LocalGitCodeHostTestSuite) TestRootCommitDiffContainsChanges() {
+	r := repo.NewRepo(1, "mirror", fileURL(s.bare), "", time.Time{})
+	diff, err := s.codeHost.GetCommitDiff(context.Background(), r, s.shas[len(s.shas)-1])
+
+	assert.Nil(s.T(), err)
+	assert.Contains(s.T(), diff.Text, "+package main")
+	assert.Empty(s.T(), diff.Parents)
+	assert.Equal(s.T(), codehost.FileAdded, diff.Files[0].Status)
+}
+
+func (s *LocalGitCodeHostTestSuite) TestCommitDiffRejectsInvalidSHA() {
+	r := repo.NewRepo(1, "mirror", fileURL(s.bare), "", time.Time{})
+	_, err := s.codeHost.GetCommitDiff(context.Background(), r, "--output=/tmp/x")
+
+	assert.NotNil(s.T(), err)
+}
+
+func (s *LocalGitCodeHostTestSuite) TestSearchListsReposUnderRoot() {
+	results, err := s.codeHost.SearchRepositories(context.Background(), "")
+
+	assert.Nil(s.T(), err)
+	assert.Len(s.T(), results, 3)
+
+	results, _ = s.codeHost.SearchRepositories(context.Background(), "MIRROR")
+	assert.Len(s.T(), results, 1)
+	assert.Equal(s.T(), fileURL(s.bare), results[0].URL)
+}
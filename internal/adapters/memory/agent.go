@@ -12,8 +12,8 @@ func NewAgent() *Agent {
 	return &Agent{}
 }
 
-func (a *Agent) AnalyzeDiff(ctx context.Context, diff string) ([]agent.AnalysisResult, error) {
-	if diff == FailingDiff {
+func (a *Agent) AnalyzeCommit(ctx context.Context, input agent.AnalysisInput) ([]agent.AnalysisResult, error) {
+	if input.Diff == FailingDiff {
 		return nil, agent.ErrAnalysisFailed
 	}
 
@@ -0,0 +1,34 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+)
+
+type AnalysisCache struct {
+	mu      sync.Mutex
+	entries map[string][]agent.AnalysisResult
+}
+
+func NewAnalysisCache() *AnalysisCache {
+	return &AnalysisCache{entries: map[string][]agent.AnalysisResult{}}
+}
+
+func (c *AnalysisCache) GetAnalysis(ctx context.Context, key string) ([]agent.AnalysisResult, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	results, ok := c.entries[key]
+	return slices.Clone(results), ok, nil
+}
+
+func (c *AnalysisCache) StoreAnalysis(ctx context.Context, key string, results []agent.AnalysisResult) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = slices.Clone(results)
+	return nil
+}
@@ -0,0 +1,58 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/octokerbs/chronocode/internal/domain/analysis"
+)
+
+type AnalysisRunRepository struct {
+	mu     sync.Mutex
+	runs   map[int64]analysis.Run
+	nextID int64
+}
+
+func NewAnalysisRunRepository() *AnalysisRunRepository {
+	return &AnalysisRunRepository{runs: map[int64]analysis.Run{}}
+}
+
+func (r *AnalysisRunRepository) StoreRun(ctx context.Context, run *analysis.Run) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if run.ID() == 0 {
+		r.nextID++
+		run.SetID(r.nextID)
+	}
+	r.runs[run.ID()] = *run
+	return nil
+}
+
+func (r *AnalysisRunRepository) GetRun(ctx context.Context, id int64) (*analysis.Run, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	run, ok := r.runs[id]
+	if !ok {
+		return nil, analysis.ErrRunNotFound
+	}
+	return &run, nil
+}
+
+func (r *AnalysisRunRepository) ListRuns(ctx context.Context, repoID int64) ([]*analysis.Run, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result []*analysis.Run
+	for _, run := range r.runs {
+		if run.RepoID() == repoID {
+			run := run
+			result = append(result, &run)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].ID() > result[j].ID() })
+	return result, nil
+}
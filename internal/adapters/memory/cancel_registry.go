@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/octokerbs/chronocode/internal/domain/analysis"
+)
+
+type cancelEntry struct {
+	cancel context.CancelCauseFunc
+}
+
+type InMemoryCancelRegistry struct {
+	mu      sync.Mutex
+	entries map[int64]*cancelEntry
+}
+
+func NewInMemoryCancelRegistry() *InMemoryCancelRegistry {
+	return &InMemoryCancelRegistry{entries: make(map[int64]*cancelEntry)}
+}
+
+func (r *InMemoryCancelRegistry) Register(_ context.Context, repoID int64, cancel context.CancelCauseFunc) func() {
+	entry := &cancelEntry{cancel: cancel}
+
+	r.mu.Lock()
+	r.entries[repoID] = entry
+	r.mu.Unlock()
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.entries[repoID] == entry {
+			delete(r.entries, repoID)
+		}
+	}
+}
+
+func (r *InMemoryCancelRegistry) Cancel(_ context.Context, repoID int64) error {
+	r.mu.Lock()
+	entry, ok := r.entries[repoID]
+	r.mu.Unlock()
+
+	if !ok {
+		return analysis.ErrNoAnalysisRunning
+	}
+
+	entry.cancel(analysis.ErrAnalysisCancelled)
+	return nil
+}
@@ -38,6 +38,10 @@ var (
 	InvalidAccessToken = "invalid-token"
 	ValidCommitDiff    = "diff --git a/main.go b/main.go\n+func main() {}"
 	FailingDiff        = "failing-diff"
+	ValidCommitMessage = "feat: add main entrypoint"
+	ValidCommitAuthor  = codehost.CommitAuthor{Name: "Test User", Email: "test@example.com"}
+	// ValidPullRequest merged ValidRepoCommitSHA, the other commits were pushed directly
+	ValidPullRequest = codehost.PullRequest{Number: 7, Title: "Add main entrypoint", Body: "Closes #3", URL: "https://github.com/octokerbs/chronocode/pull/7", MergedAt: time.Date(2025, 1, 16, 9, 0, 0, 0, time.UTC)}
 
 	MockRepoCreatedAt = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
 )
@@ -129,12 +133,21 @@ func (c *CodeHost) GetRepoCommitSHAsIntoChannel(ctx context.Context, r *repo.Rep
 	return headSHA, nil
 }
 
-func (c *CodeHost) GetCommitDiff(ctx context.Context, r *repo.Repo, commitSHA string) (string, error) {
+func (c *CodeHost) GetCommitDiff(ctx context.Context, r *repo.Repo, commitSHA string) (*codehost.CommitDiff, error) {
+	ref := codehost.CommitReference{SHA: commitSHA, Message: ValidCommitMessage, Author: ValidCommitAuthor}
 	if commitSHA == FailingCommitSHA {
-		return FailingDiff, nil
+		return &codehost.CommitDiff{CommitReference: ref, Text: FailingDiff}, nil
 	}
 
-	return ValidCommitDiff, nil
+	return &codehost.CommitDiff{CommitReference: ref, Text: ValidCommitDiff, Files: []codehost.FileStat{{Path: "main.go", Status: codehost.FileModified, Additions: 1}}}, nil
+}
+
+func (c *CodeHost) GetCommitPullRequest(ctx context.Context, r *repo.Repo, commitSHA string) (*codehost.PullRequest, error) {
+	if commitSHA != ValidRepoCommitSHA {
+		return nil, nil
+	}
+	pr := ValidPullRequest
+	return &pr, nil
 }
 
 func (c *CodeHost) GetAuthenticatedUser(ctx context.Context) (*codehost.UserProfile, error) {
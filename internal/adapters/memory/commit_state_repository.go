@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+	"github.com/octokerbs/chronocode/internal/domain/analysis"
+)
+
+type commitStateKey struct {
+	repoID    int64
+	commitSHA string
+}
+
+type CommitStateRepository struct {
+	mu     sync.Mutex
+	states map[commitStateKey]analysis.CommitState
+}
+
+func NewCommitStateRepository() *CommitStateRepository {
+	return &CommitStateRepository{states: map[commitStateKey]analysis.CommitState{}}
+}
+
+func (r *CommitStateRepository) ListCommitStates(ctx context.Context, repoID int64, statuses ...analysis.CommitStatus) ([]*analysis.CommitState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result []*analysis.CommitState
+	for key, state := range r.states {
+		if key.repoID != repoID {
+			continue
+		}
+		if len(statuses) > 0 && !slices.Contains(statuses, state.Status()) {
+			continue
+		}
+		state := state
+		result = append(result, &state)
+	}
+	return result, nil
+}
+
+func (r *CommitStateRepository) StoreCommitState(ctx context.Context, state *analysis.CommitState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.states[commitStateKey{state.RepoID(), state.CommitSHA()}] = *state
+	return nil
+}
+
+func (r *CommitStateRepository) RepoUsage(ctx context.Context, repoID int64) (agent.Usage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var usage agent.Usage
+	for key, state := range r.states {
+		if key.repoID == repoID {
+			usage = usage.Add(state.Usage())
+		}
+	}
+	return usage, nil
+}
+
+func (r *CommitStateRepository) TotalUsage(ctx context.Context) (agent.Usage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var usage agent.Usage
+	for _, state := range r.states {
+		usage = usage.Add(state.Usage())
+	}
+	return usage, nil
+}
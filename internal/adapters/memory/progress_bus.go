@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/octokerbs/chronocode/internal/domain/analysis"
+)
+
+const progressSubscriberBuffer = 256
+
+type InMemoryProgressBus struct {
+	mu          sync.RWMutex
+	subscribers map[int64]map[chan analysis.ProgressEvent]struct{}
+}
+
+func NewInMemoryProgressBus() *InMemoryProgressBus {
+	return &InMemoryProgressBus{subscribers: make(map[int64]map[chan analysis.ProgressEvent]struct{})}
+}
+
+func (b *InMemoryProgressBus) Publish(_ context.Context, event analysis.ProgressEvent) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for ch := range b.subscribers[event.RepoID] {
+		select {
+		case ch <- event:
+		default:
+			slog.Warn("Progress subscriber is falling behind, dropping event", "repo_id", event.RepoID, "type", event.Type)
+		}
+	}
+}
+
+func (b *InMemoryProgressBus) Subscribe(_ context.Context, repoID int64) (<-chan analysis.ProgressEvent, func()) {
+	ch := make(chan analysis.ProgressEvent, progressSubscriberBuffer)
+
+	b.mu.Lock()
+	if b.subscribers[repoID] == nil {
+		b.subscribers[repoID] = make(map[chan analysis.ProgressEvent]struct{})
+	}
+	b.subscribers[repoID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers[repoID], ch)
+			if len(b.subscribers[repoID]) == 0 {
+				delete(b.subscribers, repoID)
+			}
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+
+	return ch, unsubscribe
+}
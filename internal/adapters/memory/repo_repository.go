@@ -3,6 +3,7 @@ package memory
 import (
 	"context"
 
+	"github.com/octokerbs/chronocode/internal/domain/agent"
 	"github.com/octokerbs/chronocode/internal/domain/repo"
 )
 
@@ -41,7 +42,100 @@ func (r *RepoRepository) ListRepos(ctx context.Context) ([]*repo.Repo, error) {
 	return result, nil
 }
 
+// StoreRepo
+// Keeps the stored ignore patterns, budget, prompt overrides, taxonomy and issue trackers,
+// like the postgres repository does
 func (r *RepoRepository) StoreRepo(ctx context.Context, aRepo *repo.Repo) error {
-	r.repos[aRepo.URL()] = *aRepo
+	stored := *aRepo
+	if existing, ok := r.repos[aRepo.URL()]; ok {
+		stored.SetIgnorePatterns(existingPatterns(&existing))
+		stored.SetBudget(existing.Budget())
+		stored.SetPromptOverrides(existing.PromptOverrides())
+		stored.SetModificationTypes(existingModificationTypes(&existing))
+		stored.SetIssueTrackers(existingIssueTrackers(&existing))
+	} else {
+		stored.SetIgnorePatterns(nil)
+		stored.SetBudget(agent.Budget{})
+		stored.SetPromptOverrides(agent.PromptOverrides{})
+		stored.SetModificationTypes(nil)
+		stored.SetIssueTrackers(nil)
+	}
+	r.repos[aRepo.URL()] = stored
 	return nil
 }
+
+func (r *RepoRepository) UpdateIgnorePatterns(ctx context.Context, id int64, patterns []string) error {
+	for url, rp := range r.repos {
+		if rp.ID() == id {
+			rp.SetIgnorePatterns(patterns)
+			r.repos[url] = rp
+			return nil
+		}
+	}
+	return repo.ErrRepositoryNotFound
+}
+
+func (r *RepoRepository) UpdateBudget(ctx context.Context, id int64, budget agent.Budget) error {
+	for url, rp := range r.repos {
+		if rp.ID() == id {
+			rp.SetBudget(budget)
+			r.repos[url] = rp
+			return nil
+		}
+	}
+	return repo.ErrRepositoryNotFound
+}
+
+func (r *RepoRepository) UpdatePromptOverrides(ctx context.Context, id int64, overrides agent.PromptOverrides) error {
+	for url, rp := range r.repos {
+		if rp.ID() == id {
+			rp.SetPromptOverrides(overrides)
+			r.repos[url] = rp
+			return nil
+		}
+	}
+	return repo.ErrRepositoryNotFound
+}
+
+func (r *RepoRepository) UpdateModificationTypes(ctx context.Context, id int64, types []string) error {
+	for url, rp := range r.repos {
+		if rp.ID() == id {
+			rp.SetModificationTypes(types)
+			r.repos[url] = rp
+			return nil
+		}
+	}
+	return repo.ErrRepositoryNotFound
+}
+
+func (r *RepoRepository) UpdateIssueTrackers(ctx context.Context, id int64, trackers []repo.IssueTracker) error {
+	for url, rp := range r.repos {
+		if rp.ID() == id {
+			rp.SetIssueTrackers(trackers)
+			r.repos[url] = rp
+			return nil
+		}
+	}
+	return repo.ErrRepositoryNotFound
+}
+
+func existingPatterns(r *repo.Repo) []string {
+	if !r.HasCustomIgnorePatterns() {
+		return nil
+	}
+	return r.IgnorePatterns()
+}
+
+func existingModificationTypes(r *repo.Repo) []string {
+	if !r.HasCustomModificationTypes() {
+		return nil
+	}
+	return r.ModificationTypes()
+}
+
+func existingIssueTrackers(r *repo.Repo) []repo.IssueTracker {
+	if !r.HasCustomIssueTrackers() {
+		return nil
+	}
+	return r.IssueTrackers()
+}
@@ -2,11 +2,13 @@ package memory
 
 import (
 	"context"
+	"sync"
 
 	"github.com/octokerbs/chronocode/internal/domain/subcommit"
 )
 
 type SubcommitRepository struct {
+	mu         sync.RWMutex
 	subcommits []subcommit.Subcommit
 }
 
@@ -15,6 +17,9 @@ func NewSubcommitRepository() *SubcommitRepository {
 }
 
 func (s *SubcommitRepository) GetSubcommits(ctx context.Context, repoID int64) ([]subcommit.Subcommit, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	repoSubcommits := []subcommit.Subcommit{}
 	for _, sc := range s.subcommits {
 		if sc.RepoID() == repoID {
@@ -25,7 +30,24 @@ func (s *SubcommitRepository) GetSubcommits(ctx context.Context, repoID int64) (
 	return repoSubcommits, nil
 }
 
+func (s *SubcommitRepository) GetSubcommitsByIssue(ctx context.Context, repoID int64, ref string) ([]subcommit.Subcommit, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	issueSubcommits := []subcommit.Subcommit{}
+	for _, sc := range s.subcommits {
+		if sc.RepoID() == repoID && sc.MentionsIssue(ref) {
+			issueSubcommits = append(issueSubcommits, sc)
+		}
+	}
+
+	return issueSubcommits, nil
+}
+
 func (s *SubcommitRepository) HasSubcommitsForCommit(ctx context.Context, repoID int64, commitSHA string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	for _, sc := range s.subcommits {
 		if sc.RepoID() == repoID && sc.CommitSHA() == commitSHA {
 			return true, nil
@@ -36,7 +58,9 @@ func (s *SubcommitRepository) HasSubcommitsForCommit(ctx context.Context, repoID
 
 func (s *SubcommitRepository) StoreSubcommits(ctx context.Context, subcommits <-chan subcommit.Subcommit) error {
 	for sc := range subcommits {
+		s.mu.Lock()
 		s.subcommits = append(s.subcommits, sc)
+		s.mu.Unlock()
 	}
 	return nil
 }
@@ -0,0 +1,175 @@
+package multihost
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+)
+
+// FileScheme
+// Route key of file:// URLs, which have no host
+const FileScheme = "file"
+
+type CodeHostFactory struct {
+	defaultHost string
+	factories   map[string]codehost.CodeHostFactory
+}
+
+// NewCodeHostFactory
+// Routes every repository to the factory registered for its URL host. Calls that are
+// not about a repository (user profile, search) go to the factory of defaultHost.
+func NewCodeHostFactory(defaultHost string, factory codehost.CodeHostFactory) *CodeHostFactory {
+	f := &CodeHostFactory{defaultHost: strings.ToLower(defaultHost), factories: map[string]codehost.CodeHostFactory{}}
+	f.Register(defaultHost, factory)
+	return f
+}
+
+// Register
+// Serves repositories on host with factory; use FileScheme for file:// URLs
+func (f *CodeHostFactory) Register(host string, factory codehost.CodeHostFactory) {
+	f.factories[strings.ToLower(host)] = factory
+}
+
+// RegisterWithHostToken
+// Serves repositories on host with factory, creating its code hosts with the caller's
+// own token for host (see codehost.WithHostTokens) instead of their sign-in token. The
+// factory gets an empty token when the caller has none.
+func (f *CodeHostFactory) RegisterWithHostToken(host string, factory codehost.CodeHostFactory) {
+	f.Register(host, hostTokenFactory{host: host, factory: factory})
+}
+
+type hostTokenFactory struct {
+	host    string
+	factory codehost.CodeHostFactory
+}
+
+func (f hostTokenFactory) Create(ctx context.Context, accessToken string) (codehost.CodeHost, error) {
+	return f.factory.Create(ctx, codehost.HostToken(ctx, f.host))
+}
+
+// Create
+// Code hosts are created lazily, on the first call for their host, so that a token
+// rejected by one host does not prevent using the others
+func (f *CodeHostFactory) Create(ctx context.Context, accessToken string) (codehost.CodeHost, error) {
+	// Pipelines keep using the code host after the request that created it is done
+	return &CodeHost{ctx: context.WithoutCancel(ctx), accessToken: accessToken, factory: f, created: map[string]codehost.CodeHost{}}, nil
+}
+
+type CodeHost struct {
+	ctx         context.Context
+	accessToken string
+	factory     *CodeHostFactory
+
+	mu      sync.Mutex
+	created map[string]codehost.CodeHost
+}
+
+func (ch *CodeHost) CanAccessRepo(ctx context.Context, repoURL string) error {
+	host, err := ch.forURL(repoURL)
+	if err != nil {
+		return err
+	}
+	return host.CanAccessRepo(ctx, repoURL)
+}
+
+func (ch *CodeHost) CreateRepoFromURL(ctx context.Context, repoURL string) (*repo.Repo, error) {
+	host, err := ch.forURL(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	return host.CreateRepoFromURL(ctx, repoURL)
+}
+
+func (ch *CodeHost) GetRepoCommitSHAsIntoChannel(ctx context.Context, r *repo.Repo, commits chan<- codehost.CommitReference) (string, error) {
+	host, err := ch.forURL(r.URL())
+	if err != nil {
+		return "", err
+	}
+	return host.GetRepoCommitSHAsIntoChannel(ctx, r, commits)
+}
+
+func (ch *CodeHost) GetCommitDiff(ctx context.Context, r *repo.Repo, commitSHA string) (*codehost.CommitDiff, error) {
+	host, err := ch.forURL(r.URL())
+	if err != nil {
+		return nil, err
+	}
+	return host.GetCommitDiff(ctx, r, commitSHA)
+}
+
+func (ch *CodeHost) GetCommitPullRequest(ctx context.Context, r *repo.Repo, commitSHA string) (*codehost.PullRequest, error) {
+	host, err := ch.forURL(r.URL())
+	if err != nil {
+		return nil, err
+	}
+	return host.GetCommitPullRequest(ctx, r, commitSHA)
+}
+
+func (ch *CodeHost) GetAuthenticatedUser(ctx context.Context) (*codehost.UserProfile, error) {
+	host, err := ch.forHost(ch.factory.defaultHost)
+	if err != nil {
+		return nil, err
+	}
+	return host.GetAuthenticatedUser(ctx)
+}
+
+func (ch *CodeHost) SearchRepositories(ctx context.Context, query string) ([]codehost.RepoSearchResult, error) {
+	host, err := ch.forHost(ch.factory.defaultHost)
+	if err != nil {
+		return nil, err
+	}
+	return host.SearchRepositories(ctx, query)
+}
+
+func (ch *CodeHost) forURL(repoURL string) (codehost.CodeHost, error) {
+	key, err := routeKey(repoURL)
+	if err != nil {
+		slog.Warn("Cannot route repo URL to a code host", "repo_url", repoURL, "error", err)
+		return nil, codehost.ErrInvalidRepoURL
+	}
+	return ch.forHost(key)
+}
+
+func (ch *CodeHost) forHost(key string) (codehost.CodeHost, error) {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	if host, ok := ch.created[key]; ok {
+		return host, nil
+	}
+
+	factory, ok := ch.factory.factories[key]
+	if !ok {
+		slog.Warn("No code host configured for host", "host", key)
+		return nil, fmt.Errorf("%w: no code host configured for %q", codehost.ErrInvalidRepoURL, key)
+	}
+
+	host, err := factory.Create(ch.ctx, ch.accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Debug("Code host created for host", "host", key)
+	ch.created[key] = host
+	return host, nil
+}
+
+func routeKey(repoURL string) (string, error) {
+	parsed, err := url.Parse(repoURL)
+	if err != nil {
+		return "", err
+	}
+
+	if parsed.Scheme == FileScheme {
+		return FileScheme, nil
+	}
+	if parsed.Host == "" {
+		return "", fmt.Errorf("url '%s' has no host", repoURL)
+	}
+	return strings.ToLower(parsed.Host), nil
+}
@@ -0,0 +1,163 @@
+package multihost
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// namedFactory creates code hosts that answer every call with their own name
+type namedFactory struct {
+	name    string
+	created int
+	err     error
+}
+
+func (f *namedFactory) Create(ctx context.Context, accessToken string) (codehost.CodeHost, error) {
+	f.created++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &namedCodeHost{name: f.name}, nil
+}
+
+type namedCodeHost struct {
+	name string
+}
+
+func (c *namedCodeHost) CanAccessRepo(ctx context.Context, repoURL string) error {
+	return nil
+}
+
+func (c *namedCodeHost) CreateRepoFromURL(ctx context.Context, url string) (*repo.Repo, error) {
+	return repo.NewRepo(1, c.name, url, "", time.Time{}), nil
+}
+
+func (c *namedCodeHost) GetRepoCommitSHAsIntoChannel(ctx context.Context, r *repo.Repo, commits chan<- codehost.CommitReference) (string, error) {
+	return c.name, nil
+}
+
+func (c *namedCodeHost) GetCommitDiff(ctx context.Context, r *repo.Repo, commitSHA string) (*codehost.CommitDiff, error) {
+	return &codehost.CommitDiff{Text: c.name}, nil
+}
+
+func (c *namedCodeHost) GetCommitPullRequest(ctx context.Context, r *repo.Repo, commitSHA string) (*codehost.PullRequest, error) {
+	return &codehost.PullRequest{Title: c.name}, nil
+}
+
+func (c *namedCodeHost) GetAuthenticatedUser(ctx context.Context) (*codehost.UserProfile, error) {
+	return &codehost.UserProfile{Login: c.name}, nil
+}
+
+func (c *namedCodeHost) SearchRepositories(ctx context.Context, query string) ([]codehost.RepoSearchResult, error) {
+	return []codehost.RepoSearchResult{{Name: c.name}}, nil
+}
+
+type MultiHostCodeHostTestSuite struct {
+	suite.Suite
+	github   *namedFactory
+	gitlab   *namedFactory
+	local    *namedFactory
+	codeHost codehost.CodeHost
+}
+
+func TestMultiHostCodeHostTestSuite(t *testing.T) {
+	suite.Run(t, new(MultiHostCodeHostTestSuite))
+}
+
+func (s *MultiHostCodeHostTestSuite) SetupTest() {
+	s.github = &namedFactory{name: "github"}
+	s.gitlab = &namedFactory{name: "gitlab"}
+	s.local = &namedFactory{name: "local"}
+
+	factory := NewCodeHostFactory("github.com", s.github)
+	factory.Register("GitLab.Example", s.gitlab)
+	factory.Register(FileScheme, s.local)
+	s.codeHost, _ = factory.Create(context.Background(), "token")
+}
+
+func (s *MultiHostCodeHostTestSuite) TestRoutesRepositoriesByHost() {
+	github, _ := s.codeHost.CreateRepoFromURL(context.Background(), "https://github.com/octokerbs/chronocode")
+	gitlab, _ := s.codeHost.CreateRepoFromURL(context.Background(), "https://gitlab.example/platform/api")
+	local, _ := s.codeHost.CreateRepoFromURL(context.Background(), "file:///srv/mirrors/api.git")
+
+	assert.Equal(s.T(), "github", github.Name())
+	assert.Equal(s.T(), "gitlab", gitlab.Name())
+	assert.Equal(s.T(), "local", local.Name())
+}
+
+func (s *MultiHostCodeHostTestSuite) TestRoutesRepoCallsByRepoURL() {
+	r := repo.NewRepo(1, "api", "https://gitlab.example/platform/api", "", time.Time{})
+	diff, _ := s.codeHost.GetCommitDiff(context.Background(), r, "sha")
+
+	assert.Equal(s.T(), "gitlab", diff.Text)
+
+	pr, _ := s.codeHost.GetCommitPullRequest(context.Background(), r, "sha")
+	assert.Equal(s.T(), "gitlab", pr.Title)
+}
+
+func (s *MultiHostCodeHostTestSuite) TestUserCallsGoToDefaultHost() {
+	profile, _ := s.codeHost.GetAuthenticatedUser(context.Background())
+	results, _ := s.codeHost.SearchRepositories(context.Background(), "")
+
+	assert.Equal(s.T(), "github", profile.Login)
+	assert.Equal(s.T(), "github", results[0].Name)
+}
+
+func (s *MultiHostCodeHostTestSuite) TestUnknownHostIsInvalidURL() {
+	err := s.codeHost.CanAccessRepo(context.Background(), "https://bitbucket.org/team/repo")
+	assert.True(s.T(), errors.Is(err, codehost.ErrInvalidRepoURL))
+
+	err = s.codeHost.CanAccessRepo(context.Background(), "not a url")
+	assert.True(s.T(), errors.Is(err, codehost.ErrInvalidRepoURL))
+}
+
+func (s *MultiHostCodeHostTestSuite) TestCodeHostsAreCreatedOncePerHost() {
+	_ = s.codeHost.CanAccessRepo(context.Background(), "https://gitlab.example/platform/api")
+	_ = s.codeHost.CanAccessRepo(context.Background(), "https://gitlab.example/platform/web")
+
+	assert.Equal(s.T(), 1, s.gitlab.created)
+	assert.Equal(s.T(), 0, s.github.created)
+}
+
+func (s *MultiHostCodeHostTestSuite) TestCreationErrorOnlyAffectsItsHost() {
+	s.github.err = codehost.ErrAccessDenied
+
+	err := s.codeHost.CanAccessRepo(context.Background(), "https://github.com/octokerbs/chronocode")
+	assert.True(s.T(), errors.Is(err, codehost.ErrAccessDenied))
+	assert.Nil(s.T(), s.codeHost.CanAccessRepo(context.Background(), "file:///srv/mirrors/api.git"))
+}
+
+// tokenFactory records the token its code hosts were created with
+type tokenFactory struct {
+	namedFactory
+	token string
+}
+
+func (f *tokenFactory) Create(ctx context.Context, accessToken string) (codehost.CodeHost, error) {
+	f.token = accessToken
+	return f.namedFactory.Create(ctx, accessToken)
+}
+
+func (s *MultiHostCodeHostTestSuite) TestHostTokenFactoriesNeverGetTheSignInToken() {
+	gitea := &tokenFactory{namedFactory: namedFactory{name: "gitea"}}
+	forgejo := &tokenFactory{namedFactory: namedFactory{name: "forgejo"}}
+	factory := NewCodeHostFactory("github.com", s.github)
+	factory.RegisterWithHostToken("gitea.example", gitea)
+	factory.RegisterWithHostToken("forgejo.example", forgejo)
+
+	ctx := codehost.WithHostTokens(context.Background(), map[string]string{"Gitea.Example": "gitea-token"})
+	codeHost, _ := factory.Create(ctx, "github-oauth-token")
+	_ = codeHost.CanAccessRepo(context.Background(), "https://gitea.example/platform/api")
+	_ = codeHost.CanAccessRepo(context.Background(), "https://forgejo.example/platform/api")
+
+	assert.Equal(s.T(), "gitea-token", gitea.token)
+	assert.Equal(s.T(), "", forgejo.token)
+	assert.Equal(s.T(), 1, forgejo.created)
+}
@@ -0,0 +1,223 @@
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/octokerbs/chronocode/internal/adapters/llm"
+	"github.com/octokerbs/chronocode/internal/adapters/retry"
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+)
+
+const DefaultBaseURL = "http://localhost:11434"
+
+type Config struct {
+	// BaseURL of the Ollama server, DefaultBaseURL when empty
+	BaseURL string
+	Model   string
+	// NumCtx overrides the model context window. Ollama silently truncates prompts
+	// beyond it, and its default is too small for most diffs
+	NumCtx     int
+	HTTPClient *http.Client
+	// Retry applies to rate-limited and unavailable responses, the zero value never retries
+	Retry retry.Policy
+	// Prompts renders the analysis prompt, llm.DefaultPrompts when nil
+	Prompts *llm.Prompts
+}
+
+type Agent struct {
+	endpoint   string
+	config     Config
+	httpClient *http.Client
+}
+
+func NewAgent(config Config) (*Agent, error) {
+	if config.BaseURL == "" {
+		config.BaseURL = DefaultBaseURL
+	}
+
+	parsed, err := url.Parse(strings.TrimSuffix(config.BaseURL, "/"))
+	if err != nil || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid Ollama base URL %q", config.BaseURL)
+	}
+
+	if config.Model == "" {
+		return nil, errors.New("missing model")
+	}
+
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		// Local models on modest hardware can take minutes per diff
+		httpClient = &http.Client{Timeout: 15 * time.Minute}
+	}
+
+	httpClient = config.Retry.Wrap(httpClient)
+
+	if config.Prompts == nil {
+		config.Prompts = llm.DefaultPrompts
+	}
+
+	slog.Info("Ollama agent initialized", "base_url", parsed.String(), "model", config.Model)
+	return &Agent{endpoint: parsed.String() + "/api/chat", config: config, httpClient: httpClient}, nil
+}
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type options struct {
+	Temperature float64 `json:"temperature"`
+	NumCtx      int     `json:"num_ctx,omitempty"`
+}
+
+type chatRequest struct {
+	Model    string         `json:"model"`
+	Messages []message      `json:"messages"`
+	Stream   bool           `json:"stream"`
+	Format   map[string]any `json:"format"`
+	Options  options        `json:"options"`
+}
+
+type chatResponse struct {
+	Message    message `json:"message"`
+	Done       bool    `json:"done"`
+	DoneReason string  `json:"done_reason"`
+	// Ollama reports the prompt and response token counts as eval counts
+	PromptEvalCount int64 `json:"prompt_eval_count"`
+	EvalCount       int64 `json:"eval_count"`
+}
+
+func (a *Agent) AnalyzeCommit(ctx context.Context, input agent.AnalysisInput) ([]agent.AnalysisResult, error) {
+	slog.Debug("Ollama agent analyzing diff", "diff_length", len(input.Diff))
+
+	prompt, err := a.config.Prompts.Render(agent.PromptOverridesFrom(ctx), input)
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := a.chat(ctx, prompt.Text+input.Diff, prompt.JSONSchema())
+	if err != nil {
+		slog.Error("Ollama chat failed", "error", err, "diff_length", len(input.Diff))
+		return nil, err
+	}
+
+	results, err := parseAnalysis(text, prompt)
+	if err != nil {
+		slog.Error("Failed to parse Ollama response", "error", err, "response_length", len(text))
+		return nil, err
+	}
+
+	slog.Debug("Ollama analysis completed", "subcommits_produced", len(results), "diff_length", len(input.Diff))
+	return results, nil
+}
+
+func (a *Agent) chat(ctx context.Context, prompt string, schema map[string]any) (string, error) {
+	body, err := json.Marshal(chatRequest{
+		Model:    a.config.Model,
+		Messages: []message{{Role: "user", Content: prompt}},
+		Format:   schema,
+		Options:  options{NumCtx: a.config.NumCtx},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("ollama responded %d: %s", resp.StatusCode, apiErrorMessage(resp.Body))
+	}
+
+	var response chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", fmt.Errorf("decoding ollama response: %w", err)
+	}
+
+	agent.RecordUsage(ctx, agent.Usage{PromptTokens: response.PromptEvalCount, ResponseTokens: response.EvalCount})
+
+	if response.DoneReason == "length" {
+		return "", errors.New("ollama response was truncated by the token limit")
+	}
+
+	if strings.TrimSpace(response.Message.Content) == "" {
+		return "", errors.New("ollama returned no content")
+	}
+
+	slog.Debug("Ollama response received", "response_length", len(response.Message.Content))
+	return response.Message.Content, nil
+}
+
+func apiErrorMessage(body io.Reader) string {
+	raw, _ := io.ReadAll(io.LimitReader(body, 4096))
+
+	var parsed struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(raw, &parsed); err == nil && parsed.Error != "" {
+		return parsed.Error
+	}
+	return strings.TrimSpace(string(raw))
+}
+
+// parseAnalysis
+// Small local models do not always honour the format schema: they wrap the JSON in
+// prose or code fences, answer with the bare subcommit array, or use lowercase or
+// unknown types. Recover what we can instead of failing the whole commit
+func parseAnalysis(text string, prompt llm.Prompt) ([]agent.AnalysisResult, error) {
+	object := extractJSON(text, '{', '}')
+	if array := extractJSON(text, '[', ']'); array != "" && (object == "" || strings.Index(text, array) < strings.Index(text, object)) {
+		object = `{"subcommits":` + array + `}`
+	}
+
+	results, err := prompt.DecodeAnalysis([]byte(object))
+	if err != nil {
+		return nil, err
+	}
+
+	var cleaned []agent.AnalysisResult
+	for _, result := range results {
+		if strings.TrimSpace(result.Title) == "" {
+			continue
+		}
+
+		result.ModificationType = agent.CoerceModificationType(prompt.ModificationTypes, result.ModificationType)
+		cleaned = append(cleaned, result)
+	}
+
+	if len(results) > 0 && len(cleaned) == 0 {
+		return nil, errors.New("ollama response contained no usable subcommits")
+	}
+	return cleaned, nil
+}
+
+// extractJSON
+// Returns the text between the first open and the last close delimiter, or "" when
+// there is none
+func extractJSON(text string, first, last byte) string {
+	start := strings.IndexByte(text, first)
+	end := strings.LastIndexByte(text, last)
+	if start < 0 || end < start {
+		return ""
+	}
+	return text[start : end+1]
+}
@@ -0,0 +1,151 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/octokerbs/chronocode/internal/adapters/llm"
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+const analysisJSON = `{"subcommits":[{"title":"Add main entrypoint","idea":"Bootstrap the binary","description":"Adds an empty main function","epic":"Setup","type":"FEATURE","files":["main.go"]}]}`
+
+var expectedResults = []agent.AnalysisResult{{
+	Title: "Add main entrypoint", Idea: "Bootstrap the binary", Description: "Adds an empty main function",
+	Epic: "Setup", ModificationType: "FEATURE", Files: []string{"main.go"}, PromptVersion: llm.DefaultPrompts.Version(),
+}}
+
+type OllamaAgentTestSuite struct {
+	suite.Suite
+	server  *httptest.Server
+	paths   []string
+	bodies  []chatRequest
+	respond func(w http.ResponseWriter)
+}
+
+func TestOllamaAgentTestSuite(t *testing.T) {
+	suite.Run(t, new(OllamaAgentTestSuite))
+}
+
+func (s *OllamaAgentTestSuite) SetupTest() {
+	s.paths = nil
+	s.bodies = nil
+	s.respondWith(analysisJSON)
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body chatRequest
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		s.paths = append(s.paths, r.URL.Path)
+		s.bodies = append(s.bodies, body)
+		s.respond(w)
+	}))
+	s.T().Cleanup(s.server.Close)
+}
+
+func (s *OllamaAgentTestSuite) respondWith(content string) {
+	s.respond = func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"model":       "llama3.1",
+			"message":     map[string]any{"role": "assistant", "content": content},
+			"done":        true,
+			"done_reason": "stop",
+		})
+	}
+}
+
+func (s *OllamaAgentTestSuite) analyze(diff string) ([]agent.AnalysisResult, error) {
+	a, err := NewAgent(Config{BaseURL: s.server.URL, Model: "llama3.1", NumCtx: 32768, HTTPClient: s.server.Client()})
+	require.NoError(s.T(), err)
+	return a.AnalyzeCommit(context.Background(), agent.AnalysisInput{Diff: diff})
+}
+
+func (s *OllamaAgentTestSuite) TestRequiresModel() {
+	_, err := NewAgent(Config{})
+	assert.NotNil(s.T(), err)
+}
+
+func (s *OllamaAgentTestSuite) TestDefaultsToLocalServer() {
+	a, err := NewAgent(Config{Model: "llama3.1"})
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), DefaultBaseURL+"/api/chat", a.endpoint)
+}
+
+func (s *OllamaAgentTestSuite) TestSendsNonStreamingChatWithFormatSchema() {
+	_, err := s.analyze("+func main() {}")
+
+	assert.Nil(s.T(), err)
+	require.Len(s.T(), s.bodies, 1)
+	assert.Equal(s.T(), "/api/chat", s.paths[0])
+
+	body := s.bodies[0]
+	assert.Equal(s.T(), "llama3.1", body.Model)
+	assert.False(s.T(), body.Stream)
+	assert.Equal(s.T(), 32768, body.Options.NumCtx)
+	assert.Contains(s.T(), body.Messages[0].Content, "+func main() {}")
+	assert.Contains(s.T(), body.Format["properties"], "subcommits")
+}
+
+func (s *OllamaAgentTestSuite) TestAnalyzeCommitReturnsSubcommits() {
+	results, err := s.analyze("diff")
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), expectedResults, results)
+}
+
+func (s *OllamaAgentTestSuite) TestRecoversJSONWrappedInProse() {
+	s.respondWith("Here is the analysis:\n```json\n" + analysisJSON + "\n```\nLet me know if you need more.")
+	results, err := s.analyze("diff")
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), expectedResults, results)
+}
+
+func (s *OllamaAgentTestSuite) TestRecoversBareSubcommitArray() {
+	s.respondWith(`[{"title":"Add main entrypoint","idea":"Bootstrap the binary","description":"Adds an empty main function","epic":"Setup","type":"FEATURE","files":["main.go"]}]`)
+	results, err := s.analyze("diff")
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), expectedResults, results)
+}
+
+func (s *OllamaAgentTestSuite) TestNormalizesTypesAndDropsUntitledSubcommits() {
+	s.respondWith(`{"subcommits":[{"title":"Fix nil check","type":" bug "},{"title":"Tidy imports","type":"cleanup"},{"title":"","type":"FEATURE"}]}`)
+	results, err := s.analyze("diff")
+
+	assert.Nil(s.T(), err)
+	require.Len(s.T(), results, 2)
+	assert.Equal(s.T(), "BUG", results[0].ModificationType)
+	assert.Equal(s.T(), "CHORE", results[1].ModificationType)
+}
+
+func (s *OllamaAgentTestSuite) TestNoSubcommitsIsNotAnError() {
+	s.respondWith(`{"subcommits":[]}`)
+	results, err := s.analyze("diff")
+
+	assert.Nil(s.T(), err)
+	assert.Empty(s.T(), results)
+}
+
+func (s *OllamaAgentTestSuite) TestUnparseableContentIsAnError() {
+	s.respondWith("I cannot analyze this diff.")
+	_, err := s.analyze("diff")
+
+	assert.NotNil(s.T(), err)
+}
+
+func (s *OllamaAgentTestSuite) TestServerErrorIsReturnedWithMessage() {
+	s.respond = func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"model \"llama3.1\" not found, try pulling it first"}`))
+	}
+	_, err := s.analyze("diff")
+
+	assert.ErrorContains(s.T(), err, "try pulling it first")
+}
@@ -0,0 +1,212 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/octokerbs/chronocode/internal/adapters/llm"
+	"github.com/octokerbs/chronocode/internal/adapters/retry"
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+)
+
+type Config struct {
+	// BaseURL of the API, e.g. http://vllm:8000/v1. For Azure OpenAI, the deployment
+	// URL: https://<resource>.openai.azure.com/openai/deployments/<deployment>
+	BaseURL string
+	// APIKey is optional, most self-hosted servers do not check it
+	APIKey string
+	Model  string
+	// APIVersion switches to Azure OpenAI, which authenticates with an api-key header
+	// and versions its API through a query parameter
+	APIVersion string
+	HTTPClient *http.Client
+	// Retry applies to rate-limited and unavailable responses, the zero value never retries
+	Retry retry.Policy
+	// Prompts renders the analysis prompt, llm.DefaultPrompts when nil
+	Prompts *llm.Prompts
+}
+
+type Agent struct {
+	endpoint   string
+	config     Config
+	httpClient *http.Client
+}
+
+func NewAgent(config Config) (*Agent, error) {
+	parsed, err := url.Parse(strings.TrimSuffix(config.BaseURL, "/"))
+	if err != nil || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid OpenAI-compatible base URL %q", config.BaseURL)
+	}
+
+	if config.Model == "" && config.APIVersion == "" {
+		return nil, errors.New("missing model")
+	}
+
+	endpoint := parsed.String() + "/chat/completions"
+	if config.APIVersion != "" {
+		endpoint += "?" + url.Values{"api-version": {config.APIVersion}}.Encode()
+	}
+
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 5 * time.Minute}
+	}
+
+	httpClient = config.Retry.Wrap(httpClient)
+
+	if config.Prompts == nil {
+		config.Prompts = llm.DefaultPrompts
+	}
+
+	slog.Info("OpenAI-compatible agent initialized", "base_url", parsed.String(), "model", config.Model, "azure", config.APIVersion != "")
+	return &Agent{endpoint: endpoint, config: config, httpClient: httpClient}, nil
+}
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type jsonSchemaFormat struct {
+	Name   string         `json:"name"`
+	Strict bool           `json:"strict"`
+	Schema map[string]any `json:"schema"`
+}
+
+type responseFormat struct {
+	Type       string           `json:"type"`
+	JSONSchema jsonSchemaFormat `json:"json_schema"`
+}
+
+type chatRequest struct {
+	Model          string         `json:"model,omitempty"`
+	Messages       []message      `json:"messages"`
+	ResponseFormat responseFormat `json:"response_format"`
+	Temperature    float64        `json:"temperature"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+			Refusal string `json:"refusal"`
+		} `json:"message"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int64 `json:"prompt_tokens"`
+		CompletionTokens int64 `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+type errorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (a *Agent) AnalyzeCommit(ctx context.Context, input agent.AnalysisInput) ([]agent.AnalysisResult, error) {
+	slog.Debug("OpenAI-compatible agent analyzing diff", "diff_length", len(input.Diff))
+
+	prompt, err := a.config.Prompts.Render(agent.PromptOverridesFrom(ctx), input)
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := a.complete(ctx, prompt.Text+input.Diff, prompt.JSONSchema())
+	if err != nil {
+		slog.Error("OpenAI-compatible chat completion failed", "error", err, "diff_length", len(input.Diff))
+		return nil, err
+	}
+
+	results, err := prompt.ParseAnalysis([]byte(text))
+	if err != nil {
+		slog.Error("Failed to unmarshal OpenAI-compatible response", "error", err, "response_length", len(text))
+		return nil, err
+	}
+
+	slog.Debug("OpenAI-compatible analysis completed", "subcommits_produced", len(results), "diff_length", len(input.Diff))
+	return results, nil
+}
+
+func (a *Agent) complete(ctx context.Context, prompt string, schema map[string]any) (string, error) {
+	body, err := json.Marshal(chatRequest{
+		Model:    a.config.Model,
+		Messages: []message{{Role: "user", Content: prompt}},
+		ResponseFormat: responseFormat{
+			Type:       "json_schema",
+			JSONSchema: jsonSchemaFormat{Name: "commit_analysis", Strict: true, Schema: schema},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	switch {
+	case a.config.APIKey == "":
+	case a.config.APIVersion != "":
+		req.Header.Set("api-key", a.config.APIKey)
+	default:
+		req.Header.Set("Authorization", "Bearer "+a.config.APIKey)
+	}
+
+	slog.Debug("Sending request to OpenAI-compatible API", "prompt_length", len(prompt))
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("chat completion responded %d: %s", resp.StatusCode, apiErrorMessage(resp.Body))
+	}
+
+	var completion chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&completion); err != nil {
+		return "", fmt.Errorf("decoding chat completion: %w", err)
+	}
+
+	agent.RecordUsage(ctx, agent.Usage{PromptTokens: completion.Usage.PromptTokens, ResponseTokens: completion.Usage.CompletionTokens})
+
+	if len(completion.Choices) == 0 {
+		return "", errors.New("chat completion returned no choices")
+	}
+
+	choice := completion.Choices[0]
+	switch {
+	case choice.Message.Refusal != "":
+		return "", fmt.Errorf("model refused the analysis: %s", choice.Message.Refusal)
+	case choice.FinishReason == "length":
+		return "", errors.New("chat completion was truncated by the token limit")
+	case choice.Message.Content == "":
+		return "", errors.New("chat completion returned no content")
+	}
+
+	slog.Debug("OpenAI-compatible API response received", "response_length", len(choice.Message.Content))
+	return choice.Message.Content, nil
+}
+
+func apiErrorMessage(body io.Reader) string {
+	raw, _ := io.ReadAll(io.LimitReader(body, 4096))
+
+	var parsed errorResponse
+	if err := json.Unmarshal(raw, &parsed); err == nil && parsed.Error.Message != "" {
+		return parsed.Error.Message
+	}
+	return strings.TrimSpace(string(raw))
+}
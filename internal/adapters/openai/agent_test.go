@@ -0,0 +1,186 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/octokerbs/chronocode/internal/adapters/llm"
+	"github.com/octokerbs/chronocode/internal/adapters/retry"
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+const analysisJSON = `{"subcommits":[{"title":"Add main entrypoint","idea":"Bootstrap the binary","description":"Adds an empty main function","epic":"Setup","type":"FEATURE","files":["main.go"]}]}`
+
+type OpenAIAgentTestSuite struct {
+	suite.Suite
+	server   *httptest.Server
+	requests []*http.Request
+	bodies   []chatRequest
+	respond  func(w http.ResponseWriter)
+}
+
+func TestOpenAIAgentTestSuite(t *testing.T) {
+	suite.Run(t, new(OpenAIAgentTestSuite))
+}
+
+func (s *OpenAIAgentTestSuite) SetupTest() {
+	s.requests = nil
+	s.bodies = nil
+	s.respond = func(w http.ResponseWriter) {
+		writeCompletion(w, analysisJSON, "stop")
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body chatRequest
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		s.requests = append(s.requests, r)
+		s.bodies = append(s.bodies, body)
+		s.respond(w)
+	}))
+	s.T().Cleanup(s.server.Close)
+}
+
+func writeCompletion(w http.ResponseWriter, content, finishReason string) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"choices": []map[string]any{{"message": map[string]any{"role": "assistant", "content": content}, "finish_reason": finishReason}},
+	})
+}
+
+func (s *OpenAIAgentTestSuite) newAgent(config Config) *Agent {
+	if config.BaseURL == "" {
+		config.BaseURL = s.server.URL + "/v1"
+	}
+	config.HTTPClient = s.server.Client()
+
+	a, err := NewAgent(config)
+	require.NoError(s.T(), err)
+	return a
+}
+
+func (s *OpenAIAgentTestSuite) TestRequiresBaseURLAndModel() {
+	_, err := NewAgent(Config{Model: "qwen"})
+	assert.NotNil(s.T(), err)
+
+	_, err = NewAgent(Config{BaseURL: "http://vllm:8000/v1"})
+	assert.NotNil(s.T(), err)
+}
+
+func (s *OpenAIAgentTestSuite) TestAnalyzeCommitReturnsSubcommits() {
+	results, err := s.newAgent(Config{Model: "qwen"}).AnalyzeCommit(context.Background(), agent.AnalysisInput{Diff: "+func main() {}"})
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), []agent.AnalysisResult{{
+		Title: "Add main entrypoint", Idea: "Bootstrap the binary", Description: "Adds an empty main function",
+		Epic: "Setup", ModificationType: "FEATURE", Files: []string{"main.go"}, PromptVersion: llm.DefaultPrompts.Version(),
+	}}, results)
+}
+
+func (s *OpenAIAgentTestSuite) TestRequestsStrictJSONSchemaOutput() {
+	_, _ = s.newAgent(Config{Model: "qwen", APIKey: "secret"}).AnalyzeCommit(context.Background(), agent.AnalysisInput{Diff: "+func main() {}"})
+
+	require.Len(s.T(), s.requests, 1)
+	assert.Equal(s.T(), "/v1/chat/completions", s.requests[0].URL.Path)
+	assert.Equal(s.T(), "Bearer secret", s.requests[0].Header.Get("Authorization"))
+
+	body := s.bodies[0]
+	assert.Equal(s.T(), "qwen", body.Model)
+	assert.Contains(s.T(), body.Messages[0].Content, "+func main() {}")
+	assert.Equal(s.T(), "json_schema", body.ResponseFormat.Type)
+	assert.True(s.T(), body.ResponseFormat.JSONSchema.Strict)
+	assert.Contains(s.T(), body.ResponseFormat.JSONSchema.Schema["properties"], "subcommits")
+}
+
+func (s *OpenAIAgentTestSuite) TestRepositoryPromptOverridesReachTheModel() {
+	ctx := agent.WithPromptOverrides(context.Background(), agent.PromptOverrides{Glossary: "Ledger: the accounting service", TitleStyle: "Imperative mood"})
+	results, err := s.newAgent(Config{Model: "qwen"}).AnalyzeCommit(ctx, agent.AnalysisInput{Diff: "+func main() {}"})
+
+	require.Nil(s.T(), err)
+	assert.Contains(s.T(), s.bodies[0].Messages[0].Content, "Ledger: the accounting service")
+	assert.Contains(s.T(), s.bodies[0].Messages[0].Content, "Imperative mood")
+	assert.NotEqual(s.T(), llm.DefaultPrompts.Version(), results[0].PromptVersion)
+}
+
+func (s *OpenAIAgentTestSuite) TestOmitsAuthorizationWithoutAPIKey() {
+	_, _ = s.newAgent(Config{Model: "qwen"}).AnalyzeCommit(context.Background(), agent.AnalysisInput{Diff: "diff"})
+
+	assert.Empty(s.T(), s.requests[0].Header.Get("Authorization"))
+}
+
+func (s *OpenAIAgentTestSuite) TestAzureUsesAPIKeyHeaderAndVersion() {
+	a := s.newAgent(Config{BaseURL: s.server.URL + "/openai/deployments/gpt-4o", APIKey: "azure-key", APIVersion: "2024-08-01-preview"})
+	_, err := a.AnalyzeCommit(context.Background(), agent.AnalysisInput{Diff: "diff"})
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), "/openai/deployments/gpt-4o/chat/completions", s.requests[0].URL.Path)
+	assert.Equal(s.T(), "2024-08-01-preview", s.requests[0].URL.Query().Get("api-version"))
+	assert.Equal(s.T(), "azure-key", s.requests[0].Header.Get("api-key"))
+	assert.Empty(s.T(), s.requests[0].Header.Get("Authorization"))
+}
+
+func (s *OpenAIAgentTestSuite) TestServerErrorIsReturnedWithMessage() {
+	s.respond = func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":{"message":"model not loaded"}}`))
+	}
+	_, err := s.newAgent(Config{Model: "qwen"}).AnalyzeCommit(context.Background(), agent.AnalysisInput{Diff: "diff"})
+
+	assert.ErrorContains(s.T(), err, "model not loaded")
+}
+
+func (s *OpenAIAgentTestSuite) TestTruncatedCompletionIsAnError() {
+	s.respond = func(w http.ResponseWriter) {
+		writeCompletion(w, `{"subcommits":[{"title":"Add`, "length")
+	}
+	_, err := s.newAgent(Config{Model: "qwen"}).AnalyzeCommit(context.Background(), agent.AnalysisInput{Diff: "diff"})
+
+	assert.ErrorContains(s.T(), err, "truncated")
+}
+
+func (s *OpenAIAgentTestSuite) TestMalformedContentIsAnError() {
+	s.respond = func(w http.ResponseWriter) {
+		writeCompletion(w, "Sure! Here are the subcommits", "stop")
+	}
+	_, err := s.newAgent(Config{Model: "qwen"}).AnalyzeCommit(context.Background(), agent.AnalysisInput{Diff: "diff"})
+
+	assert.NotNil(s.T(), err)
+}
+
+func (s *OpenAIAgentTestSuite) TestRetriesRateLimitedRequests() {
+	s.respond = func(w http.ResponseWriter) {
+		if len(s.requests) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		writeCompletion(w, analysisJSON, "stop")
+	}
+	a := s.newAgent(Config{Model: "qwen", Retry: retry.Policy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}})
+	results, err := a.AnalyzeCommit(context.Background(), agent.AnalysisInput{Diff: "+func main() {}"})
+
+	assert.Nil(s.T(), err)
+	assert.Len(s.T(), results, 1)
+	require.Len(s.T(), s.bodies, 2)
+	assert.Contains(s.T(), s.bodies[1].Messages[0].Content, "+func main() {}")
+}
+
+func (s *OpenAIAgentTestSuite) TestReportsTokenUsage() {
+	s.respond = func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]any{"role": "assistant", "content": analysisJSON}, "finish_reason": "stop"}},
+			"usage":   map[string]any{"prompt_tokens": 1200, "completion_tokens": 85, "total_tokens": 1285},
+		})
+	}
+	ctx, meter := agent.WithUsageMeter(context.Background())
+	_, err := s.newAgent(Config{Model: "qwen"}).AnalyzeCommit(ctx, agent.AnalysisInput{Diff: "diff"})
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), agent.Usage{PromptTokens: 1200, ResponseTokens: 85}, meter.Usage())
+}
@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+)
+
+type AnalysisCache struct {
+	db *sql.DB
+}
+
+func NewAnalysisCache(db *sql.DB) (*AnalysisCache, error) {
+	if db == nil {
+		return nil, errors.New("missing postgres client")
+	}
+
+	return &AnalysisCache{db: db}, nil
+}
+
+type cachedResult struct {
+	Title            string   `json:"title"`
+	Idea             string   `json:"idea"`
+	Description      string   `json:"description"`
+	Epic             string   `json:"epic"`
+	ModificationType string   `json:"type"`
+	Files            []string `json:"files"`
+	Agent            string   `json:"agent,omitempty"`
+}
+
+func (c *AnalysisCache) GetAnalysis(ctx context.Context, key string) ([]agent.AnalysisResult, bool, error) {
+	const query = `SELECT results FROM analysis_cache WHERE key = $1`
+
+	var raw []byte
+	err := c.db.QueryRowContext(ctx, query, key).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		slog.Error("Database error querying analysis cache", "key", key, "error", err)
+		return nil, false, err
+	}
+
+	var cached []cachedResult
+	if err := json.Unmarshal(raw, &cached); err != nil {
+		slog.Error("Failed to decode cached analysis", "key", key, "error", err)
+		return nil, false, err
+	}
+
+	results := make([]agent.AnalysisResult, len(cached))
+	for i, result := range cached {
+		results[i] = agent.AnalysisResult{
+			Title:            result.Title,
+			Idea:             result.Idea,
+			Description:      result.Description,
+			Epic:             result.Epic,
+			ModificationType: result.ModificationType,
+			Files:            result.Files,
+			Agent:            result.Agent,
+		}
+	}
+	return results, true, nil
+}
+
+func (c *AnalysisCache) StoreAnalysis(ctx context.Context, key string, results []agent.AnalysisResult) error {
+	const query = `INSERT INTO analysis_cache (key, results) VALUES ($1, $2) ON CONFLICT (key) DO NOTHING`
+
+	cached := make([]cachedResult, len(results))
+	for i, result := range results {
+		cached[i] = cachedResult{
+			Title:            result.Title,
+			Idea:             result.Idea,
+			Description:      result.Description,
+			Epic:             result.Epic,
+			ModificationType: result.ModificationType,
+			Files:            result.Files,
+			Agent:            result.Agent,
+		}
+	}
+
+	raw, err := json.Marshal(cached)
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.db.ExecContext(ctx, query, key, raw); err != nil {
+		slog.Error("Database error storing analysis cache entry", "key", key, "error", err)
+		return err
+	}
+
+	slog.Debug("Analysis cached", "key", key, "subcommits", len(results))
+	return nil
+}
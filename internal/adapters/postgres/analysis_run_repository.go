@@ -0,0 +1,142 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+	"github.com/octokerbs/chronocode/internal/domain/analysis"
+)
+
+const analysisRunColumns = `id, repo_id, status, head_sha, commits_total, commits_analyzed, commits_skipped, commits_failed, commits_cached, prompt_tokens, response_tokens, cost_usd, error, started_at, finished_at`
+
+type AnalysisRunRepository struct {
+	db *sql.DB
+}
+
+func NewAnalysisRunRepository(db *sql.DB) (*AnalysisRunRepository, error) {
+	if db == nil {
+		return nil, errors.New("missing postgres client")
+	}
+
+	return &AnalysisRunRepository{db: db}, nil
+}
+
+func (r *AnalysisRunRepository) StoreRun(ctx context.Context, run *analysis.Run) error {
+	counts := run.Counts()
+	usage := run.Usage()
+	finishedAt := sql.NullTime{Time: run.FinishedAt(), Valid: !run.FinishedAt().IsZero()}
+
+	if run.ID() == 0 {
+		const query = `
+			INSERT INTO analysis_run (repo_id, status, head_sha, commits_total, commits_analyzed, commits_skipped, commits_failed, commits_cached, prompt_tokens, response_tokens, cost_usd, error, started_at, finished_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+			RETURNING id`
+
+		var id int64
+		err := r.db.QueryRowContext(ctx, query,
+			run.RepoID(), string(run.Status()), run.HeadSHA(), counts.Total, counts.Analyzed, counts.Skipped, counts.Failed, counts.Cached,
+			usage.PromptTokens, usage.ResponseTokens, usage.CostUSD, run.Error(), run.StartedAt(), finishedAt).Scan(&id)
+		if err != nil {
+			slog.Error("Database error inserting analysis run", "repo_id", run.RepoID(), "error", err)
+			return err
+		}
+
+		run.SetID(id)
+		slog.Debug("Analysis run inserted", "run_id", id, "repo_id", run.RepoID())
+		return nil
+	}
+
+	const query = `
+		UPDATE analysis_run SET
+			status = $2,
+			head_sha = $3,
+			commits_total = $4,
+			commits_analyzed = $5,
+			commits_skipped = $6,
+			commits_failed = $7,
+			commits_cached = $8,
+			prompt_tokens = $9,
+			response_tokens = $10,
+			cost_usd = $11,
+			error = $12,
+			finished_at = $13
+		WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query,
+		run.ID(), string(run.Status()), run.HeadSHA(), counts.Total, counts.Analyzed, counts.Skipped, counts.Failed, counts.Cached,
+		usage.PromptTokens, usage.ResponseTokens, usage.CostUSD, run.Error(), finishedAt)
+	if err != nil {
+		slog.Error("Database error updating analysis run", "run_id", run.ID(), "repo_id", run.RepoID(), "error", err)
+		return err
+	}
+
+	slog.Debug("Analysis run updated", "run_id", run.ID(), "status", run.Status())
+	return nil
+}
+
+func (r *AnalysisRunRepository) GetRun(ctx context.Context, id int64) (*analysis.Run, error) {
+	query := `SELECT ` + analysisRunColumns + ` FROM analysis_run WHERE id = $1`
+
+	slog.Debug("Querying analysis run by ID", "run_id", id)
+
+	run, err := scanAnalysisRun(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			slog.Debug("Analysis run not found", "run_id", id)
+			return nil, analysis.ErrRunNotFound
+		}
+		slog.Error("Database error querying analysis run", "run_id", id, "error", err)
+		return nil, err
+	}
+
+	return run, nil
+}
+
+func (r *AnalysisRunRepository) ListRuns(ctx context.Context, repoID int64) ([]*analysis.Run, error) {
+	query := `SELECT ` + analysisRunColumns + ` FROM analysis_run WHERE repo_id = $1 ORDER BY started_at DESC, id DESC`
+
+	slog.Debug("Listing analysis runs", "repo_id", repoID)
+
+	rows, err := r.db.QueryContext(ctx, query, repoID)
+	if err != nil {
+		slog.Error("Database error listing analysis runs", "repo_id", repoID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []*analysis.Run
+	for rows.Next() {
+		run, err := scanAnalysisRun(rows)
+		if err != nil {
+			slog.Error("Database error scanning analysis run row", "repo_id", repoID, "error", err)
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+
+	slog.Debug("Analysis runs listed", "repo_id", repoID, "count", len(runs))
+	return runs, rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAnalysisRun(row rowScanner) (*analysis.Run, error) {
+	var id, repoID int64
+	var status, headSHA, runErr string
+	var counts analysis.CommitCounts
+	var usage agent.Usage
+	var startedAt time.Time
+	var finishedAt sql.NullTime
+
+	if err := row.Scan(&id, &repoID, &status, &headSHA, &counts.Total, &counts.Analyzed, &counts.Skipped, &counts.Failed, &counts.Cached, &usage.PromptTokens, &usage.ResponseTokens, &usage.CostUSD, &runErr, &startedAt, &finishedAt); err != nil {
+		return nil, err
+	}
+
+	return analysis.NewRunFromDB(id, repoID, analysis.RunStatus(status), headSHA, counts, usage, runErr, startedAt, finishedAt.Time), nil
+}
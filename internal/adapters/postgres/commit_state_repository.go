@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+	"github.com/octokerbs/chronocode/internal/domain/analysis"
+)
+
+type CommitStateRepository struct {
+	db *sql.DB
+}
+
+func NewCommitStateRepository(db *sql.DB) (*CommitStateRepository, error) {
+	if db == nil {
+		return nil, errors.New("missing postgres client")
+	}
+
+	return &CommitStateRepository{db: db}, nil
+}
+
+func (r *CommitStateRepository) ListCommitStates(ctx context.Context, repoID int64, statuses ...analysis.CommitStatus) ([]*analysis.CommitState, error) {
+	const query = `
+		SELECT repo_id, commit_sha, committed_at, status, attempts, last_error, updated_at, prompt_tokens, response_tokens, cost_usd
+		FROM commit_analysis
+		WHERE repo_id = $1 AND (cardinality($2::text[]) = 0 OR status = ANY($2))
+		ORDER BY committed_at DESC`
+
+	filter := make([]string, len(statuses))
+	for i, status := range statuses {
+		filter[i] = string(status)
+	}
+
+	slog.Debug("Querying commit analysis states", "repo_id", repoID, "statuses", filter)
+
+	rows, err := r.db.QueryContext(ctx, query, repoID, pq.Array(filter))
+	if err != nil {
+		slog.Error("Database error querying commit analysis states", "repo_id", repoID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var states []*analysis.CommitState
+	for rows.Next() {
+		var rID int64
+		var sha, status, lastError string
+		var attempts int
+		var committedAt, updatedAt time.Time
+		var usage agent.Usage
+		if err := rows.Scan(&rID, &sha, &committedAt, &status, &attempts, &lastError, &updatedAt, &usage.PromptTokens, &usage.ResponseTokens, &usage.CostUSD); err != nil {
+			slog.Error("Database error scanning commit analysis state row", "repo_id", repoID, "error", err)
+			return nil, err
+		}
+		states = append(states, analysis.NewCommitStateFromDB(rID, sha, committedAt, analysis.CommitStatus(status), attempts, lastError, updatedAt, usage))
+	}
+
+	slog.Debug("Commit analysis states fetched", "repo_id", repoID, "count", len(states))
+	return states, rows.Err()
+}
+
+func (r *CommitStateRepository) StoreCommitState(ctx context.Context, state *analysis.CommitState) error {
+	const query = `
+		INSERT INTO commit_analysis (repo_id, commit_sha, committed_at, status, attempts, last_error, updated_at, prompt_tokens, response_tokens, cost_usd)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (repo_id, commit_sha) DO UPDATE SET
+			status = EXCLUDED.status,
+			attempts = EXCLUDED.attempts,
+			last_error = EXCLUDED.last_error,
+			updated_at = EXCLUDED.updated_at,
+			prompt_tokens = EXCLUDED.prompt_tokens,
+			response_tokens = EXCLUDED.response_tokens,
+			cost_usd = EXCLUDED.cost_usd`
+
+	usage := state.Usage()
+	_, err := r.db.ExecContext(ctx, query,
+		state.RepoID(), state.CommitSHA(), state.CommittedAt(), string(state.Status()), state.Attempts(), state.LastError(), state.UpdatedAt(),
+		usage.PromptTokens, usage.ResponseTokens, usage.CostUSD)
+	if err != nil {
+		slog.Error("Database error storing commit analysis state", "repo_id", state.RepoID(), "commit_sha", state.CommitSHA(), "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *CommitStateRepository) RepoUsage(ctx context.Context, repoID int64) (agent.Usage, error) {
+	const query = `
+		SELECT COALESCE(SUM(prompt_tokens), 0), COALESCE(SUM(response_tokens), 0), COALESCE(SUM(cost_usd), 0)
+		FROM commit_analysis
+		WHERE repo_id = $1`
+
+	var usage agent.Usage
+	if err := r.db.QueryRowContext(ctx, query, repoID).Scan(&usage.PromptTokens, &usage.ResponseTokens, &usage.CostUSD); err != nil {
+		slog.Error("Database error summing repository usage", "repo_id", repoID, "error", err)
+		return agent.Usage{}, err
+	}
+	return usage, nil
+}
+
+func (r *CommitStateRepository) TotalUsage(ctx context.Context) (agent.Usage, error) {
+	const query = `
+		SELECT COALESCE(SUM(prompt_tokens), 0), COALESCE(SUM(response_tokens), 0), COALESCE(SUM(cost_usd), 0)
+		FROM commit_analysis`
+
+	var usage agent.Usage
+	if err := r.db.QueryRowContext(ctx, query).Scan(&usage.PromptTokens, &usage.ResponseTokens, &usage.CostUSD); err != nil {
+		slog.Error("Database error summing total usage", "error", err)
+		return agent.Usage{}, err
+	}
+	return usage, nil
+}
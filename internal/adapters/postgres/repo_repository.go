@@ -3,13 +3,19 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"log/slog"
 	"time"
 
+	"github.com/lib/pq"
+	"github.com/octokerbs/chronocode/internal/domain/agent"
 	"github.com/octokerbs/chronocode/internal/domain/repo"
 )
 
+const repoColumns = `id, name, url, last_analyzed_commit_sha, created_at, ignore_patterns, budget_tokens, budget_cost_usd,
+	prompt_glossary, prompt_title_style, prompt_instructions, modification_types, issue_trackers`
+
 type RepoRepository struct {
 	db *sql.DB
 }
@@ -23,14 +29,11 @@ func NewRepoRepository(db *sql.DB) (*RepoRepository, error) {
 }
 
 func (r *RepoRepository) GetRepo(ctx context.Context, url string) (*repo.Repo, error) {
-	const query = `SELECT id, name, url, last_analyzed_commit_sha, created_at FROM repository WHERE url = $1`
+	query := `SELECT ` + repoColumns + ` FROM repository WHERE url = $1`
 
 	slog.Debug("Querying repository by URL", "url", url)
 
-	var id int64
-	var name, repoURL, lastSHA string
-	var createdAt time.Time
-	err := r.db.QueryRowContext(ctx, query, url).Scan(&id, &name, &repoURL, &lastSHA, &createdAt)
+	foundRepo, err := scanRepo(r.db.QueryRowContext(ctx, query, url))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			slog.Debug("Repository not found by URL", "url", url)
@@ -40,19 +43,16 @@ func (r *RepoRepository) GetRepo(ctx context.Context, url string) (*repo.Repo, e
 		return nil, err
 	}
 
-	slog.Debug("Repository found by URL", "repo_id", id, "name", name)
-	return repo.NewRepo(id, name, repoURL, lastSHA, createdAt), nil
+	slog.Debug("Repository found by URL", "repo_id", foundRepo.ID(), "name", foundRepo.Name())
+	return foundRepo, nil
 }
 
 func (r *RepoRepository) GetRepoByID(ctx context.Context, id int64) (*repo.Repo, error) {
-	const query = `SELECT id, name, url, last_analyzed_commit_sha, created_at FROM repository WHERE id = $1`
+	query := `SELECT ` + repoColumns + ` FROM repository WHERE id = $1`
 
 	slog.Debug("Querying repository by ID", "repo_id", id)
 
-	var repoID int64
-	var name, repoURL, lastSHA string
-	var createdAt time.Time
-	err := r.db.QueryRowContext(ctx, query, id).Scan(&repoID, &name, &repoURL, &lastSHA, &createdAt)
+	foundRepo, err := scanRepo(r.db.QueryRowContext(ctx, query, id))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			slog.Debug("Repository not found by ID", "repo_id", id)
@@ -62,12 +62,12 @@ func (r *RepoRepository) GetRepoByID(ctx context.Context, id int64) (*repo.Repo,
 		return nil, err
 	}
 
-	slog.Debug("Repository found by ID", "repo_id", repoID, "name", name)
-	return repo.NewRepo(repoID, name, repoURL, lastSHA, createdAt), nil
+	slog.Debug("Repository found by ID", "repo_id", foundRepo.ID(), "name", foundRepo.Name())
+	return foundRepo, nil
 }
 
 func (r *RepoRepository) ListRepos(ctx context.Context) ([]*repo.Repo, error) {
-	const query = `SELECT id, name, url, last_analyzed_commit_sha, created_at FROM repository ORDER BY created_at DESC`
+	query := `SELECT ` + repoColumns + ` FROM repository ORDER BY created_at DESC`
 
 	slog.Debug("Listing all repositories from database")
 
@@ -80,14 +80,12 @@ func (r *RepoRepository) ListRepos(ctx context.Context) ([]*repo.Repo, error) {
 
 	var repos []*repo.Repo
 	for rows.Next() {
-		var id int64
-		var name, repoURL, lastSHA string
-		var createdAt time.Time
-		if err := rows.Scan(&id, &name, &repoURL, &lastSHA, &createdAt); err != nil {
+		foundRepo, err := scanRepo(rows)
+		if err != nil {
 			slog.Error("Database error scanning repository row", "error", err)
 			return nil, err
 		}
-		repos = append(repos, repo.NewRepo(id, name, repoURL, lastSHA, createdAt))
+		repos = append(repos, foundRepo)
 	}
 
 	slog.Debug("Repositories listed from database", "count", len(repos))
@@ -114,3 +112,192 @@ func (r *RepoRepository) StoreRepo(ctx context.Context, repo *repo.Repo) error {
 	slog.Info("Repository stored", "repo_id", repo.ID(), "name", repo.Name())
 	return nil
 }
+
+func (r *RepoRepository) UpdateIgnorePatterns(ctx context.Context, id int64, patterns []string) error {
+	const query = `UPDATE repository SET ignore_patterns = $2 WHERE id = $1`
+
+	slog.Debug("Updating repository ignore patterns", "repo_id", id, "patterns", len(patterns), "defaults", patterns == nil)
+
+	result, err := r.db.ExecContext(ctx, query, id, pq.StringArray(patterns))
+	if err != nil {
+		slog.Error("Database error updating repository ignore patterns", "repo_id", id, "error", err)
+		return err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return repo.ErrRepositoryNotFound
+	}
+
+	slog.Info("Repository ignore patterns updated", "repo_id", id, "defaults", patterns == nil)
+	return nil
+}
+
+func (r *RepoRepository) UpdateBudget(ctx context.Context, id int64, budget agent.Budget) error {
+	const query = `UPDATE repository SET budget_tokens = $2, budget_cost_usd = $3 WHERE id = $1`
+
+	slog.Debug("Updating repository budget", "repo_id", id, "max_tokens", budget.MaxTokens, "max_cost_usd", budget.MaxCostUSD)
+
+	result, err := r.db.ExecContext(ctx, query, id, budget.MaxTokens, budget.MaxCostUSD)
+	if err != nil {
+		slog.Error("Database error updating repository budget", "repo_id", id, "error", err)
+		return err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return repo.ErrRepositoryNotFound
+	}
+
+	slog.Info("Repository budget updated", "repo_id", id, "max_tokens", budget.MaxTokens, "max_cost_usd", budget.MaxCostUSD)
+	return nil
+}
+
+func (r *RepoRepository) UpdatePromptOverrides(ctx context.Context, id int64, overrides agent.PromptOverrides) error {
+	const query = `UPDATE repository SET prompt_glossary = $2, prompt_title_style = $3, prompt_instructions = $4 WHERE id = $1`
+
+	slog.Debug("Updating repository prompt overrides", "repo_id", id, "fingerprint", overrides.Fingerprint())
+
+	result, err := r.db.ExecContext(ctx, query, id, overrides.Glossary, overrides.TitleStyle, overrides.Instructions)
+	if err != nil {
+		slog.Error("Database error updating repository prompt overrides", "repo_id", id, "error", err)
+		return err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return repo.ErrRepositoryNotFound
+	}
+
+	slog.Info("Repository prompt overrides updated", "repo_id", id, "fingerprint", overrides.Fingerprint())
+	return nil
+}
+
+func (r *RepoRepository) UpdateModificationTypes(ctx context.Context, id int64, types []string) error {
+	const query = `UPDATE repository SET modification_types = $2 WHERE id = $1`
+
+	slog.Debug("Updating repository modification types", "repo_id", id, "types", types, "defaults", types == nil)
+
+	result, err := r.db.ExecContext(ctx, query, id, pq.StringArray(types))
+	if err != nil {
+		slog.Error("Database error updating repository modification types", "repo_id", id, "error", err)
+		return err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return repo.ErrRepositoryNotFound
+	}
+
+	slog.Info("Repository modification types updated", "repo_id", id, "defaults", types == nil)
+	return nil
+}
+
+func (r *RepoRepository) UpdateIssueTrackers(ctx context.Context, id int64, trackers []repo.IssueTracker) error {
+	const query = `UPDATE repository SET issue_trackers = $2 WHERE id = $1`
+
+	slog.Debug("Updating repository issue trackers", "repo_id", id, "trackers", len(trackers), "defaults", trackers == nil)
+
+	encoded, err := encodeIssueTrackers(trackers)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.ExecContext(ctx, query, id, encoded)
+	if err != nil {
+		slog.Error("Database error updating repository issue trackers", "repo_id", id, "error", err)
+		return err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return repo.ErrRepositoryNotFound
+	}
+
+	slog.Info("Repository issue trackers updated", "repo_id", id, "defaults", trackers == nil)
+	return nil
+}
+
+// issueTrackerRow is how repo.IssueTracker is stored in the issue_trackers column
+type issueTrackerRow struct {
+	Pattern     string `json:"pattern"`
+	URLTemplate string `json:"urlTemplate"`
+}
+
+// encodeIssueTrackers
+// nil encodes to NULL, so that the repository goes back to the defaults
+func encodeIssueTrackers(trackers []repo.IssueTracker) ([]byte, error) {
+	if trackers == nil {
+		return nil, nil
+	}
+
+	rows := make([]issueTrackerRow, len(trackers))
+	for i, tracker := range trackers {
+		rows[i] = issueTrackerRow(tracker)
+	}
+	return json.Marshal(rows)
+}
+
+func decodeIssueTrackers(raw []byte) ([]repo.IssueTracker, error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	var rows []issueTrackerRow
+	if err := json.Unmarshal(raw, &rows); err != nil {
+		return nil, err
+	}
+
+	trackers := make([]repo.IssueTracker, len(rows))
+	for i, row := range rows {
+		trackers[i] = repo.IssueTracker(row)
+	}
+	return trackers, nil
+}
+
+// scanRepo
+// A NULL ignore_patterns, modification_types or issue_trackers scans to nil, which keeps
+// the repository on the defaults
+func scanRepo(row rowScanner) (*repo.Repo, error) {
+	var id int64
+	var name, repoURL, lastSHA string
+	var createdAt time.Time
+	var ignorePatterns pq.StringArray
+	var budget agent.Budget
+	var overrides agent.PromptOverrides
+	var modificationTypes pq.StringArray
+	var rawIssueTrackers []byte
+
+	if err := row.Scan(&id, &name, &repoURL, &lastSHA, &createdAt, &ignorePatterns, &budget.MaxTokens, &budget.MaxCostUSD,
+		&overrides.Glossary, &overrides.TitleStyle, &overrides.Instructions, &modificationTypes, &rawIssueTrackers); err != nil {
+		return nil, err
+	}
+
+	issueTrackers, err := decodeIssueTrackers(rawIssueTrackers)
+	if err != nil {
+		return nil, err
+	}
+
+	foundRepo := repo.NewRepo(id, name, repoURL, lastSHA, createdAt)
+	foundRepo.SetIgnorePatterns(ignorePatterns)
+	foundRepo.SetBudget(budget)
+	foundRepo.SetPromptOverrides(overrides)
+	foundRepo.SetModificationTypes(modificationTypes)
+	foundRepo.SetIssueTrackers(issueTrackers)
+	return foundRepo, nil
+}
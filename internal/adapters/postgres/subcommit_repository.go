@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"log/slog"
 	"time"
@@ -23,9 +24,86 @@ func NewPostgresSubcommitRepository(db *sql.DB) (*SubcommitRepository, error) {
 	return &SubcommitRepository{db: db}, nil
 }
 
+// fileStatRow is how subcommit.FileStat is stored in the file_stats column
+type fileStatRow struct {
+	Path         string `json:"path"`
+	PreviousPath string `json:"previousPath,omitempty"`
+	Status       string `json:"status"`
+	Additions    int    `json:"additions"`
+	Deletions    int    `json:"deletions"`
+}
+
+func encodeFileStats(stats []subcommit.FileStat) ([]byte, error) {
+	rows := make([]fileStatRow, len(stats))
+	for i, stat := range stats {
+		rows[i] = fileStatRow(stat)
+	}
+	return json.Marshal(rows)
+}
+
+func decodeFileStats(raw []byte) ([]subcommit.FileStat, error) {
+	var rows []fileStatRow
+	if err := json.Unmarshal(raw, &rows); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	stats := make([]subcommit.FileStat, len(rows))
+	for i, row := range rows {
+		stats[i] = subcommit.FileStat(row)
+	}
+	return stats, nil
+}
+
+// pullRequestColumns
+// The pr_* values of a subcommit, all NULL for commits pushed directly
+func pullRequestColumns(pr *subcommit.PullRequest) (number sql.NullInt64, title, url sql.NullString, mergedAt sql.NullTime) {
+	if pr == nil {
+		return
+	}
+	number = sql.NullInt64{Int64: int64(pr.Number), Valid: true}
+	title = sql.NullString{String: pr.Title, Valid: true}
+	url = sql.NullString{String: pr.URL, Valid: true}
+	mergedAt = sql.NullTime{Time: pr.MergedAt, Valid: !pr.MergedAt.IsZero()}
+	return
+}
+
+// subcommitColumns
+// The issues of a subcommit come from subcommit_issue as a JSON array, in the order
+// they were mentioned
+const subcommitColumns = `id, title, idea, description, epic, modification_type, commit_sha, files, repo_id, committed_at, agent, prompt_version,
+	author_name, author_email, author_login, author_avatar_url, file_stats,
+	pr_number, pr_title, pr_url, pr_merged_at,
+	COALESCE((SELECT json_agg(json_build_object('ref', i.ref, 'url', i.url, 'closes', i.closes) ORDER BY i.position)
+	          FROM subcommit_issue i WHERE i.subcommit_id = subcommit.id), '[]')`
+
+// issueRow is how subcommit.Issue is read back from subcommit_issue
+type issueRow struct {
+	Ref    string `json:"ref"`
+	URL    string `json:"url"`
+	Closes bool   `json:"closes"`
+}
+
+func decodeIssues(raw []byte) ([]subcommit.Issue, error) {
+	var rows []issueRow
+	if err := json.Unmarshal(raw, &rows); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	issues := make([]subcommit.Issue, len(rows))
+	for i, row := range rows {
+		issues[i] = subcommit.Issue(row)
+	}
+	return issues, nil
+}
+
 func (r *SubcommitRepository) GetSubcommits(ctx context.Context, repoID int64) ([]subcommit.Subcommit, error) {
-	const query = `
-		SELECT id, title, idea, description, epic, modification_type, commit_sha, files, repo_id, committed_at
+	query := `SELECT ` + subcommitColumns + `
 		FROM subcommit
 		WHERE repo_id = $1
 		ORDER BY committed_at DESC`
@@ -39,22 +117,77 @@ func (r *SubcommitRepository) GetSubcommits(ctx context.Context, repoID int64) (
 	}
 	defer rows.Close()
 
+	subcommits, err := scanSubcommits(rows)
+	if err != nil {
+		slog.Error("Database error scanning subcommit rows", "repo_id", repoID, "error", err)
+		return nil, err
+	}
+
+	slog.Debug("Subcommits fetched from database", "repo_id", repoID, "count", len(subcommits))
+	return subcommits, nil
+}
+
+func (r *SubcommitRepository) GetSubcommitsByIssue(ctx context.Context, repoID int64, ref string) ([]subcommit.Subcommit, error) {
+	query := `SELECT ` + subcommitColumns + `
+		FROM subcommit
+		WHERE repo_id = $1 AND id IN (SELECT subcommit_id FROM subcommit_issue WHERE repo_id = $1 AND ref = $2)
+		ORDER BY committed_at DESC`
+
+	slog.Debug("Querying subcommits of issue from database", "repo_id", repoID, "ref", ref)
+
+	rows, err := r.db.QueryContext(ctx, query, repoID, ref)
+	if err != nil {
+		slog.Error("Database error querying subcommits of issue", "repo_id", repoID, "ref", ref, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	subcommits, err := scanSubcommits(rows)
+	if err != nil {
+		slog.Error("Database error scanning subcommit rows", "repo_id", repoID, "ref", ref, "error", err)
+		return nil, err
+	}
+
+	slog.Debug("Subcommits of issue fetched from database", "repo_id", repoID, "ref", ref, "count", len(subcommits))
+	return subcommits, nil
+}
+
+func scanSubcommits(rows *sql.Rows) ([]subcommit.Subcommit, error) {
 	var subcommits []subcommit.Subcommit
 	for rows.Next() {
 		var id, rID int64
-		var title, idea, desc, epic, modType, sha string
+		var title, idea, desc, epic, modType, sha, agent, promptVersion string
 		var files pq.StringArray
 		var committedAt time.Time
+		var author subcommit.Author
+		var rawFileStats, rawIssues []byte
+		var prNumber sql.NullInt64
+		var prTitle, prURL sql.NullString
+		var prMergedAt sql.NullTime
+
+		if err := rows.Scan(&id, &title, &idea, &desc, &epic, &modType, &sha, &files, &rID, &committedAt, &agent, &promptVersion,
+			&author.Name, &author.Email, &author.Login, &author.AvatarURL, &rawFileStats,
+			&prNumber, &prTitle, &prURL, &prMergedAt, &rawIssues); err != nil {
+			return nil, err
+		}
 
-		if err := rows.Scan(&id, &title, &idea, &desc, &epic, &modType, &sha, &files, &rID, &committedAt); err != nil {
-			slog.Error("Database error scanning subcommit row", "repo_id", repoID, "error", err)
+		fileStats, err := decodeFileStats(rawFileStats)
+		if err != nil {
 			return nil, err
 		}
 
-		subcommits = append(subcommits, subcommit.NewSubcommitFromDB(id, title, idea, desc, epic, modType, sha, []string(files), rID, committedAt))
-	}
+		issues, err := decodeIssues(rawIssues)
+		if err != nil {
+			return nil, err
+		}
 
-	slog.Debug("Subcommits fetched from database", "repo_id", repoID, "count", len(subcommits))
+		var pullRequest *subcommit.PullRequest
+		if prNumber.Valid {
+			pullRequest = &subcommit.PullRequest{Number: int(prNumber.Int64), Title: prTitle.String, URL: prURL.String, MergedAt: prMergedAt.Time}
+		}
+
+		subcommits = append(subcommits, subcommit.NewSubcommitFromDB(id, title, idea, desc, epic, modType, sha, []string(files), rID, committedAt, agent, promptVersion, author, fileStats, pullRequest, issues))
+	}
 	return subcommits, rows.Err()
 }
 
@@ -71,18 +204,42 @@ func (r *SubcommitRepository) HasSubcommitsForCommit(ctx context.Context, repoID
 
 func (r *SubcommitRepository) StoreSubcommits(ctx context.Context, subcommits <-chan subcommit.Subcommit) error {
 	const query = `
-		INSERT INTO subcommit (title, idea, description, epic, modification_type, commit_sha, files, repo_id, committed_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+		INSERT INTO subcommit (title, idea, description, epic, modification_type, commit_sha, files, repo_id, committed_at, agent, prompt_version,
+		                       author_name, author_email, author_login, author_avatar_url, file_stats,
+		                       pr_number, pr_title, pr_url, pr_merged_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
+		RETURNING id`
+	const issueQuery = `
+		INSERT INTO subcommit_issue (subcommit_id, repo_id, ref, url, closes, position)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (subcommit_id, ref) DO NOTHING`
 
 	var count int
 	for sc := range subcommits {
-		_, err := r.db.ExecContext(ctx, query,
+		fileStats, err := encodeFileStats(sc.FileStats())
+		if err != nil {
+			return err
+		}
+
+		author := sc.Author()
+		prNumber, prTitle, prURL, prMergedAt := pullRequestColumns(sc.PullRequest())
+		var id int64
+		err = r.db.QueryRowContext(ctx, query,
 			sc.Title(), sc.Idea(), sc.Description(), sc.Epic(), sc.ModificationType(), sc.CommitSHA(),
-			pq.Array(sc.Files()), sc.RepoID(), sc.CommittedAt())
+			pq.Array(sc.Files()), sc.RepoID(), sc.CommittedAt(), sc.Agent(), sc.PromptVersion(),
+			author.Name, author.Email, author.Login, author.AvatarURL, fileStats,
+			prNumber, prTitle, prURL, prMergedAt).Scan(&id)
 		if err != nil {
 			slog.Error("Database error storing subcommit", "repo_id", sc.RepoID(), "commit_sha", sc.CommitSHA(), "title", sc.Title(), "error", err)
 			return err
 		}
+
+		for position, issue := range sc.Issues() {
+			if _, err := r.db.ExecContext(ctx, issueQuery, id, sc.RepoID(), issue.Ref, issue.URL, issue.Closes, position); err != nil {
+				slog.Error("Database error linking subcommit to issue", "repo_id", sc.RepoID(), "subcommit_id", id, "ref", issue.Ref, "error", err)
+				return err
+			}
+		}
 		count++
 	}
 
@@ -0,0 +1,31 @@
+package pricing
+
+import (
+	"context"
+
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+)
+
+// Agent
+// Prices the tokens reported by the wrapped agent, which only knows how many it used
+type Agent struct {
+	agent agent.Agent
+	price agent.Price
+}
+
+func NewAgent(a agent.Agent, price agent.Price) *Agent {
+	return &Agent{agent: a, price: price}
+}
+
+// AnalyzeCommit
+// Failed calls are priced too, providers bill the tokens of every answered request
+func (a *Agent) AnalyzeCommit(ctx context.Context, input agent.AnalysisInput) ([]agent.AnalysisResult, error) {
+	meteredCtx, meter := agent.WithUsageMeter(ctx)
+	results, err := a.agent.AnalyzeCommit(meteredCtx, input)
+
+	usage := meter.Usage()
+	usage.CostUSD += a.price.Cost(usage.PromptTokens, usage.ResponseTokens)
+	agent.RecordUsage(ctx, usage)
+
+	return results, err
+}
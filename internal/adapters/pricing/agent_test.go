@@ -0,0 +1,66 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type billedAgent struct {
+	calls []agent.Usage
+	err   error
+}
+
+func (a *billedAgent) AnalyzeCommit(ctx context.Context, input agent.AnalysisInput) ([]agent.AnalysisResult, error) {
+	for _, usage := range a.calls {
+		agent.RecordUsage(ctx, usage)
+	}
+	return nil, a.err
+}
+
+type PricingAgentTestSuite struct {
+	suite.Suite
+	price agent.Price
+}
+
+func TestPricingAgentTestSuite(t *testing.T) {
+	suite.Run(t, new(PricingAgentTestSuite))
+}
+
+func (s *PricingAgentTestSuite) SetupTest() {
+	s.price = agent.Price{PromptPerMillion: 3, ResponsePerMillion: 15}
+}
+
+func (s *PricingAgentTestSuite) TestPricesEveryReportedCall() {
+	inner := &billedAgent{calls: []agent.Usage{{PromptTokens: 600_000, ResponseTokens: 20_000}, {PromptTokens: 400_000, ResponseTokens: 80_000}}}
+	ctx, meter := agent.WithUsageMeter(context.Background())
+
+	_, _ = NewAgent(inner, s.price).AnalyzeCommit(ctx, agent.AnalysisInput{Diff: "diff"})
+
+	usage := meter.Usage()
+	assert.Equal(s.T(), int64(1_000_000), usage.PromptTokens)
+	assert.Equal(s.T(), int64(100_000), usage.ResponseTokens)
+	assert.InDelta(s.T(), 4.5, usage.CostUSD, 1e-9)
+}
+
+func (s *PricingAgentTestSuite) TestFailedCallsAreStillBilled() {
+	inner := &billedAgent{calls: []agent.Usage{{PromptTokens: 1_000_000}}, err: errors.New("malformed answer")}
+	ctx, meter := agent.WithUsageMeter(context.Background())
+
+	_, err := NewAgent(inner, s.price).AnalyzeCommit(ctx, agent.AnalysisInput{Diff: "diff"})
+
+	assert.NotNil(s.T(), err)
+	assert.InDelta(s.T(), 3.0, meter.Usage().CostUSD, 1e-9)
+}
+
+func (s *PricingAgentTestSuite) TestWorksWithoutAMeter() {
+	inner := &billedAgent{calls: []agent.Usage{{PromptTokens: 10}}}
+
+	_, err := NewAgent(inner, s.price).AnalyzeCommit(context.Background(), agent.AnalysisInput{Diff: "diff"})
+
+	assert.Nil(s.T(), err)
+}
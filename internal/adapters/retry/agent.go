@@ -0,0 +1,50 @@
+package retry
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+)
+
+// Classifier
+// Reports whether an agent error is transient, and how long the provider asked to wait
+type Classifier func(err error) (retryable bool, advised time.Duration)
+
+// Agent
+// Retries an agent.Agent whose client does not go through a Transport
+type Agent struct {
+	agent    agent.Agent
+	policy   Policy
+	classify Classifier
+	sleep    func(ctx context.Context, d time.Duration) error
+}
+
+func NewAgent(a agent.Agent, policy Policy, classify Classifier) *Agent {
+	return &Agent{agent: a, policy: policy, classify: classify, sleep: sleep}
+}
+
+func (a *Agent) AnalyzeCommit(ctx context.Context, input agent.AnalysisInput) ([]agent.AnalysisResult, error) {
+	for attempt := 1; ; attempt++ {
+		results, err := a.agent.AnalyzeCommit(ctx, input)
+		if err == nil || ctx.Err() != nil {
+			return results, err
+		}
+
+		retryable, advised := a.classify(err)
+		if !retryable {
+			return nil, err
+		}
+
+		wait, ok := a.policy.delay(attempt, advised)
+		if !ok {
+			return nil, err
+		}
+
+		slog.Warn("Retrying agent analysis", "error", err, "attempt", attempt, "wait", wait)
+		if err := a.sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+var errQuota = errors.New("quota exhausted")
+
+type flakyAgent struct {
+	failures []error
+	calls    int
+}
+
+func (f *flakyAgent) AnalyzeCommit(ctx context.Context, input agent.AnalysisInput) ([]agent.AnalysisResult, error) {
+	f.calls++
+	if f.calls <= len(f.failures) {
+		return nil, f.failures[f.calls-1]
+	}
+	return []agent.AnalysisResult{{Title: "title"}}, nil
+}
+
+type RetryAgentTestSuite struct {
+	suite.Suite
+	waits []time.Duration
+}
+
+func TestRetryAgentTestSuite(t *testing.T) {
+	suite.Run(t, new(RetryAgentTestSuite))
+}
+
+func (s *RetryAgentTestSuite) SetupTest() {
+	s.waits = nil
+}
+
+func (s *RetryAgentTestSuite) retrying(inner agent.Agent) *Agent {
+	a := NewAgent(inner, testPolicy, func(err error) (bool, time.Duration) {
+		if errors.Is(err, errQuota) {
+			return true, 2 * time.Second
+		}
+		return false, 0
+	})
+	a.sleep = func(ctx context.Context, d time.Duration) error {
+		s.waits = append(s.waits, d)
+		return nil
+	}
+	return a
+}
+
+func (s *RetryAgentTestSuite) TestRetriesTransientErrors() {
+	inner := &flakyAgent{failures: []error{errQuota, errQuota}}
+	results, err := s.retrying(inner).AnalyzeCommit(context.Background(), agent.AnalysisInput{Diff: "diff"})
+
+	assert.Nil(s.T(), err)
+	assert.Len(s.T(), results, 1)
+	assert.Equal(s.T(), []time.Duration{2 * time.Second, 2 * time.Second}, s.waits)
+}
+
+func (s *RetryAgentTestSuite) TestDoesNotRetryPermanentErrors() {
+	inner := &flakyAgent{failures: []error{errors.New("invalid API key")}}
+	_, err := s.retrying(inner).AnalyzeCommit(context.Background(), agent.AnalysisInput{Diff: "diff"})
+
+	assert.NotNil(s.T(), err)
+	assert.Equal(s.T(), 1, inner.calls)
+}
+
+func (s *RetryAgentTestSuite) TestGivesUpAfterMaxAttempts() {
+	inner := &flakyAgent{failures: []error{errQuota, errQuota, errQuota, errQuota}}
+	_, err := s.retrying(inner).AnalyzeCommit(context.Background(), agent.AnalysisInput{Diff: "diff"})
+
+	assert.ErrorIs(s.T(), err, errQuota)
+	assert.Equal(s.T(), 3, inner.calls)
+}
@@ -0,0 +1,117 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Policy
+// How often and how patiently a failed call is retried. The zero value never retries
+type Policy struct {
+	// MaxAttempts counts the first call, 1 or less disables retries
+	MaxAttempts int
+	// BaseDelay is the backoff before the second attempt, doubled for every later one
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff. A server asking to wait longer than MaxDelay through
+	// Retry-After or a rate-limit reset is not retried, its answer is returned as is
+	MaxDelay time.Duration
+}
+
+var DefaultPolicy = Policy{MaxAttempts: 4, BaseDelay: time.Second, MaxDelay: time.Minute}
+
+var ErrInvalidPolicy = errors.New("invalid retry policy")
+
+// Validate
+// Rejects negative attempts and delays
+func (p Policy) Validate() error {
+	if p.MaxAttempts < 0 || p.BaseDelay < 0 || p.MaxDelay < 0 {
+		return ErrInvalidPolicy
+	}
+	return nil
+}
+
+func (p Policy) enabled() bool {
+	return p.MaxAttempts > 1
+}
+
+// delay
+// Wait before the attempt following the given one, honouring the wait the server
+// advised when there is one. Reports false when the caller should give up instead
+func (p Policy) delay(attempt int, advised time.Duration) (time.Duration, bool) {
+	if attempt >= p.MaxAttempts {
+		return 0, false
+	}
+
+	if advised > 0 {
+		return advised, advised <= p.MaxDelay
+	}
+
+	backoff := p.BaseDelay << (attempt - 1)
+	if backoff <= 0 || backoff > p.MaxDelay {
+		backoff = p.MaxDelay
+	}
+	if backoff <= 0 {
+		return 0, true
+	}
+
+	// Equal jitter: at least half the backoff, so concurrent callers spread out
+	// without retrying immediately
+	half := backoff / 2
+	return half + rand.N(half+1), true
+}
+
+// Wrap
+// Returns a copy of client whose transport retries according to the policy, or client
+// itself when the policy never retries. A nil client stands for http.DefaultClient
+func (p Policy) Wrap(client *http.Client) *http.Client {
+	if client == nil {
+		client = &http.Client{}
+	}
+
+	if !p.enabled() {
+		return client
+	}
+
+	wrapped := *client
+	wrapped.Transport = NewTransport(client.Transport, p)
+	return &wrapped
+}
+
+// AdvisedWait
+// How long the server asked to wait before retrying, from Retry-After (seconds or HTTP
+// date) or, once the rate limit is exhausted, from GitHub's X-RateLimit-Reset
+func AdvisedWait(header http.Header, now time.Time) time.Duration {
+	if value := header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil {
+			return max(time.Duration(seconds)*time.Second, 0)
+		}
+		if date, err := http.ParseTime(value); err == nil {
+			return max(date.Sub(now), 0)
+		}
+	}
+
+	if header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			// The reset time has second precision, wait one more to be past it
+			return max(time.Unix(reset, 0).Sub(now)+time.Second, 0)
+		}
+	}
+
+	return 0
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,89 @@
+package retry
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Transport
+// An http.RoundTripper that retries rate-limited (429, GitHub's exhausted 403) and
+// unavailable (5xx) responses and network errors
+type Transport struct {
+	base   http.RoundTripper
+	policy Policy
+	now    func() time.Time
+	sleep  func(ctx context.Context, d time.Duration) error
+}
+
+func NewTransport(base http.RoundTripper, policy Policy) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &Transport{base: base, policy: policy, now: time.Now, sleep: sleep}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if req.Context().Err() != nil {
+			return resp, err
+		}
+
+		var advised time.Duration
+		switch {
+		case err != nil:
+		case retryableStatus(resp):
+			advised = AdvisedWait(resp.Header, t.now())
+		default:
+			return resp, nil
+		}
+
+		// A request whose body cannot be replayed is only sent once
+		if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+			return resp, err
+		}
+
+		wait, ok := t.policy.delay(attempt, advised)
+		if !ok {
+			return resp, err
+		}
+
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+		slog.Warn("Retrying HTTP request", "method", req.Method, "host", req.URL.Host, "path", req.URL.Path,
+			"status", status, "error", err, "attempt", attempt, "wait", wait)
+
+		if err := t.sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+func retryableStatus(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	case http.StatusForbidden:
+		// GitHub answers 403 both for missing permissions and for exhausted rate limits
+		return resp.Header.Get("X-RateLimit-Remaining") == "0" || resp.Header.Get("Retry-After") != ""
+	}
+	return false
+}
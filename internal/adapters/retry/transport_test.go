@@ -0,0 +1,199 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+var testPolicy = Policy{MaxAttempts: 3, BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+
+// flakyServer fails the first failures requests with the response written by fail,
+// then answers 200 with the request body echoed back
+type flakyServer struct {
+	failures int
+	fail     func(w http.ResponseWriter)
+	requests atomic.Int32
+}
+
+func (f *flakyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := int(f.requests.Add(1))
+	if n <= f.failures {
+		f.fail(w)
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	_, _ = w.Write(body)
+}
+
+type TransportTestSuite struct {
+	suite.Suite
+	fake   *flakyServer
+	server *httptest.Server
+	now    time.Time
+	waits  []time.Duration
+	client *http.Client
+}
+
+func TestTransportTestSuite(t *testing.T) {
+	suite.Run(t, new(TransportTestSuite))
+}
+
+func (s *TransportTestSuite) SetupTest() {
+	s.fake = &flakyServer{fail: func(w http.ResponseWriter) { w.WriteHeader(http.StatusServiceUnavailable) }}
+	s.server = httptest.NewServer(s.fake)
+	s.T().Cleanup(s.server.Close)
+
+	s.now = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	s.waits = nil
+	s.client = s.clientWith(testPolicy)
+}
+
+func (s *TransportTestSuite) clientWith(policy Policy) *http.Client {
+	client := policy.Wrap(s.server.Client())
+	if transport, ok := client.Transport.(*Transport); ok {
+		transport.now = func() time.Time { return s.now }
+		transport.sleep = func(ctx context.Context, d time.Duration) error {
+			s.waits = append(s.waits, d)
+			return ctx.Err()
+		}
+	}
+	return client
+}
+
+func (s *TransportTestSuite) post(body string) *http.Response {
+	resp, err := s.client.Post(s.server.URL, "text/plain", strings.NewReader(body))
+	require.NoError(s.T(), err)
+	s.T().Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func (s *TransportTestSuite) TestRetriesUnavailableAndReplaysBody() {
+	s.fake.failures = 2
+	resp := s.post("diff")
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(s.T(), http.StatusOK, resp.StatusCode)
+	assert.Equal(s.T(), "diff", string(body))
+	assert.Equal(s.T(), int32(3), s.fake.requests.Load())
+}
+
+func (s *TransportTestSuite) TestBacksOffExponentiallyWithJitter() {
+	s.fake.failures = 2
+	s.post("diff")
+
+	require.Len(s.T(), s.waits, 2)
+	assert.GreaterOrEqual(s.T(), s.waits[0], 500*time.Millisecond)
+	assert.LessOrEqual(s.T(), s.waits[0], time.Second)
+	assert.GreaterOrEqual(s.T(), s.waits[1], time.Second)
+	assert.LessOrEqual(s.T(), s.waits[1], 2*time.Second)
+}
+
+func (s *TransportTestSuite) TestGivesUpAfterMaxAttempts() {
+	s.fake.failures = 10
+	resp := s.post("diff")
+
+	assert.Equal(s.T(), http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(s.T(), int32(3), s.fake.requests.Load())
+}
+
+func (s *TransportTestSuite) TestHonoursRetryAfter() {
+	s.fake.failures = 1
+	s.fake.fail = func(w http.ResponseWriter) {
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}
+	resp := s.post("diff")
+
+	assert.Equal(s.T(), http.StatusOK, resp.StatusCode)
+	assert.Equal(s.T(), []time.Duration{7 * time.Second}, s.waits)
+}
+
+func (s *TransportTestSuite) TestWaitsForGithubRateLimitReset() {
+	s.fake.failures = 1
+	s.fake.fail = func(w http.ResponseWriter) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(s.now.Add(4*time.Second).Unix(), 10))
+		w.WriteHeader(http.StatusForbidden)
+	}
+	resp := s.post("diff")
+
+	assert.Equal(s.T(), http.StatusOK, resp.StatusCode)
+	assert.Equal(s.T(), []time.Duration{5 * time.Second}, s.waits)
+}
+
+func (s *TransportTestSuite) TestDoesNotWaitLongerThanMaxDelay() {
+	s.fake.failures = 1
+	s.fake.fail = func(w http.ResponseWriter) {
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}
+	resp := s.post("diff")
+
+	assert.Equal(s.T(), http.StatusTooManyRequests, resp.StatusCode)
+	assert.Empty(s.T(), s.waits)
+}
+
+func (s *TransportTestSuite) TestPermissionDeniedIsNotRetried() {
+	s.fake.failures = 1
+	s.fake.fail = func(w http.ResponseWriter) { w.WriteHeader(http.StatusForbidden) }
+	resp := s.post("diff")
+
+	assert.Equal(s.T(), http.StatusForbidden, resp.StatusCode)
+	assert.Equal(s.T(), int32(1), s.fake.requests.Load())
+}
+
+func (s *TransportTestSuite) TestZeroPolicyDoesNotRetry() {
+	s.client = s.clientWith(Policy{})
+	s.fake.failures = 1
+	resp := s.post("diff")
+
+	assert.Equal(s.T(), http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(s.T(), int32(1), s.fake.requests.Load())
+}
+
+func (s *TransportTestSuite) TestStopsWaitingWhenCancelled() {
+	s.fake.failures = 10
+	ctx, cancel := context.WithCancel(context.Background())
+	s.client.Transport.(*Transport).sleep = func(ctx context.Context, d time.Duration) error {
+		cancel()
+		return ctx.Err()
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, s.server.URL, nil)
+	_, err := s.client.Do(req)
+
+	assert.ErrorIs(s.T(), err, context.Canceled)
+	assert.Equal(s.T(), int32(1), s.fake.requests.Load())
+}
+
+func (s *TransportTestSuite) TestAdvisedWaitParsesHTTPDate() {
+	header := http.Header{"Retry-After": {s.now.Add(30 * time.Second).Format(http.TimeFormat)}}
+	assert.Equal(s.T(), 30*time.Second, AdvisedWait(header, s.now))
+}
+
+func (s *TransportTestSuite) TestNegativeDelaysAreInvalidAndDoNotPanic() {
+	policy := Policy{MaxAttempts: 3, BaseDelay: -time.Second, MaxDelay: -time.Second}
+	assert.True(s.T(), errors.Is(policy.Validate(), ErrInvalidPolicy))
+	assert.True(s.T(), errors.Is(Policy{MaxAttempts: -1}.Validate(), ErrInvalidPolicy))
+	assert.Nil(s.T(), DefaultPolicy.Validate())
+
+	s.client = s.clientWith(policy)
+	s.fake.failures = 1
+	resp := s.post("diff")
+
+	assert.Equal(s.T(), http.StatusOK, resp.StatusCode)
+	assert.Equal(s.T(), []time.Duration{0}, s.waits)
+}
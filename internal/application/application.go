@@ -13,12 +13,29 @@ type Application struct {
 }
 
 type Commands struct {
-	AnalyzeRepo command.AnalyzeRepoHandler
+	AnalyzeRepo          command.AnalyzeRepoHandler
+	CancelAnalysis       command.CancelAnalysisHandler
+	RetryFailedCommits   command.RetryFailedCommitsHandler
+	SetIgnorePatterns    command.SetIgnorePatternsHandler
+	SetBudget            command.SetBudgetHandler
+	SetPromptOverrides   command.SetPromptOverridesHandler
+	SetModificationTypes command.SetModificationTypesHandler
+	SetIssueTrackers     command.SetIssueTrackersHandler
 }
 
 type Queries struct {
-	GetSubcommits   query.GetSubcommitsHandler
-	GetRepos        query.GetReposHandler
-	GetUserProfile  query.GetUserProfileHandler
-	SearchUserRepos query.SearchUserReposHandler
+	GetSubcommits          query.GetSubcommitsHandler
+	GetRepos               query.GetReposHandler
+	GetUserProfile         query.GetUserProfileHandler
+	SearchUserRepos        query.SearchUserReposHandler
+	GetAnalysisRuns        query.GetAnalysisRunsHandler
+	GetAnalysisRun         query.GetAnalysisRunHandler
+	WatchAnalysisProgress  query.WatchAnalysisProgressHandler
+	GetIgnorePatterns      query.GetIgnorePatternsHandler
+	GetUsage               query.GetUsageHandler
+	GetPromptOverrides     query.GetPromptOverridesHandler
+	GetModificationTypes   query.GetModificationTypesHandler
+	GetPullRequestTimeline query.GetPullRequestTimelineHandler
+	GetIssueTrackers       query.GetIssueTrackersHandler
+	GetIssueSubcommits     query.GetIssueSubcommitsHandler
 }
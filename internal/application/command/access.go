@@ -0,0 +1,33 @@
+package command
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+)
+
+// accessibleRepo
+// Looks a repository up by ID and checks the caller can reach it on its code host,
+// returning the code host client the check was made with
+func accessibleRepo(ctx context.Context, repoRepository repo.Repository, codeHostFactory codehost.CodeHostFactory, repoID int64, accessToken string) (*repo.Repo, codehost.CodeHost, error) {
+	foundRepo, err := repoRepository.GetRepoByID(ctx, repoID)
+	if err != nil {
+		slog.Warn("Repository not found by ID", "repo_id", repoID, "error", err)
+		return nil, nil, err
+	}
+
+	codeHost, err := codeHostFactory.Create(ctx, accessToken)
+	if err != nil {
+		slog.Error("Failed to create code host client", "repo_id", repoID, "error", err)
+		return nil, nil, err
+	}
+
+	if err := codeHost.CanAccessRepo(ctx, foundRepo.URL()); err != nil {
+		slog.Warn("Repository access denied", "repo_id", repoID, "repo_url", foundRepo.URL(), "error", err)
+		return nil, nil, err
+	}
+
+	return foundRepo, codeHost, nil
+}
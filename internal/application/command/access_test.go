@@ -0,0 +1,56 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/octokerbs/chronocode/internal/adapters/memory"
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// newStoredReposFixture
+// A repository store holding the valid and the forbidden repository of the memory
+// code host, with that code host
+func newStoredReposFixture() (repo.Repository, codehost.CodeHostFactory) {
+	repoRepository := memory.NewRepoRepository()
+	_ = repoRepository.StoreRepo(context.Background(), repo.NewRepo(memory.ValidRepoID, "chronocode", memory.ValidRepoURL, "", time.Time{}))
+	_ = repoRepository.StoreRepo(context.Background(), repo.NewRepo(memory.ForbiddenRepoID, "forbidden", memory.ForbiddenRepoURL, "", time.Time{}))
+	return repoRepository, memory.NewCodeHostFactory()
+}
+
+type AccessibleRepoTestSuite struct {
+	suite.Suite
+	repoRepository  repo.Repository
+	codeHostFactory codehost.CodeHostFactory
+}
+
+func TestAccessibleRepoTestSuite(t *testing.T) {
+	suite.Run(t, new(AccessibleRepoTestSuite))
+}
+
+func (s *AccessibleRepoTestSuite) SetupTest() {
+	s.repoRepository, s.codeHostFactory = newStoredReposFixture()
+}
+
+func (s *AccessibleRepoTestSuite) TestReturnsRepoAndItsCodeHost() {
+	found, codeHost, err := accessibleRepo(context.Background(), s.repoRepository, s.codeHostFactory, memory.ValidRepoID, memory.ValidAccessToken)
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), memory.ValidRepoURL, found.URL())
+	assert.NotNil(s.T(), codeHost)
+}
+
+func (s *AccessibleRepoTestSuite) TestUnknownRepoIsNotFound() {
+	_, _, err := accessibleRepo(context.Background(), s.repoRepository, s.codeHostFactory, 404, memory.ValidAccessToken)
+	assert.True(s.T(), errors.Is(err, repo.ErrRepositoryNotFound))
+}
+
+func (s *AccessibleRepoTestSuite) TestInaccessibleRepoIsDenied() {
+	_, _, err := accessibleRepo(context.Background(), s.repoRepository, s.codeHostFactory, memory.ForbiddenRepoID, memory.ValidAccessToken)
+	assert.True(s.T(), errors.Is(err, codehost.ErrAccessDenied))
+}
@@ -3,9 +3,18 @@ package command
 import (
 	"context"
 	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/octokerbs/chronocode/internal/adapters/cache"
+	"github.com/octokerbs/chronocode/internal/adapters/fallback"
+	"github.com/octokerbs/chronocode/internal/adapters/localgit"
 	"github.com/octokerbs/chronocode/internal/adapters/memory"
 	"github.com/octokerbs/chronocode/internal/domain/agent"
 	"github.com/octokerbs/chronocode/internal/domain/analysis"
@@ -20,9 +29,13 @@ type AnalyzeRepositoryTestSuite struct {
 	suite.Suite
 	repoRepository      repo.Repository
 	subcommitRepository subcommit.Repository
+	runRepository       analysis.RunRepository
+	commitStates        analysis.CommitStateRepository
 	agent               agent.Agent
 	codeHostFactory     codehost.CodeHostFactory
 	locker              analysis.Locker
+	progressBus         analysis.ProgressBus
+	cancelRegistry      analysis.CancelRegistry
 	handler             AnalyzeRepoHandler
 }
 
@@ -33,10 +46,14 @@ func TestAnalyzeRepositoryTestSuite(t *testing.T) {
 func (s *AnalyzeRepositoryTestSuite) SetupTest() {
 	s.repoRepository = memory.NewRepoRepository()
 	s.subcommitRepository = memory.NewSubcommitRepository()
+	s.runRepository = memory.NewAnalysisRunRepository()
+	s.commitStates = memory.NewCommitStateRepository()
 	s.agent = memory.NewAgent()
 	s.codeHostFactory = memory.NewCodeHostFactory()
 	s.locker = memory.NewInMemoryLocker()
-	s.handler = NewAnalyzeRepoHandler(s.repoRepository, s.subcommitRepository, s.agent, s.codeHostFactory, s.locker)
+	s.progressBus = memory.NewInMemoryProgressBus()
+	s.cancelRegistry = memory.NewInMemoryCancelRegistry()
+	s.handler = NewAnalyzeRepoHandler(s.repoRepository, s.subcommitRepository, s.runRepository, s.commitStates, s.agent, s.codeHostFactory, s.locker, s.progressBus, s.cancelRegistry, agent.Budget{})
 }
 
 func (s *AnalyzeRepositoryTestSuite) TestCannotAnalyzeWithoutAccessToken() {
@@ -73,6 +90,19 @@ func (s *AnalyzeRepositoryTestSuite) TestNewRepoHasSubcommitsAfterAnalysis() {
 	assert.NotEmpty(s.T(), subcommits)
 }
 
+func (s *AnalyzeRepositoryTestSuite) TestRecordsWhichAgentProducedEachSubcommit() {
+	chain, _ := fallback.NewAgent(3, time.Minute, fallback.Member{Name: "memory", Agent: s.agent})
+	handler := NewAnalyzeRepoHandler(s.repoRepository, s.subcommitRepository, s.runRepository, s.commitStates, chain, s.codeHostFactory, s.locker, s.progressBus, s.cancelRegistry, agent.Budget{})
+
+	_, _ = handler.Handle(context.Background(), AnalyzeRepo{memory.ValidRepoURL, memory.ValidAccessToken})
+	subcommits, _ := s.subcommitRepository.GetSubcommits(context.Background(), memory.ValidRepoID)
+
+	assert.NotEmpty(s.T(), subcommits)
+	for _, sc := range subcommits {
+		assert.Equal(s.T(), "memory", sc.Agent())
+	}
+}
+
 func (s *AnalyzeRepositoryTestSuite) TestNewRepoWithoutCommitsHasNoSubcommits() {
 	_, _ = s.handler.Handle(context.Background(), AnalyzeRepo{memory.ValidEmptyRepoURL, memory.ValidAccessToken})
 	subcommits, err := s.subcommitRepository.GetSubcommits(context.Background(), memory.ValidEmptyRepoID)
@@ -176,6 +206,65 @@ func (s *AnalyzeRepositoryTestSuite) TestAgentFailureDoesNotStoreSubcommits() {
 	assert.Empty(s.T(), subcommits)
 }
 
+// Subcommit storage failures
+
+// verboseAgent splits every commit in more subcommits than the pipeline buffers
+type verboseAgent struct{}
+
+func (verboseAgent) AnalyzeCommit(ctx context.Context, input agent.AnalysisInput) ([]agent.AnalysisResult, error) {
+	results := make([]agent.AnalysisResult, 150)
+	for i := range results {
+		results[i] = agent.AnalysisResult{Title: fmt.Sprintf("Change %d", i), ModificationType: "FEATURE", Files: []string{"main.go"}}
+	}
+	return results, nil
+}
+
+// failingSubcommitRepository fails on the first subcommit and stops reading, as the
+// Postgres repository does on a failed insert
+type failingSubcommitRepository struct {
+	subcommit.Repository
+}
+
+func (r failingSubcommitRepository) StoreSubcommits(ctx context.Context, subcommits <-chan subcommit.Subcommit) error {
+	<-subcommits
+	return errors.New("insert failed")
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestSubcommitStorageFailureDoesNotBlockTheRun() {
+	storage := failingSubcommitRepository{Repository: s.subcommitRepository}
+	handler := NewAnalyzeRepoHandler(s.repoRepository, storage, s.runRepository, s.commitStates, verboseAgent{}, s.codeHostFactory, s.locker, s.progressBus, s.cancelRegistry, agent.Budget{})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := handler.Handle(context.Background(), AnalyzeRepo{memory.ValidRepoURL, memory.ValidAccessToken})
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		assert.NotNil(s.T(), err)
+	case <-time.After(5 * time.Second):
+		s.T().Fatal("analysis blocked on a failed subcommit store")
+	}
+	runs, _ := s.runRepository.ListRuns(context.Background(), memory.ValidRepoID)
+	assert.Equal(s.T(), analysis.RunStatusFailed, runs[0].Status())
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestCommitsAreNotAnalyzedUntilTheirSubcommitsAreStored() {
+	storage := failingSubcommitRepository{Repository: s.subcommitRepository}
+	handler := NewAnalyzeRepoHandler(s.repoRepository, storage, s.runRepository, s.commitStates, s.agent, s.codeHostFactory, s.locker, s.progressBus, s.cancelRegistry, agent.Budget{})
+
+	_, err := handler.Handle(context.Background(), AnalyzeRepo{memory.ValidRepoURL, memory.ValidAccessToken})
+	assert.NotNil(s.T(), err)
+	assert.Equal(s.T(), analysis.CommitFailed, s.commitState(memory.ValidRepoID, memory.ValidRepoCommitSHA).Status())
+
+	_, err = s.handler.Handle(context.Background(), AnalyzeRepo{memory.ValidRepoURL, memory.ValidAccessToken})
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), analysis.CommitAnalyzed, s.commitState(memory.ValidRepoID, memory.ValidRepoCommitSHA).Status())
+	stored, _ := s.subcommitRepository.HasSubcommitsForCommit(context.Background(), memory.ValidRepoID, memory.ValidRepoCommitSHA)
+	assert.True(s.T(), stored)
+}
+
 // Partial failure (some commits succeed, some fail)
 
 func (s *AnalyzeRepositoryTestSuite) TestPartialFailureReturnsError() {
@@ -217,11 +306,74 @@ func (s *AnalyzeRepositoryTestSuite) TestSuccessfulAnalysisUpdatesLastAnalyzedSH
 	assert.Equal(s.T(), memory.ValidRepoCommitSHA, r.LastAnalyzedCommitSHA())
 }
 
-func (s *AnalyzeRepositoryTestSuite) TestPartialFailureDoesNotUpdateLastAnalyzedSHA() {
+func (s *AnalyzeRepositoryTestSuite) TestPartialFailureStillUpdatesLastAnalyzedSHA() {
 	_, _ = s.handler.Handle(context.Background(), AnalyzeRepo{memory.PartialFailureRepoURL, memory.ValidAccessToken})
 	r, _ := s.repoRepository.GetRepo(context.Background(), memory.PartialFailureRepoURL)
 
-	assert.Equal(s.T(), "", r.LastAnalyzedCommitSHA())
+	assert.Equal(s.T(), memory.ValidRepoCommitSHA, r.LastAnalyzedCommitSHA())
+}
+
+// Commit states
+
+func (s *AnalyzeRepositoryTestSuite) commitState(repoID int64, sha string) *analysis.CommitState {
+	states, _ := s.commitStates.ListCommitStates(context.Background(), repoID)
+	for _, state := range states {
+		if state.CommitSHA() == sha {
+			return state
+		}
+	}
+	return nil
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestAnalyzedCommitsAreRecordedAsAnalyzed() {
+	_, _ = s.handler.Handle(context.Background(), AnalyzeRepo{memory.ValidRepoURL, memory.ValidAccessToken})
+	states, err := s.commitStates.ListCommitStates(context.Background(), memory.ValidRepoID, analysis.CommitAnalyzed)
+
+	assert.Nil(s.T(), err)
+	assert.Len(s.T(), states, 2)
+	for _, state := range states {
+		assert.Equal(s.T(), 1, state.Attempts())
+	}
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestFailedCommitIsRecordedAsFailedWithError() {
+	_, _ = s.handler.Handle(context.Background(), AnalyzeRepo{memory.PartialFailureRepoURL, memory.ValidAccessToken})
+	state := s.commitState(memory.PartialFailureRepoID, memory.FailingCommitSHA)
+
+	assert.NotNil(s.T(), state)
+	assert.Equal(s.T(), analysis.CommitFailed, state.Status())
+	assert.Equal(s.T(), 1, state.Attempts())
+	assert.Contains(s.T(), state.LastError(), agent.ErrAnalysisFailed.Error())
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestReanalysisRetriesOnlyFailedCommits() {
+	_, _ = s.handler.Handle(context.Background(), AnalyzeRepo{memory.PartialFailureRepoURL, memory.ValidAccessToken})
+	_, _ = s.handler.Handle(context.Background(), AnalyzeRepo{memory.PartialFailureRepoURL, memory.ValidAccessToken})
+	runs, _ := s.runRepository.ListRuns(context.Background(), memory.PartialFailureRepoID)
+
+	assert.Equal(s.T(), analysis.CommitCounts{Total: 1, Failed: 1}, runs[0].Counts())
+	assert.Equal(s.T(), 2, s.commitState(memory.PartialFailureRepoID, memory.FailingCommitSHA).Attempts())
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestReanalysisStopsRetryingAfterMaxAttempts() {
+	for i := 0; i < maxAutomaticAttempts+1; i++ {
+		_, _ = s.handler.Handle(context.Background(), AnalyzeRepo{memory.PartialFailureRepoURL, memory.ValidAccessToken})
+	}
+	runs, _ := s.runRepository.ListRuns(context.Background(), memory.PartialFailureRepoID)
+
+	assert.Equal(s.T(), int64(0), runs[0].Counts().Total)
+	assert.Equal(s.T(), analysis.RunStatusSucceeded, runs[0].Status())
+	assert.Equal(s.T(), maxAutomaticAttempts, s.commitState(memory.PartialFailureRepoID, memory.FailingCommitSHA).Attempts())
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestCancelledCommitStaysPending() {
+	handler := s.handlerCancellingOnCall(1)
+	_, _ = handler.Handle(context.Background(), AnalyzeRepo{memory.ValidRepoURL, memory.ValidAccessToken})
+	failed, _ := s.commitStates.ListCommitStates(context.Background(), memory.ValidRepoID, analysis.CommitFailed)
+	analyzed, _ := s.commitStates.ListCommitStates(context.Background(), memory.ValidRepoID, analysis.CommitAnalyzed)
+
+	assert.Empty(s.T(), failed)
+	assert.Empty(s.T(), analyzed)
 }
 
 // Subcommit date
@@ -244,3 +396,586 @@ func (s *AnalyzeRepositoryTestSuite) TestConcurrentAnalysisOfSameRepoReturnsErro
 	_, err := s.handler.Handle(context.Background(), AnalyzeRepo{memory.ValidRepoURL, memory.ValidAccessToken})
 	assert.True(s.T(), errors.Is(err, analysis.ErrAnalysisInProgress))
 }
+
+// Analysis runs
+
+func (s *AnalyzeRepositoryTestSuite) TestSuccessfulAnalysisRecordsSucceededRun() {
+	_, _ = s.handler.Handle(context.Background(), AnalyzeRepo{memory.ValidRepoURL, memory.ValidAccessToken})
+	runs, err := s.runRepository.ListRuns(context.Background(), memory.ValidRepoID)
+
+	assert.Nil(s.T(), err)
+	assert.Len(s.T(), runs, 1)
+	assert.Equal(s.T(), analysis.RunStatusSucceeded, runs[0].Status())
+	assert.Equal(s.T(), memory.ValidRepoCommitSHA, runs[0].HeadSHA())
+	assert.Equal(s.T(), analysis.CommitCounts{Total: 2, Analyzed: 2}, runs[0].Counts())
+	assert.False(s.T(), runs[0].FinishedAt().IsZero())
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestPartialFailureRecordsFailedRunWithError() {
+	_, _ = s.handler.Handle(context.Background(), AnalyzeRepo{memory.PartialFailureRepoURL, memory.ValidAccessToken})
+	runs, _ := s.runRepository.ListRuns(context.Background(), memory.PartialFailureRepoID)
+
+	assert.Len(s.T(), runs, 1)
+	assert.Equal(s.T(), analysis.RunStatusFailed, runs[0].Status())
+	assert.Equal(s.T(), analysis.CommitCounts{Total: 2, Analyzed: 1, Failed: 1}, runs[0].Counts())
+	assert.Contains(s.T(), runs[0].Error(), agent.ErrAnalysisFailed.Error())
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestReanalysisRecordsOneRunPerAnalysis() {
+	_, _ = s.handler.Handle(context.Background(), AnalyzeRepo{memory.ValidRepoURL, memory.ValidAccessToken})
+	_, _ = s.handler.Handle(context.Background(), AnalyzeRepo{memory.ValidRepoURL, memory.ValidAccessToken})
+	runs, _ := s.runRepository.ListRuns(context.Background(), memory.ValidRepoID)
+
+	assert.Len(s.T(), runs, 2)
+	assert.Equal(s.T(), int64(0), runs[0].Counts().Total)
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestInvalidURLDoesNotRecordRun() {
+	_, _ = s.handler.Handle(context.Background(), AnalyzeRepo{memory.InvalidRepoURL, memory.ValidAccessToken})
+	runs, _ := s.runRepository.ListRuns(context.Background(), memory.ValidRepoID)
+
+	assert.Empty(s.T(), runs)
+}
+
+// Progress events
+
+func (s *AnalyzeRepositoryTestSuite) collectEvents(repoID int64, analyze func()) []analysis.ProgressEvent {
+	events, unsubscribe := s.progressBus.Subscribe(context.Background(), repoID)
+	analyze()
+	unsubscribe()
+
+	var collected []analysis.ProgressEvent
+	for event := range events {
+		collected = append(collected, event)
+	}
+	return collected
+}
+
+func countEvents(events []analysis.ProgressEvent, eventType analysis.EventType) int {
+	var n int
+	for _, event := range events {
+		if event.Type == eventType {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestAnalysisPublishesProgressEvents() {
+	events := s.collectEvents(memory.ValidRepoID, func() {
+		_, _ = s.handler.Handle(context.Background(), AnalyzeRepo{memory.ValidRepoURL, memory.ValidAccessToken})
+	})
+
+	assert.Equal(s.T(), 2, countEvents(events, analysis.EventCommitFetched))
+	assert.Equal(s.T(), 2, countEvents(events, analysis.EventCommitAnalyzed))
+	assert.Equal(s.T(), analysis.EventRunFinished, events[len(events)-1].Type)
+	assert.Equal(s.T(), analysis.CommitCounts{Total: 2, Analyzed: 2}, events[len(events)-1].Counts)
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestFailedCommitPublishesFailureEvent() {
+	events := s.collectEvents(memory.PartialFailureRepoID, func() {
+		_, _ = s.handler.Handle(context.Background(), AnalyzeRepo{memory.PartialFailureRepoURL, memory.ValidAccessToken})
+	})
+
+	assert.Equal(s.T(), 1, countEvents(events, analysis.EventCommitFailed))
+	assert.NotEmpty(s.T(), events[len(events)-1].Error)
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestProgressEventsAreScopedToRepo() {
+	events := s.collectEvents(memory.ValidEmptyRepoID, func() {
+		_, _ = s.handler.Handle(context.Background(), AnalyzeRepo{memory.ValidRepoURL, memory.ValidAccessToken})
+	})
+
+	assert.Empty(s.T(), events)
+}
+
+// Cancellation
+
+// cancellingAgent cancels the analysis of repoID on its n-th call and blocks until the
+// pipeline context is done; earlier calls are answered by the memory agent.
+type cancellingAgent struct {
+	registry analysis.CancelRegistry
+	repoID   int64
+	n        int64
+	calls    atomic.Int64
+}
+
+func (a *cancellingAgent) AnalyzeCommit(ctx context.Context, input agent.AnalysisInput) ([]agent.AnalysisResult, error) {
+	if a.calls.Add(1) < a.n {
+		return memory.NewAgent().AnalyzeCommit(ctx, input)
+	}
+
+	_ = a.registry.Cancel(ctx, a.repoID)
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (s *AnalyzeRepositoryTestSuite) handlerCancellingOnCall(n int64) AnalyzeRepoHandler {
+	cancelling := &cancellingAgent{registry: s.cancelRegistry, repoID: memory.ValidRepoID, n: n}
+	return NewAnalyzeRepoHandler(s.repoRepository, s.subcommitRepository, s.runRepository, s.commitStates, cancelling, s.codeHostFactory, s.locker, s.progressBus, s.cancelRegistry, agent.Budget{})
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestCancelledAnalysisReturnsCancelledError() {
+	handler := s.handlerCancellingOnCall(1)
+	_, err := handler.Handle(context.Background(), AnalyzeRepo{memory.ValidRepoURL, memory.ValidAccessToken})
+
+	assert.True(s.T(), errors.Is(err, analysis.ErrAnalysisCancelled))
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestCancelledAnalysisRecordsCancelledRun() {
+	handler := s.handlerCancellingOnCall(1)
+	_, _ = handler.Handle(context.Background(), AnalyzeRepo{memory.ValidRepoURL, memory.ValidAccessToken})
+	runs, _ := s.runRepository.ListRuns(context.Background(), memory.ValidRepoID)
+
+	assert.Len(s.T(), runs, 1)
+	assert.Equal(s.T(), analysis.RunStatusCancelled, runs[0].Status())
+	assert.Equal(s.T(), int64(0), runs[0].Counts().Failed)
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestCancelledAnalysisDoesNotUpdateLastAnalyzedSHA() {
+	handler := s.handlerCancellingOnCall(2)
+	_, _ = handler.Handle(context.Background(), AnalyzeRepo{memory.ValidRepoURL, memory.ValidAccessToken})
+	r, _ := s.repoRepository.GetRepo(context.Background(), memory.ValidRepoURL)
+
+	assert.Equal(s.T(), "", r.LastAnalyzedCommitSHA())
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestCancelledAnalysisKeepsSubcommitsOfFinishedCommits() {
+	handler := s.handlerCancellingOnCall(2)
+	_, _ = handler.Handle(context.Background(), AnalyzeRepo{memory.ValidRepoURL, memory.ValidAccessToken})
+	subcommits, _ := s.subcommitRepository.GetSubcommits(context.Background(), memory.ValidRepoID)
+
+	assert.Len(s.T(), subcommits, 1)
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestAnalysisAfterCancellationCompletesRemainingCommits() {
+	handler := s.handlerCancellingOnCall(2)
+	_, _ = handler.Handle(context.Background(), AnalyzeRepo{memory.ValidRepoURL, memory.ValidAccessToken})
+
+	_, err := s.handler.Handle(context.Background(), AnalyzeRepo{memory.ValidRepoURL, memory.ValidAccessToken})
+	subcommits, _ := s.subcommitRepository.GetSubcommits(context.Background(), memory.ValidRepoID)
+	r, _ := s.repoRepository.GetRepo(context.Background(), memory.ValidRepoURL)
+
+	assert.Nil(s.T(), err)
+	assert.Len(s.T(), subcommits, 2)
+	assert.Equal(s.T(), memory.ValidRepoCommitSHA, r.LastAnalyzedCommitSHA())
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestFinishedAnalysisCannotBeCancelled() {
+	_, _ = s.handler.Handle(context.Background(), AnalyzeRepo{memory.ValidRepoURL, memory.ValidAccessToken})
+	err := s.cancelRegistry.Cancel(context.Background(), memory.ValidRepoID)
+
+	assert.True(s.T(), errors.Is(err, analysis.ErrNoAnalysisRunning))
+}
+
+// Local git repositories
+
+func (s *AnalyzeRepositoryTestSuite) TestAnalyzesLocalGitRepositoryOffline() {
+	if _, err := exec.LookPath("git"); err != nil {
+		s.T().Skip("git is not installed")
+	}
+
+	root := s.T().TempDir()
+	workTree := filepath.Join(root, "fixture")
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", root}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com", "GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1")
+		out, err := cmd.CombinedOutput()
+		s.Require().NoError(err, string(out))
+	}
+	git("init", "-q", workTree)
+	for _, name := range []string{"a.go", "b.go"} {
+		s.Require().NoError(os.WriteFile(filepath.Join(workTree, name), []byte("package fixture\n"), 0o644))
+		git("-C", workTree, "add", name)
+		git("-C", workTree, "commit", "-q", "-m", "Add "+name)
+	}
+
+	factory, err := localgit.NewCodeHostFactory(root)
+	s.Require().NoError(err)
+	handler := NewAnalyzeRepoHandler(s.repoRepository, s.subcommitRepository, s.runRepository, s.commitStates, s.agent, factory, s.locker, s.progressBus, s.cancelRegistry, agent.Budget{})
+
+	repoID, err := handler.Handle(context.Background(), AnalyzeRepo{"file://" + workTree, ""})
+	subcommits, _ := s.subcommitRepository.GetSubcommits(context.Background(), repoID)
+	runs, _ := s.runRepository.ListRuns(context.Background(), repoID)
+
+	assert.Nil(s.T(), err)
+	assert.Len(s.T(), subcommits, 2)
+	assert.Equal(s.T(), analysis.CommitCounts{Total: 2, Analyzed: 2}, runs[0].Counts())
+}
+
+// Analysis cache
+
+func (s *AnalyzeRepositoryTestSuite) TestIdenticalDiffsAreServedFromCache() {
+	caching := cache.NewAgent(s.agent, memory.NewAnalysisCache(), "v1")
+	handler := NewAnalyzeRepoHandler(s.repoRepository, s.subcommitRepository, s.runRepository, s.commitStates, caching, s.codeHostFactory, s.locker, s.progressBus, s.cancelRegistry, agent.Budget{})
+
+	_, _ = handler.Handle(context.Background(), AnalyzeRepo{memory.ValidRepoURL, memory.ValidAccessToken})
+	_, _ = handler.Handle(context.Background(), AnalyzeRepo{memory.PartialFailureRepoURL, memory.ValidAccessToken})
+	runs, _ := s.runRepository.ListRuns(context.Background(), memory.PartialFailureRepoID)
+	subcommits, _ := s.subcommitRepository.GetSubcommits(context.Background(), memory.PartialFailureRepoID)
+
+	assert.Equal(s.T(), analysis.CommitCounts{Total: 2, Analyzed: 1, Failed: 1, Cached: 1}, runs[0].Counts())
+	assert.Equal(s.T(), 1.0, runs[0].Counts().CacheHitRate())
+	assert.Len(s.T(), subcommits, 1)
+}
+
+// Ignore patterns
+
+func (s *AnalyzeRepositoryTestSuite) TestCommitsTouchingOnlyIgnoredFilesAreRecordedAsSkipped() {
+	_ = s.repoRepository.StoreRepo(context.Background(), repo.NewRepo(memory.ValidRepoID, "chronocode", memory.ValidRepoURL, "", time.Time{}))
+	_ = s.repoRepository.UpdateIgnorePatterns(context.Background(), memory.ValidRepoID, []string{"*.go"})
+
+	_, err := s.handler.Handle(context.Background(), AnalyzeRepo{memory.ValidRepoURL, memory.ValidAccessToken})
+	subcommits, _ := s.subcommitRepository.GetSubcommits(context.Background(), memory.ValidRepoID)
+	skipped, _ := s.commitStates.ListCommitStates(context.Background(), memory.ValidRepoID, analysis.CommitSkipped)
+	runs, _ := s.runRepository.ListRuns(context.Background(), memory.ValidRepoID)
+
+	assert.Nil(s.T(), err)
+	assert.Empty(s.T(), subcommits)
+	assert.Len(s.T(), skipped, 2)
+	assert.Equal(s.T(), analysis.CommitCounts{Total: 2, Skipped: 2}, runs[0].Counts())
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestSkippedCommitsAreNotAnalyzedAgain() {
+	_ = s.repoRepository.StoreRepo(context.Background(), repo.NewRepo(memory.ValidRepoID, "chronocode", memory.ValidRepoURL, "", time.Time{}))
+	_ = s.repoRepository.UpdateIgnorePatterns(context.Background(), memory.ValidRepoID, []string{"*.go"})
+	_, _ = s.handler.Handle(context.Background(), AnalyzeRepo{memory.ValidRepoURL, memory.ValidAccessToken})
+
+	_ = s.repoRepository.UpdateIgnorePatterns(context.Background(), memory.ValidRepoID, nil)
+	_, _ = s.handler.Handle(context.Background(), AnalyzeRepo{memory.ValidRepoURL, memory.ValidAccessToken})
+	subcommits, _ := s.subcommitRepository.GetSubcommits(context.Background(), memory.ValidRepoID)
+
+	assert.Empty(s.T(), subcommits)
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestAnalysisKeepsIgnorePatterns() {
+	_ = s.repoRepository.StoreRepo(context.Background(), repo.NewRepo(memory.ValidRepoID, "chronocode", memory.ValidRepoURL, "", time.Time{}))
+	_ = s.repoRepository.UpdateIgnorePatterns(context.Background(), memory.ValidRepoID, []string{"docs/"})
+
+	_, _ = s.handler.Handle(context.Background(), AnalyzeRepo{memory.ValidRepoURL, memory.ValidAccessToken})
+	r, _ := s.repoRepository.GetRepo(context.Background(), memory.ValidRepoURL)
+
+	assert.Equal(s.T(), []string{"docs/"}, r.IgnorePatterns())
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestDefaultPatternsFilterLockAndVendoredFiles() {
+	rules, err := repo.CompileIgnorePatterns(repo.DefaultIgnorePatterns)
+	s.Require().NoError(err)
+
+	diff := "Subject: Bump deps\n\n" +
+		"File: main.go\n+func main() {}\n\n" +
+		"File: go.sum\n+golang.org/x/net v0.1.0 h1:abc\n\n" +
+		"File: vendor/golang.org/x/net/http.go\n+package net\n\n" +
+		"File: web/dist/app.min.js\n+!function(){}\n\n"
+	filtered, ignored, kept := filterIgnoredFiles(diff, rules)
+
+	assert.Equal(s.T(), "Subject: Bump deps\n\nFile: main.go\n+func main() {}\n\n", filtered)
+	assert.Equal(s.T(), 3, ignored)
+	assert.Equal(s.T(), 1, kept)
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestIgnoreRulesFollowGitignoreSyntax() {
+	rules, err := repo.CompileIgnorePatterns([]string{
+		"# generated code",
+		"/gen/",
+		"docs/**/*.png",
+		"*.snap",
+		"!keep.snap",
+		"build/",
+		"!build/keep.txt",
+		"[Tt]emp?.txt",
+	})
+	s.Require().NoError(err)
+
+	for path, ignored := range map[string]bool{
+		"gen/api.go":               true,
+		"pkg/gen/api.go":           false,
+		"docs/a/b/diagram.png":     true,
+		"docs/diagram.png":         true,
+		"src/docs/diagram.png":     false,
+		"ui/__tests__/view.snap":   true,
+		"ui/keep.snap":             false,
+		"build/out.bin":            true,
+		"build/keep.txt":           true,
+		"cmd/build":                false,
+		"Temp1.txt":                true,
+		"notes/temp2.txt":          true,
+		"temp10.txt":               false,
+		"# generated code/file.go": false,
+	} {
+		assert.Equal(s.T(), ignored, rules.Ignores(path), path)
+	}
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestMalformedIgnorePatternIsRejected() {
+	_, err := repo.CompileIgnorePatterns([]string{"src/[abc"})
+	assert.True(s.T(), errors.Is(err, repo.ErrInvalidIgnorePattern))
+}
+
+// Usage and budgets
+
+// meteredAgent answers like the memory agent and reports usage for every call
+type meteredAgent struct {
+	usage agent.Usage
+}
+
+func (a meteredAgent) AnalyzeCommit(ctx context.Context, input agent.AnalysisInput) ([]agent.AnalysisResult, error) {
+	agent.RecordUsage(ctx, a.usage)
+	return memory.NewAgent().AnalyzeCommit(ctx, input)
+}
+
+func (s *AnalyzeRepositoryTestSuite) meteredHandler(globalBudget agent.Budget) AnalyzeRepoHandler {
+	metered := meteredAgent{usage: agent.Usage{PromptTokens: 100, ResponseTokens: 20, CostUSD: 0.01}}
+	return NewAnalyzeRepoHandler(s.repoRepository, s.subcommitRepository, s.runRepository, s.commitStates, metered, s.codeHostFactory, s.locker, s.progressBus, s.cancelRegistry, globalBudget)
+}
+
+// spend records usage on a commit that is already analyzed, as an earlier run would
+func (s *AnalyzeRepositoryTestSuite) spend(repoID int64, tokens int64) {
+	state := analysis.NewCommitStateFromDB(repoID, "earlier-sha", time.Time{}, analysis.CommitAnalyzed, 1, "", time.Time{}, agent.Usage{PromptTokens: tokens})
+	_ = s.commitStates.StoreCommitState(context.Background(), state)
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestRecordsUsagePerCommitAndRun() {
+	handler := s.meteredHandler(agent.Budget{})
+	_, _ = handler.Handle(context.Background(), AnalyzeRepo{memory.ValidRepoURL, memory.ValidAccessToken})
+	runs, _ := s.runRepository.ListRuns(context.Background(), memory.ValidRepoID)
+	total, _ := s.commitStates.RepoUsage(context.Background(), memory.ValidRepoID)
+
+	assert.Equal(s.T(), int64(120), s.commitState(memory.ValidRepoID, memory.ValidRepoCommitSHA).Usage().TotalTokens())
+	assert.Equal(s.T(), int64(240), runs[0].Usage().TotalTokens())
+	assert.InDelta(s.T(), 0.02, runs[0].Usage().CostUSD, 1e-9)
+	assert.Equal(s.T(), runs[0].Usage(), total)
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestExhaustedRepoBudgetPausesAnalysis() {
+	_ = s.repoRepository.StoreRepo(context.Background(), repo.NewRepo(memory.ValidRepoID, "chronocode", memory.ValidRepoURL, "", time.Time{}))
+	_ = s.repoRepository.UpdateBudget(context.Background(), memory.ValidRepoID, agent.Budget{MaxTokens: 500})
+	s.spend(memory.ValidRepoID, 500)
+	handler := s.meteredHandler(agent.Budget{})
+
+	_, err := handler.Handle(context.Background(), AnalyzeRepo{memory.ValidRepoURL, memory.ValidAccessToken})
+	runs, _ := s.runRepository.ListRuns(context.Background(), memory.ValidRepoID)
+	subcommits, _ := s.subcommitRepository.GetSubcommits(context.Background(), memory.ValidRepoID)
+	r, _ := s.repoRepository.GetRepo(context.Background(), memory.ValidRepoURL)
+
+	assert.True(s.T(), errors.Is(err, analysis.ErrBudgetExceeded))
+	assert.Equal(s.T(), analysis.RunStatusPaused, runs[0].Status())
+	assert.Empty(s.T(), subcommits)
+	assert.Equal(s.T(), "", r.LastAnalyzedCommitSHA())
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestRaisedBudgetResumesPausedAnalysis() {
+	_ = s.repoRepository.StoreRepo(context.Background(), repo.NewRepo(memory.ValidRepoID, "chronocode", memory.ValidRepoURL, "", time.Time{}))
+	_ = s.repoRepository.UpdateBudget(context.Background(), memory.ValidRepoID, agent.Budget{MaxTokens: 500})
+	s.spend(memory.ValidRepoID, 500)
+	handler := s.meteredHandler(agent.Budget{})
+	_, _ = handler.Handle(context.Background(), AnalyzeRepo{memory.ValidRepoURL, memory.ValidAccessToken})
+
+	_ = s.repoRepository.UpdateBudget(context.Background(), memory.ValidRepoID, agent.Budget{MaxTokens: 10000})
+	_, err := handler.Handle(context.Background(), AnalyzeRepo{memory.ValidRepoURL, memory.ValidAccessToken})
+	subcommits, _ := s.subcommitRepository.GetSubcommits(context.Background(), memory.ValidRepoID)
+	r, _ := s.repoRepository.GetRepo(context.Background(), memory.ValidRepoURL)
+
+	assert.Nil(s.T(), err)
+	assert.Len(s.T(), subcommits, 2)
+	assert.Equal(s.T(), memory.ValidRepoCommitSHA, r.LastAnalyzedCommitSHA())
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestGlobalBudgetCoversEveryRepository() {
+	s.spend(memory.PartialFailureRepoID, 1000)
+	handler := s.meteredHandler(agent.Budget{MaxTokens: 1000})
+
+	_, err := handler.Handle(context.Background(), AnalyzeRepo{memory.ValidRepoURL, memory.ValidAccessToken})
+	runs, _ := s.runRepository.ListRuns(context.Background(), memory.ValidRepoID)
+
+	assert.True(s.T(), errors.Is(err, analysis.ErrBudgetExceeded))
+	assert.Equal(s.T(), analysis.RunStatusPaused, runs[0].Status())
+	assert.Equal(s.T(), int64(0), runs[0].Counts().Analyzed)
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestGlobalBudgetCountsConcurrentRuns() {
+	handler := s.meteredHandler(agent.Budget{MaxTokens: 1000})
+	r := repo.NewRepo(memory.ValidRepoID, "chronocode", memory.ValidRepoURL, "", time.Time{})
+	guard, _ := handler.pipeline.newBudgetGuard(context.Background(), r, func(error) {})
+	guard.add(agent.Usage{PromptTokens: 400})
+	guard.refresh(context.Background())
+	assert.True(s.T(), guard.allow())
+
+	// Another run stores what it spent while this one is still going
+	s.spend(memory.PartialFailureRepoID, 1000)
+	guard.refreshedAt = time.Now().Add(-globalUsageRefreshInterval)
+	guard.refresh(context.Background())
+
+	assert.False(s.T(), guard.allow())
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestGlobalUsageIsNotReloadedBeforeEveryCommit() {
+	handler := s.meteredHandler(agent.Budget{MaxTokens: 1000})
+	r := repo.NewRepo(memory.ValidRepoID, "chronocode", memory.ValidRepoURL, "", time.Time{})
+	guard, _ := handler.pipeline.newBudgetGuard(context.Background(), r, func(error) {})
+
+	s.spend(memory.PartialFailureRepoID, 1000)
+	guard.refresh(context.Background())
+
+	assert.True(s.T(), guard.allow())
+}
+
+// Prompts
+
+// promptedAgent answers like the memory agent and stamps each result with the
+// fingerprint of the prompt overrides it was asked with
+type promptedAgent struct{}
+
+func (promptedAgent) AnalyzeCommit(ctx context.Context, input agent.AnalysisInput) ([]agent.AnalysisResult, error) {
+	results, err := memory.NewAgent().AnalyzeCommit(ctx, input)
+	for i := range results {
+		results[i].PromptVersion = "1+" + agent.PromptOverridesFrom(ctx).Fingerprint()
+	}
+	return results, err
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestSubcommitsRecordThePromptOfTheirRepository() {
+	overrides := agent.PromptOverrides{Glossary: "Ledger: the accounting service"}
+	_ = s.repoRepository.StoreRepo(context.Background(), repo.NewRepo(memory.ValidRepoID, "chronocode", memory.ValidRepoURL, "", time.Time{}))
+	_ = s.repoRepository.UpdatePromptOverrides(context.Background(), memory.ValidRepoID, overrides)
+	handler := NewAnalyzeRepoHandler(s.repoRepository, s.subcommitRepository, s.runRepository, s.commitStates, promptedAgent{}, s.codeHostFactory, s.locker, s.progressBus, s.cancelRegistry, agent.Budget{})
+
+	_, _ = handler.Handle(context.Background(), AnalyzeRepo{memory.ValidRepoURL, memory.ValidAccessToken})
+	subcommits, _ := s.subcommitRepository.GetSubcommits(context.Background(), memory.ValidRepoID)
+	r, _ := s.repoRepository.GetRepo(context.Background(), memory.ValidRepoURL)
+
+	assert.NotEmpty(s.T(), subcommits)
+	for _, sc := range subcommits {
+		assert.Equal(s.T(), "1+"+overrides.Fingerprint(), sc.PromptVersion())
+	}
+	assert.Equal(s.T(), overrides, r.PromptOverrides())
+}
+
+// Commit metadata
+
+// inputRecordingAgent answers like the memory agent and keeps what it was asked
+type inputRecordingAgent struct {
+	mu     sync.Mutex
+	inputs []agent.AnalysisInput
+}
+
+func (a *inputRecordingAgent) AnalyzeCommit(ctx context.Context, input agent.AnalysisInput) ([]agent.AnalysisResult, error) {
+	a.mu.Lock()
+	a.inputs = append(a.inputs, input)
+	a.mu.Unlock()
+	return memory.NewAgent().AnalyzeCommit(ctx, input)
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestAgentIsToldAboutTheCommit() {
+	recording := &inputRecordingAgent{}
+	handler := NewAnalyzeRepoHandler(s.repoRepository, s.subcommitRepository, s.runRepository, s.commitStates, recording, s.codeHostFactory, s.locker, s.progressBus, s.cancelRegistry, agent.Budget{})
+
+	_, err := handler.Handle(context.Background(), AnalyzeRepo{memory.ValidRepoURL, memory.ValidAccessToken})
+
+	s.Require().NoError(err)
+	s.Require().Len(recording.inputs, 2)
+	for _, input := range recording.inputs {
+		assert.Equal(s.T(), memory.ValidCommitDiff, input.Diff)
+		assert.Equal(s.T(), memory.ValidCommitMessage, input.Message)
+		assert.Equal(s.T(), "Test User <test@example.com>", input.Author)
+		assert.Equal(s.T(), []agent.ChangedFile{{Path: "main.go", Status: "modified", Additions: 1}}, input.Files)
+	}
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestSubcommitsRecordTheirAuthor() {
+	_, _ = s.handler.Handle(context.Background(), AnalyzeRepo{memory.ValidRepoURL, memory.ValidAccessToken})
+	subcommits, _ := s.subcommitRepository.GetSubcommits(context.Background(), memory.ValidRepoID)
+
+	s.Require().NotEmpty(subcommits)
+	for _, sc := range subcommits {
+		assert.Equal(s.T(), subcommit.Author{Name: "Test User", Email: "test@example.com"}, sc.Author())
+	}
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestSubcommitsRecordThePullRequestOfTheirCommit() {
+	recording := &inputRecordingAgent{}
+	handler := NewAnalyzeRepoHandler(s.repoRepository, s.subcommitRepository, s.runRepository, s.commitStates, recording, s.codeHostFactory, s.locker, s.progressBus, s.cancelRegistry, agent.Budget{})
+
+	_, err := handler.Handle(context.Background(), AnalyzeRepo{memory.ValidRepoURL, memory.ValidAccessToken})
+	subcommits, _ := s.subcommitRepository.GetSubcommits(context.Background(), memory.ValidRepoID)
+
+	s.Require().NoError(err)
+	s.Require().NotEmpty(subcommits)
+	pr := memory.ValidPullRequest
+	for _, sc := range subcommits {
+		if sc.CommitSHA() == memory.ValidRepoCommitSHA {
+			assert.Equal(s.T(), &subcommit.PullRequest{Number: pr.Number, Title: pr.Title, URL: pr.URL, MergedAt: pr.MergedAt}, sc.PullRequest())
+		} else {
+			assert.Nil(s.T(), sc.PullRequest())
+		}
+	}
+	for _, input := range recording.inputs {
+		if input.SHA == memory.ValidRepoCommitSHA {
+			assert.Equal(s.T(), &agent.PullRequest{Number: pr.Number, Title: pr.Title, Body: pr.Body, URL: pr.URL}, input.PullRequest)
+		} else {
+			assert.Nil(s.T(), input.PullRequest)
+		}
+	}
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestSubcommitsAreLinkedToTheIssuesOfTheirPullRequest() {
+	_, err := s.handler.Handle(context.Background(), AnalyzeRepo{memory.ValidRepoURL, memory.ValidAccessToken})
+	linked, _ := s.subcommitRepository.GetSubcommitsByIssue(context.Background(), memory.ValidRepoID, "#3")
+
+	s.Require().NoError(err)
+	s.Require().NotEmpty(linked)
+	for _, sc := range linked {
+		assert.Equal(s.T(), memory.ValidRepoCommitSHA, sc.CommitSHA())
+		assert.Equal(s.T(), []subcommit.Issue{{Ref: "#3", URL: memory.ValidRepoURL + "/issues/3", Closes: true}}, sc.Issues())
+	}
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestCommitIssuesFollowTheRepositoryTrackers() {
+	matcher, err := repo.CompileIssueTrackers(append([]repo.IssueTracker{
+		{Pattern: `JIRA-\d+`, URLTemplate: "https://example.atlassian.net/browse/{ref}"},
+	}, repo.DefaultIssueTrackers...))
+	s.Require().NoError(err)
+	pr := &codehost.PullRequest{Title: "Retry payments (JIRA-456)", Body: "Fixes octokerbs/ledger#9, see #12 and JIRA-456.\n\nNot UTF-8 or item#1."}
+
+	issues := commitIssues(matcher, "https://github.com/octokerbs/chronocode", "fix: retry failed payments\n\nResolves #12", pr)
+
+	assert.Equal(s.T(), []subcommit.Issue{
+		{Ref: "#12", URL: "https://github.com/octokerbs/chronocode/issues/12", Closes: true},
+		{Ref: "JIRA-456", URL: "https://example.atlassian.net/browse/JIRA-456"},
+		{Ref: "octokerbs/ledger#9", URL: "https://github.com/octokerbs/ledger/issues/9", Closes: true},
+	}, issues)
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestAnalysisInputDropsIgnoredFilesAndFallsBackToTheListing() {
+	rules, err := repo.CompileIgnorePatterns([]string{"go.sum"})
+	s.Require().NoError(err)
+	ref := codehost.CommitReference{SHA: "c5", Message: "chore: bump deps", Author: codehost.CommitAuthor{Email: "ada@example.com"}, Parents: []string{"c4"}}
+	commitDiff := &codehost.CommitDiff{Files: []codehost.FileStat{
+		{Path: "go.mod", Status: codehost.FileModified, Additions: 1, Deletions: 1},
+		{Path: "go.sum", Status: codehost.FileModified, Additions: 2, Deletions: 2},
+	}}
+
+	input := analysisInput(describeCommit(ref, commitDiff), commitDiff.Files, nil, "File: go.mod\n", rules)
+
+	assert.Equal(s.T(), agent.AnalysisInput{
+		Diff: "File: go.mod\n", SHA: "c5", Message: "chore: bump deps", Author: "ada@example.com", Parents: []string{"c4"},
+		Files: []agent.ChangedFile{{Path: "go.mod", Status: "modified", Additions: 1, Deletions: 1}},
+	}, input)
+}
+
+func (s *AnalyzeRepositoryTestSuite) TestSubcommitFilesGetTheStatsOfTheCommit() {
+	stats := []codehost.FileStat{
+		{Path: "cmd/main.go", Status: codehost.FileModified, Additions: 4, Deletions: 1},
+		{Path: "internal/server/server.go", PreviousPath: "server.go", Status: codehost.FileRenamed, Additions: 2},
+		{Path: "internal/config.go", Status: codehost.FileAdded, Additions: 30},
+		{Path: "pkg/config.go", Status: codehost.FileAdded, Additions: 12},
+	}
+
+	matched := fileStatsFor([]string{"cmd/main.go", "server.go", "config.go", "cmd/main.go", "missing.go"}, stats)
+
+	assert.Equal(s.T(), []subcommit.FileStat{
+		{Path: "cmd/main.go", Status: "modified", Additions: 4, Deletions: 1},
+		{Path: "internal/server/server.go", PreviousPath: "server.go", Status: "renamed", Additions: 2},
+	}, matched)
+}
@@ -0,0 +1,134 @@
+package command
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+	"github.com/octokerbs/chronocode/internal/domain/analysis"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+)
+
+// globalUsageRefreshInterval bounds how often a run sums the stored usage of every
+// repository, the query scans all commit states
+const globalUsageRefreshInterval = 5 * time.Second
+
+// budgetGuard
+// Tracks the usage of a run against the repository and global budgets. Once either is
+// exhausted it pauses the run: no further commits are sent to the agent, analyses
+// already in flight finish and are stored. Other repositories may be analyzed at the
+// same time, so the global usage is read again from storage every few seconds.
+type budgetGuard struct {
+	mu           sync.Mutex
+	repoID       int64
+	repoBudget   agent.Budget
+	globalBudget agent.Budget
+	repoSpent    agent.Usage
+	globalSpent  agent.Usage
+	// globalBase is the global usage stored when the run started
+	globalBase  agent.Usage
+	runUsage    agent.Usage
+	refreshedAt time.Time
+	paused      bool
+	pause       context.CancelCauseFunc
+	commitState analysis.CommitStateRepository
+}
+
+// newBudgetGuard
+// Only looks up the usage spent so far for the budgets that are set
+func (p *analysisPipeline) newBudgetGuard(ctx context.Context, r *repo.Repo, pause context.CancelCauseFunc) (*budgetGuard, error) {
+	guard := &budgetGuard{repoID: r.ID(), repoBudget: r.Budget(), globalBudget: p.globalBudget, pause: pause, commitState: p.commitStateRepository}
+
+	if !guard.repoBudget.IsZero() {
+		spent, err := p.commitStateRepository.RepoUsage(ctx, r.ID())
+		if err != nil {
+			slog.Error("Failed to load repository usage", "repo_id", r.ID(), "error", err)
+			return nil, err
+		}
+		guard.repoSpent = spent
+	}
+
+	if !guard.globalBudget.IsZero() {
+		spent, err := p.commitStateRepository.TotalUsage(ctx)
+		if err != nil {
+			slog.Error("Failed to load total usage", "repo_id", r.ID(), "error", err)
+			return nil, err
+		}
+		guard.globalSpent = spent
+		guard.globalBase = spent
+		guard.refreshedAt = time.Now()
+	}
+
+	return guard, nil
+}
+
+func (g *budgetGuard) add(usage agent.Usage) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.repoSpent = g.repoSpent.Add(usage)
+	g.globalSpent = g.globalSpent.Add(usage)
+	g.runUsage = g.runUsage.Add(usage)
+}
+
+// refresh
+// Catches up with the global usage stored by concurrent runs. This run's analyses may
+// not be stored yet, so its own count is kept when it is higher. Between reloads the
+// run only counts its own usage.
+func (g *budgetGuard) refresh(ctx context.Context) {
+	if g.globalBudget.IsZero() {
+		return
+	}
+
+	g.mu.Lock()
+	if time.Since(g.refreshedAt) < globalUsageRefreshInterval {
+		g.mu.Unlock()
+		return
+	}
+	// Claimed before the query so concurrent analyses don't all reload at once
+	g.refreshedAt = time.Now()
+	g.mu.Unlock()
+
+	stored, err := g.commitState.TotalUsage(ctx)
+	if err != nil {
+		slog.Warn("Failed to reload total usage, keeping the run's count", "repo_id", g.repoID, "error", err)
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	own := g.globalBase.Add(g.runUsage)
+	g.globalSpent = agent.Usage{
+		PromptTokens:   max(stored.PromptTokens, own.PromptTokens),
+		ResponseTokens: max(stored.ResponseTokens, own.ResponseTokens),
+		CostUSD:        max(stored.CostUSD, own.CostUSD),
+	}
+}
+
+func (g *budgetGuard) usage() agent.Usage {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.runUsage
+}
+
+// allow
+// Whether another commit may be analyzed, pausing the run when not
+func (g *budgetGuard) allow() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.repoBudget.ExceededBy(g.repoSpent) && !g.globalBudget.ExceededBy(g.globalSpent) {
+		return true
+	}
+	if g.paused {
+		return false
+	}
+
+	g.paused = true
+	slog.Warn("Analysis budget exceeded, pausing run", "repo_id", g.repoID,
+		"repo_tokens", g.repoSpent.TotalTokens(), "repo_cost_usd", g.repoSpent.CostUSD,
+		"global_tokens", g.globalSpent.TotalTokens(), "global_cost_usd", g.globalSpent.CostUSD)
+	g.pause(analysis.ErrBudgetExceeded)
+	return false
+}
@@ -0,0 +1,42 @@
+package command
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/octokerbs/chronocode/internal/domain/analysis"
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+)
+
+type CancelAnalysis struct {
+	RepoID      int64
+	AccessToken string
+}
+
+type CancelAnalysisHandler struct {
+	repoRepository  repo.Repository
+	codeHostFactory codehost.CodeHostFactory
+	cancelRegistry  analysis.CancelRegistry
+}
+
+func NewCancelAnalysisHandler(repoRepository repo.Repository, codeHostFactory codehost.CodeHostFactory, cancelRegistry analysis.CancelRegistry) CancelAnalysisHandler {
+	return CancelAnalysisHandler{repoRepository: repoRepository, codeHostFactory: codeHostFactory, cancelRegistry: cancelRegistry}
+}
+
+func (h *CancelAnalysisHandler) Handle(ctx context.Context, cmd CancelAnalysis) error {
+	slog.Info("CancelAnalysis command received", "repo_id", cmd.RepoID)
+
+	foundRepo, _, err := accessibleRepo(ctx, h.repoRepository, h.codeHostFactory, cmd.RepoID, cmd.AccessToken)
+	if err != nil {
+		return err
+	}
+
+	if err := h.cancelRegistry.Cancel(ctx, foundRepo.ID()); err != nil {
+		slog.Warn("No running analysis to cancel", "repo_id", cmd.RepoID, "error", err)
+		return err
+	}
+
+	slog.Info("CancelAnalysis command completed", "repo_id", cmd.RepoID)
+	return nil
+}
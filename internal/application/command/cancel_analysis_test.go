@@ -0,0 +1,71 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/octokerbs/chronocode/internal/adapters/memory"
+	"github.com/octokerbs/chronocode/internal/domain/analysis"
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type CancelAnalysisTestSuite struct {
+	suite.Suite
+	repoRepository  repo.Repository
+	codeHostFactory codehost.CodeHostFactory
+	cancelRegistry  analysis.CancelRegistry
+	handler         CancelAnalysisHandler
+}
+
+func TestCancelAnalysisTestSuite(t *testing.T) {
+	suite.Run(t, new(CancelAnalysisTestSuite))
+}
+
+func (s *CancelAnalysisTestSuite) SetupTest() {
+	s.repoRepository = memory.NewRepoRepository()
+	s.codeHostFactory = memory.NewCodeHostFactory()
+	s.cancelRegistry = memory.NewInMemoryCancelRegistry()
+	s.handler = NewCancelAnalysisHandler(s.repoRepository, s.codeHostFactory, s.cancelRegistry)
+
+	_ = s.repoRepository.StoreRepo(context.Background(), repo.NewRepo(memory.ValidRepoID, "chronocode", memory.ValidRepoURL, "", time.Time{}))
+	_ = s.repoRepository.StoreRepo(context.Background(), repo.NewRepo(memory.ForbiddenRepoID, "forbidden", memory.ForbiddenRepoURL, "", time.Time{}))
+}
+
+func (s *CancelAnalysisTestSuite) TestCancelsRunningAnalysis() {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	unregister := s.cancelRegistry.Register(ctx, memory.ValidRepoID, cancel)
+	defer unregister()
+
+	err := s.handler.Handle(context.Background(), CancelAnalysis{memory.ValidRepoID, memory.ValidAccessToken})
+
+	assert.Nil(s.T(), err)
+	assert.True(s.T(), errors.Is(context.Cause(ctx), analysis.ErrAnalysisCancelled))
+}
+
+func (s *CancelAnalysisTestSuite) TestCannotCancelWhenNothingIsRunning() {
+	err := s.handler.Handle(context.Background(), CancelAnalysis{memory.ValidRepoID, memory.ValidAccessToken})
+	assert.True(s.T(), errors.Is(err, analysis.ErrNoAnalysisRunning))
+}
+
+func (s *CancelAnalysisTestSuite) TestCannotCancelAnalysisOfInaccessibleRepo() {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	unregister := s.cancelRegistry.Register(ctx, memory.ForbiddenRepoID, cancel)
+	defer unregister()
+
+	err := s.handler.Handle(context.Background(), CancelAnalysis{memory.ForbiddenRepoID, memory.ValidAccessToken})
+
+	assert.True(s.T(), errors.Is(err, codehost.ErrAccessDenied))
+	assert.Nil(s.T(), ctx.Err())
+}
+
+func (s *CancelAnalysisTestSuite) TestCannotCancelAnalysisOfUnknownRepo() {
+	err := s.handler.Handle(context.Background(), CancelAnalysis{memory.ValidEmptyRepoID, memory.ValidAccessToken})
+	assert.True(s.T(), errors.Is(err, repo.ErrRepositoryNotFound))
+}
@@ -0,0 +1,635 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+	"github.com/octokerbs/chronocode/internal/domain/analysis"
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+	"github.com/octokerbs/chronocode/internal/domain/subcommit"
+)
+
+const (
+	maxConcurrentAnalyses = 10
+	// maxAutomaticAttempts bounds how many times a regular analysis retries a failing
+	// commit; RetryFailedCommits ignores it
+	maxAutomaticAttempts = 3
+)
+
+// commitSource feeds the commits a run should analyze and returns the head SHA the
+// repository advances to once the run completes, or "" to leave it untouched.
+type commitSource func(ctx context.Context, states map[string]*analysis.CommitState, commits chan<- codehost.CommitReference) (headSHA string, err error)
+
+// newAndUnfinishedCommits
+// Commits added since the last analysis, followed by those a previous run left pending
+// or failed. Commits sent twice are only analyzed once.
+func newAndUnfinishedCommits(codeHost codehost.CodeHost, targetRepo *repo.Repo) commitSource {
+	return func(ctx context.Context, states map[string]*analysis.CommitState, commits chan<- codehost.CommitReference) (string, error) {
+		headSHA, err := codeHost.GetRepoCommitSHAsIntoChannel(ctx, targetRepo, commits)
+		if err != nil {
+			return "", err
+		}
+
+		err = sendStoredCommits(ctx, states, commits, func(state *analysis.CommitState) bool {
+			return state.Status() == analysis.CommitPending ||
+				(state.Status() == analysis.CommitFailed && state.Attempts() < maxAutomaticAttempts)
+		})
+		return headSHA, err
+	}
+}
+
+func failedCommits() commitSource {
+	return func(ctx context.Context, states map[string]*analysis.CommitState, commits chan<- codehost.CommitReference) (string, error) {
+		return "", sendStoredCommits(ctx, states, commits, func(state *analysis.CommitState) bool {
+			return state.Status() == analysis.CommitFailed
+		})
+	}
+}
+
+func sendStoredCommits(ctx context.Context, states map[string]*analysis.CommitState, commits chan<- codehost.CommitReference, include func(*analysis.CommitState) bool) error {
+	for _, state := range states {
+		if !include(state) {
+			continue
+		}
+
+		select {
+		case commits <- codehost.CommitReference{SHA: state.CommitSHA(), CommittedAt: state.CommittedAt()}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+type analysisPipeline struct {
+	repoRepository        repo.Repository
+	subcommitRepository   subcommit.Repository
+	runRepository         analysis.RunRepository
+	commitStateRepository analysis.CommitStateRepository
+	agent                 agent.Agent
+	progressBus           analysis.ProgressBus
+	cancelRegistry        analysis.CancelRegistry
+	// globalBudget caps the usage of every repository together
+	globalBudget agent.Budget
+}
+
+// run
+// Analyzes and stores the commits produced by source until done or cancelled through
+// the cancel registry. Every commit outcome is recorded as its CommitState and
+// subcommits of commits that finished analysis are always stored, so failed and
+// interrupted commits are retried by later runs without walking the history again.
+// LastAnalyzedCommitSHA only advances when the source was fully consumed. Exhausting
+// the repository or global budget pauses the run: the source stops, the analyses in
+// flight finish and the remaining commits are left for a later run.
+func (p *analysisPipeline) run(ctx context.Context, codeHost codehost.CodeHost, targetRepo *repo.Repo, run *analysis.Run, source commitSource) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	unregister := p.cancelRegistry.Register(ctx, targetRepo.ID(), cancel)
+	defer unregister()
+
+	states, err := p.loadCommitStates(ctx, targetRepo.ID())
+	if err != nil {
+		p.finishRun(ctx, run, "", analysis.CommitCounts{}, agent.Usage{}, err)
+		return err
+	}
+
+	ignoreRules, err := repo.CompileIgnorePatterns(targetRepo.IgnorePatterns())
+	if err != nil {
+		slog.Error("Failed to compile repository ignore patterns", "repo_id", targetRepo.ID(), "error", err)
+		p.finishRun(ctx, run, "", analysis.CommitCounts{}, agent.Usage{}, err)
+		return err
+	}
+
+	issueMatcher, err := repo.CompileIssueTrackers(targetRepo.IssueTrackers())
+	if err != nil {
+		slog.Error("Failed to compile repository issue trackers", "repo_id", targetRepo.ID(), "error", err)
+		p.finishRun(ctx, run, "", analysis.CommitCounts{}, agent.Usage{}, err)
+		return err
+	}
+
+	// Pausing only stops the source, so analyses in flight are not wasted
+	fetchCtx, pause := context.WithCancelCause(ctx)
+	defer pause(nil)
+
+	guard, err := p.newBudgetGuard(ctx, targetRepo, pause)
+	if err != nil {
+		p.finishRun(ctx, run, "", analysis.CommitCounts{}, agent.Usage{}, err)
+		return err
+	}
+
+	var wg sync.WaitGroup
+	commitRefs := make(chan codehost.CommitReference, 100)
+	subcommits := make(chan subcommitBatch, maxConcurrentAnalyses)
+
+	var fetchErr, analysisErr, storageErr, cancelErr, pausedErr error
+	var headSHA string
+	var counts analysis.CommitCounts
+	wg.Add(3)
+
+	slog.Info("Starting analysis pipeline", "repo_id", targetRepo.ID(), "repo_url", targetRepo.URL(), "run_id", run.ID(), "known_commits", len(states))
+
+	go func() {
+		defer wg.Done()
+		defer close(commitRefs)
+		headSHA, fetchErr = source(fetchCtx, states, commitRefs)
+		if fetchErr != nil && !errors.Is(context.Cause(fetchCtx), analysis.ErrBudgetExceeded) {
+			slog.Error("Commit fetch pipeline failed", "repo_id", targetRepo.ID(), "error", fetchErr)
+			cancel(fetchErr)
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		defer close(subcommits)
+		counts, analysisErr = p.analyzeCommits(ctx, codeHost, targetRepo, ignoreRules, issueMatcher, guard, run, states, commitRefs, subcommits)
+	}()
+
+	go func() {
+		defer wg.Done()
+		storageErr = p.storeSubcommits(context.WithoutCancel(ctx), subcommits)
+		if storageErr != nil {
+			slog.Error("Subcommit storage pipeline failed", "repo_id", targetRepo.ID(), "error", storageErr)
+		}
+	}()
+
+	wg.Wait()
+
+	if errors.Is(context.Cause(ctx), analysis.ErrAnalysisCancelled) {
+		cancelErr = analysis.ErrAnalysisCancelled
+		if errors.Is(fetchErr, context.Canceled) {
+			fetchErr = nil
+		}
+	} else if errors.Is(context.Cause(fetchCtx), analysis.ErrBudgetExceeded) {
+		pausedErr = analysis.ErrBudgetExceeded
+		if errors.Is(fetchErr, context.Canceled) {
+			fetchErr = nil
+		}
+	}
+
+	p.finishRun(ctx, run, headSHA, counts, guard.usage(), errors.Join(fetchErr, analysisErr, storageErr, cancelErr, pausedErr))
+
+	if fetchErr != nil {
+		slog.Error("Analysis pipeline failed during fetch", "repo_id", targetRepo.ID(), "error", fetchErr)
+		return fetchErr
+	}
+
+	if cancelErr == nil && pausedErr == nil && storageErr == nil && headSHA != "" {
+		slog.Info("All commits processed, updating last analyzed SHA", "repo_id", targetRepo.ID(), "head_sha", headSHA, "failed", counts.Failed)
+		targetRepo.SetLastAnalyzedCommitSHA(headSHA)
+	} else if cancelErr != nil {
+		slog.Warn("Analysis cancelled, not updating last analyzed SHA", "repo_id", targetRepo.ID(), "analyzed", counts.Analyzed)
+	} else if pausedErr != nil {
+		slog.Warn("Analysis paused by budget, not updating last analyzed SHA", "repo_id", targetRepo.ID(), "analyzed", counts.Analyzed)
+	} else if storageErr != nil {
+		slog.Warn("Subcommit storage failed, not updating last analyzed SHA", "repo_id", targetRepo.ID(), "error", storageErr)
+	}
+
+	if err := p.repoRepository.StoreRepo(context.WithoutCancel(ctx), targetRepo); err != nil {
+		slog.Error("Failed to store repository after analysis", "repo_id", targetRepo.ID(), "error", err)
+		return err
+	}
+
+	slog.Info("Analysis pipeline completed", "repo_id", targetRepo.ID(), "head_sha", headSHA)
+	return errors.Join(analysisErr, storageErr, cancelErr, pausedErr)
+}
+
+func (p *analysisPipeline) loadCommitStates(ctx context.Context, repoID int64) (map[string]*analysis.CommitState, error) {
+	stored, err := p.commitStateRepository.ListCommitStates(ctx, repoID)
+	if err != nil {
+		slog.Error("Failed to load commit analysis states", "repo_id", repoID, "error", err)
+		return nil, err
+	}
+
+	states := make(map[string]*analysis.CommitState, len(stored))
+	for _, state := range stored {
+		states[state.CommitSHA()] = state
+	}
+	return states, nil
+}
+
+func (p *analysisPipeline) startRun(ctx context.Context, r *repo.Repo) (*analysis.Run, error) {
+	run := analysis.NewRun(r.ID(), time.Now())
+	if err := p.runRepository.StoreRun(ctx, run); err != nil {
+		slog.Error("Failed to record analysis run start", "repo_id", r.ID(), "error", err)
+		return nil, err
+	}
+
+	slog.Info("Analysis run started", "run_id", run.ID(), "repo_id", r.ID())
+	return run, nil
+}
+
+// finishRun
+// Stores the outcome even if ctx was cancelled, otherwise the run would stay RUNNING forever
+func (p *analysisPipeline) finishRun(ctx context.Context, run *analysis.Run, headSHA string, counts analysis.CommitCounts, usage agent.Usage, runErr error) {
+	ctx = context.WithoutCancel(ctx)
+	run.Finish(headSHA, counts, usage, runErr, time.Now())
+	p.publishProgress(ctx, run, analysis.EventRunFinished, "", counts, runErr)
+
+	if err := p.runRepository.StoreRun(ctx, run); err != nil {
+		slog.Error("Failed to record analysis run outcome", "run_id", run.ID(), "repo_id", run.RepoID(), "error", err)
+		return
+	}
+
+	slog.Info("Analysis run finished", "run_id", run.ID(), "repo_id", run.RepoID(), "status", run.Status(),
+		"total_commits", counts.Total, "analyzed", counts.Analyzed, "skipped", counts.Skipped, "failed", counts.Failed, "cached", counts.Cached,
+		"total_tokens", usage.TotalTokens(), "cost_usd", usage.CostUSD)
+}
+
+func (p *analysisPipeline) publishProgress(ctx context.Context, run *analysis.Run, eventType analysis.EventType, commitSHA string, counts analysis.CommitCounts, err error) {
+	event := analysis.ProgressEvent{
+		Type:       eventType,
+		RepoID:     run.RepoID(),
+		RunID:      run.ID(),
+		CommitSHA:  commitSHA,
+		Counts:     counts,
+		OccurredAt: time.Now(),
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	p.progressBus.Publish(ctx, event)
+}
+
+func (p *analysisPipeline) storeCommitState(ctx context.Context, state *analysis.CommitState) error {
+	if err := p.commitStateRepository.StoreCommitState(context.WithoutCancel(ctx), state); err != nil {
+		slog.Error("Failed to store commit analysis state", "repo_id", state.RepoID(), "commit_sha", state.CommitSHA(), "status", state.Status(), "error", err)
+		return err
+	}
+	return nil
+}
+
+func (p *analysisPipeline) analyzeCommits(ctx context.Context, codeHost codehost.CodeHost, r *repo.Repo, ignoreRules *repo.IgnoreRules, issueMatcher *repo.IssueMatcher, guard *budgetGuard, run *analysis.Run, states map[string]*analysis.CommitState, commitRefs <-chan codehost.CommitReference, subcommits chan<- subcommitBatch) (analysis.CommitCounts, error) {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+	sem := make(chan struct{}, maxConcurrentAnalyses)
+	seen := make(map[string]bool)
+
+	var totalCommits, analyzedCommits, skippedCommits, failedCommits, cachedCommits atomic.Int64
+	snapshot := func() analysis.CommitCounts {
+		return analysis.CommitCounts{
+			Total:    totalCommits.Load(),
+			Analyzed: analyzedCommits.Load(),
+			Skipped:  skippedCommits.Load(),
+			Failed:   failedCommits.Load(),
+			Cached:   cachedCommits.Load(),
+		}
+	}
+	recordErr := func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	}
+
+	for ref := range commitRefs {
+		// Keep draining so the fetcher is never left blocked on a full channel
+		if ctx.Err() != nil || seen[ref.SHA] || !guard.allow() {
+			continue
+		}
+		seen[ref.SHA] = true
+
+		totalCommits.Add(1)
+		p.publishProgress(ctx, run, analysis.EventCommitFetched, ref.SHA, snapshot(), nil)
+
+		state := states[ref.SHA]
+		if state != nil && state.IsFinished() {
+			skippedCommits.Add(1)
+			p.publishProgress(ctx, run, analysis.EventCommitSkipped, ref.SHA, snapshot(), nil)
+			slog.Debug("Commit already finished, skipping", "repo_id", r.ID(), "commit_sha", ref.SHA, "status", state.Status())
+			continue
+		}
+
+		// The source may still be reading states, so the analysis works on its own copy
+		if state != nil {
+			state = new(analysis.CommitState)
+			*state = *states[ref.SHA]
+		}
+
+		sem <- struct{}{}
+
+		wg.Add(1)
+		go func(ref codehost.CommitReference, state *analysis.CommitState) {
+			defer func() { <-sem }()
+			defer wg.Done()
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			// Guards against duplicates when subcommits were stored but the state was not,
+			// and covers commits analyzed before states were recorded
+			alreadyAnalyzed, err := p.subcommitRepository.HasSubcommitsForCommit(ctx, r.ID(), ref.SHA)
+			if interrupted(ctx, err) {
+				return
+			}
+			if err != nil {
+				failedCommits.Add(1)
+				p.publishProgress(ctx, run, analysis.EventCommitFailed, ref.SHA, snapshot(), err)
+				recordErr(err)
+				return
+			}
+
+			isNew := state == nil
+			if isNew {
+				state = analysis.NewPendingCommitState(r.ID(), ref.SHA, ref.CommittedAt, time.Now())
+			}
+
+			if alreadyAnalyzed {
+				skippedCommits.Add(1)
+				state.MarkAnalyzed(time.Now())
+				if err := p.storeCommitState(ctx, state); err != nil {
+					recordErr(err)
+				}
+				p.publishProgress(ctx, run, analysis.EventCommitSkipped, ref.SHA, snapshot(), nil)
+				slog.Debug("Commit already analyzed, skipping", "repo_id", r.ID(), "commit_sha", ref.SHA)
+				return
+			}
+
+			if isNew {
+				if err := p.storeCommitState(ctx, state); err != nil {
+					recordErr(err)
+				}
+			}
+
+			fail := func(err error) {
+				failedCommits.Add(1)
+				state.MarkFailed(err, time.Now())
+				if storeErr := p.storeCommitState(ctx, state); storeErr != nil {
+					recordErr(storeErr)
+				}
+				p.publishProgress(ctx, run, analysis.EventCommitFailed, ref.SHA, snapshot(), err)
+				recordErr(err)
+			}
+
+			slog.Debug("Analyzing commit", "repo_id", r.ID(), "commit_sha", ref.SHA, "attempt", state.Attempts()+1)
+
+			commitDiff, err := codeHost.GetCommitDiff(ctx, r, ref.SHA)
+			if interrupted(ctx, err) {
+				return
+			}
+			if err != nil {
+				slog.Error("Failed to fetch commit diff", "repo_id", r.ID(), "commit_sha", ref.SHA, "error", err)
+				fail(fmt.Errorf("%w: %v", codehost.ErrDiffFetchFailed, err))
+				return
+			}
+
+			diff, ignoredFiles, keptFiles := filterIgnoredFiles(commitDiff.Text, ignoreRules)
+			if ignoredFiles > 0 && keptFiles == 0 {
+				skippedCommits.Add(1)
+				state.MarkSkipped(time.Now())
+				if err := p.storeCommitState(ctx, state); err != nil {
+					recordErr(err)
+				}
+				p.publishProgress(ctx, run, analysis.EventCommitSkipped, ref.SHA, snapshot(), nil)
+				slog.Debug("Commit only touches ignored files, skipping", "repo_id", r.ID(), "commit_sha", ref.SHA, "ignored_files", ignoredFiles)
+				return
+			}
+
+			// Commits dispatched before the budget ran out still wait for their turn here,
+			// and concurrent runs may have spent it since
+			guard.refresh(ctx)
+			if !guard.allow() {
+				return
+			}
+
+			// A commit whose pull request can't be resolved is still analyzed, just without it
+			pullRequest, err := codeHost.GetCommitPullRequest(ctx, r, ref.SHA)
+			if interrupted(ctx, err) {
+				return
+			}
+			if err != nil {
+				slog.Warn("Failed to fetch commit pull request", "repo_id", r.ID(), "commit_sha", ref.SHA, "error", err)
+			}
+
+			meteredCtx, meter := agent.WithUsageMeter(agent.WithPromptOverrides(ctx, r.AnalysisPrompt()))
+			commit := describeCommit(ref, commitDiff)
+			results, err := p.agent.AnalyzeCommit(meteredCtx, analysisInput(commit, commitDiff.Files, pullRequest, diff, ignoreRules))
+			usage := meter.Usage()
+			state.AddUsage(usage)
+			guard.add(usage)
+			if interrupted(ctx, err) {
+				return
+			}
+			if err != nil {
+				slog.Error("Agent failed to analyze commit diff", "repo_id", r.ID(), "commit_sha", ref.SHA, "error", err)
+				fail(fmt.Errorf("%w: %v", agent.ErrAnalysisFailed, err))
+				return
+			}
+
+			author := subcommit.Author{Name: commit.Author.Name, Email: commit.Author.Email, Login: commit.Author.Login, AvatarURL: commit.Author.AvatarURL}
+			mergedThrough := subcommitPullRequest(pullRequest)
+			issues := commitIssues(issueMatcher, r.URL(), commit.Message, pullRequest)
+			batch := subcommitBatch{stored: make(chan error, 1)}
+			for _, result := range results {
+				batch.subcommits = append(batch.subcommits, subcommit.NewSubcommit(result.Title, result.Idea, result.Description, result.Epic, result.ModificationType, ref.SHA, result.Files, r.ID(), ref.CommittedAt, result.Agent, result.PromptVersion, author, fileStatsFor(result.Files, commitDiff.Files), mergedThrough, issues))
+			}
+
+			// The commit only counts as analyzed once its subcommits are stored, otherwise
+			// a failed write would leave it ANALYZED without a timeline
+			if len(batch.subcommits) > 0 {
+				subcommits <- batch
+				if err := <-batch.stored; err != nil {
+					failedCommits.Add(1)
+					state.MarkFailed(err, time.Now())
+					if storeErr := p.storeCommitState(ctx, state); storeErr != nil {
+						recordErr(storeErr)
+					}
+					p.publishProgress(ctx, run, analysis.EventCommitFailed, ref.SHA, snapshot(), err)
+					return
+				}
+			}
+
+			if len(results) > 0 && results[0].Cached {
+				cachedCommits.Add(1)
+			}
+			analyzedCommits.Add(1)
+			p.publishProgress(ctx, run, analysis.EventCommitAnalyzed, ref.SHA, snapshot(), nil)
+			slog.Debug("Commit analyzed", "repo_id", r.ID(), "commit_sha", ref.SHA, "subcommits_produced", len(results))
+
+			state.MarkAnalyzed(time.Now())
+			if err := p.storeCommitState(ctx, state); err != nil {
+				recordErr(err)
+			}
+		}(ref, state)
+	}
+
+	wg.Wait()
+
+	counts := snapshot()
+	slog.Info("Commit analysis pipeline completed",
+		"repo_id", r.ID(),
+		"total_commits", counts.Total,
+		"analyzed", counts.Analyzed,
+		"skipped", counts.Skipped,
+		"failed", counts.Failed,
+		"cached", counts.Cached,
+	)
+
+	return counts, errors.Join(errs...)
+}
+
+// subcommitBatch
+// The subcommits of one analyzed commit, stored reports the outcome of their write
+type subcommitBatch struct {
+	subcommits []subcommit.Subcommit
+	stored     chan error
+}
+
+// storeSubcommits
+// Stores every batch until the analyses are done. A failed batch doesn't stop the
+// following ones, the analyses waiting on them would otherwise block forever
+func (p *analysisPipeline) storeSubcommits(ctx context.Context, batches <-chan subcommitBatch) error {
+	var errs []error
+	for batch := range batches {
+		subcommits := make(chan subcommit.Subcommit, len(batch.subcommits))
+		for _, sc := range batch.subcommits {
+			subcommits <- sc
+		}
+		close(subcommits)
+
+		err := p.subcommitRepository.StoreSubcommits(ctx, subcommits)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		batch.stored <- err
+	}
+	return errors.Join(errs...)
+}
+
+// filterIgnoredFiles
+// Drops the sections of ignored files from the diff before it reaches the agent
+func filterIgnoredFiles(diff string, ignoreRules *repo.IgnoreRules) (string, int, int) {
+	preamble, files := codehost.SplitDiff(diff)
+
+	var ignored int
+	var filtered strings.Builder
+	filtered.WriteString(preamble)
+	for _, file := range files {
+		if ignoreRules.Ignores(file.Path) {
+			ignored++
+			continue
+		}
+		filtered.WriteString(file.Text)
+	}
+
+	if ignored == 0 {
+		return diff, 0, len(files)
+	}
+	return filtered.String(), ignored, len(files) - ignored
+}
+
+// describeCommit
+// The commit as the diff endpoint reports it, falling back to what the listing sent
+// for hosts that return less. The SHA and date are those the pipeline tracks.
+func describeCommit(ref codehost.CommitReference, commitDiff *codehost.CommitDiff) codehost.CommitReference {
+	commit := commitDiff.CommitReference
+	commit.SHA, commit.CommittedAt = ref.SHA, ref.CommittedAt
+	if commit.Message == "" {
+		commit.Message = ref.Message
+	}
+	if commit.Author == (codehost.CommitAuthor{}) {
+		commit.Author = ref.Author
+	}
+	if commit.Parents == nil {
+		commit.Parents = ref.Parents
+	}
+	return commit
+}
+
+// analysisInput
+// What the agent is told about a commit: the filtered diff, the commit, the pull request
+// it was merged through and the stats of the files that are not ignored
+func analysisInput(commit codehost.CommitReference, files []codehost.FileStat, pullRequest *codehost.PullRequest, diff string, ignoreRules *repo.IgnoreRules) agent.AnalysisInput {
+	input := agent.AnalysisInput{Diff: diff, SHA: commit.SHA, Message: commit.Message, Author: commit.Author.String(), Parents: commit.Parents}
+	if pullRequest != nil {
+		input.PullRequest = &agent.PullRequest{Number: pullRequest.Number, Title: pullRequest.Title, Body: pullRequest.Body, URL: pullRequest.URL}
+	}
+	for _, file := range files {
+		if ignoreRules.Ignores(file.Path) {
+			continue
+		}
+		input.Files = append(input.Files, agent.ChangedFile{
+			Path: file.Path, PreviousPath: file.PreviousPath, Status: string(file.Status), Additions: file.Additions, Deletions: file.Deletions,
+		})
+	}
+	return input
+}
+
+// subcommitPullRequest
+// The part of the pull request kept on the subcommits of its commit
+func subcommitPullRequest(pullRequest *codehost.PullRequest) *subcommit.PullRequest {
+	if pullRequest == nil {
+		return nil
+	}
+	return &subcommit.PullRequest{Number: pullRequest.Number, Title: pullRequest.Title, URL: pullRequest.URL, MergedAt: pullRequest.MergedAt}
+}
+
+// commitIssues
+// The issues the commit message mentions, then those of its pull request that it does not
+func commitIssues(issueMatcher *repo.IssueMatcher, repoURL, message string, pullRequest *codehost.PullRequest) []subcommit.Issue {
+	texts := []string{message}
+	if pullRequest != nil {
+		texts = append(texts, pullRequest.Title, pullRequest.Body)
+	}
+
+	var issues []subcommit.Issue
+	for _, reference := range issueMatcher.References(repoURL, texts...) {
+		issues = append(issues, subcommit.Issue(reference))
+	}
+	return issues
+}
+
+// fileStatsFor
+// The stats of the files a subcommit names. Agents sometimes shorten paths to file
+// names, so a name that matches no path is looked up as the last element of one, as
+// long as that is unambiguous.
+func fileStatsFor(files []string, stats []codehost.FileStat) []subcommit.FileStat {
+	var matched []subcommit.FileStat
+	seen := make(map[string]bool)
+	for _, file := range files {
+		stat, ok := findFileStat(file, stats)
+		if !ok || seen[stat.Path] {
+			continue
+		}
+		seen[stat.Path] = true
+		matched = append(matched, subcommit.FileStat{
+			Path: stat.Path, PreviousPath: stat.PreviousPath, Status: string(stat.Status), Additions: stat.Additions, Deletions: stat.Deletions,
+		})
+	}
+	return matched
+}
+
+func findFileStat(file string, stats []codehost.FileStat) (codehost.FileStat, bool) {
+	file = strings.TrimPrefix(strings.TrimSpace(file), "/")
+	for _, stat := range stats {
+		if stat.Path == file {
+			return stat, true
+		}
+	}
+
+	var found codehost.FileStat
+	var matches int
+	for _, stat := range stats {
+		if strings.HasSuffix(stat.Path, "/"+file) {
+			found = stat
+			matches++
+		}
+	}
+	return found, matches == 1
+}
+
+// interrupted
+// Errors caused by the pipeline being cancelled are not commit failures: the commit
+// stays pending and is picked up again by the next run
+func interrupted(ctx context.Context, err error) bool {
+	return err != nil && ctx.Err() != nil
+}
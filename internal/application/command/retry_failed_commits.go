@@ -0,0 +1,115 @@
+package command
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+	"github.com/octokerbs/chronocode/internal/domain/analysis"
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+	"github.com/octokerbs/chronocode/internal/domain/subcommit"
+)
+
+type RetryFailedCommits struct {
+	RepoID      int64
+	AccessToken string
+}
+
+type RetryFailedCommitsHandler struct {
+	repoRepository        repo.Repository
+	commitStateRepository analysis.CommitStateRepository
+	codeHostFactory       codehost.CodeHostFactory
+	locker                analysis.Locker
+	pipeline              analysisPipeline
+}
+
+func NewRetryFailedCommitsHandler(repoRepository repo.Repository, subcommitRepository subcommit.Repository, runRepository analysis.RunRepository, commitStateRepository analysis.CommitStateRepository, agent agent.Agent, codeHostFactory codehost.CodeHostFactory, locker analysis.Locker, progressBus analysis.ProgressBus, cancelRegistry analysis.CancelRegistry, globalBudget agent.Budget) RetryFailedCommitsHandler {
+	return RetryFailedCommitsHandler{
+		repoRepository:        repoRepository,
+		commitStateRepository: commitStateRepository,
+		codeHostFactory:       codeHostFactory,
+		locker:                locker,
+		pipeline: analysisPipeline{
+			repoRepository:        repoRepository,
+			subcommitRepository:   subcommitRepository,
+			runRepository:         runRepository,
+			commitStateRepository: commitStateRepository,
+			agent:                 agent,
+			progressBus:           progressBus,
+			cancelRegistry:        cancelRegistry,
+			globalBudget:          globalBudget,
+		},
+	}
+}
+
+// Handle
+// Re-analyzes only the commits whose last attempt failed, regardless of how many
+// attempts they already had. Returns the ID of the run.
+func (h *RetryFailedCommitsHandler) Handle(ctx context.Context, cmd RetryFailedCommits) (int64, error) {
+	slog.Info("RetryFailedCommits command received", "repo_id", cmd.RepoID)
+
+	prepared, err := h.prepare(ctx, cmd)
+	if err != nil {
+		return 0, err
+	}
+	defer prepared.release()
+
+	if err := h.pipeline.run(ctx, prepared.codeHost, prepared.repo, prepared.run, failedCommits()); err != nil {
+		return prepared.run.ID(), err
+	}
+
+	slog.Info("RetryFailedCommits command completed", "repo_id", cmd.RepoID, "run_id", prepared.run.ID())
+	return prepared.run.ID(), nil
+}
+
+func (h *RetryFailedCommitsHandler) HandleAsync(ctx context.Context, cmd RetryFailedCommits) (int64, error) {
+	slog.Info("RetryFailedCommits async command received", "repo_id", cmd.RepoID)
+
+	prepared, err := h.prepare(ctx, cmd)
+	if err != nil {
+		return 0, err
+	}
+
+	go func() {
+		defer prepared.release()
+		_ = h.pipeline.run(context.Background(), prepared.codeHost, prepared.repo, prepared.run, failedCommits())
+	}()
+
+	slog.Info("RetryFailedCommits async command returning immediately", "repo_id", cmd.RepoID, "run_id", prepared.run.ID())
+	return prepared.run.ID(), nil
+}
+
+func (h *RetryFailedCommitsHandler) prepare(ctx context.Context, cmd RetryFailedCommits) (*preparedAnalysis, error) {
+	foundRepo, codeHost, err := accessibleRepo(ctx, h.repoRepository, h.codeHostFactory, cmd.RepoID, cmd.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	release, err := h.locker.Acquire(ctx, foundRepo.URL())
+	if err != nil {
+		slog.Warn("Failed to acquire analysis lock - analysis already in progress", "repo_id", cmd.RepoID)
+		return nil, err
+	}
+
+	failed, err := h.commitStateRepository.ListCommitStates(ctx, foundRepo.ID(), analysis.CommitFailed)
+	if err != nil {
+		slog.Error("Failed to list failed commits", "repo_id", cmd.RepoID, "error", err)
+		release()
+		return nil, err
+	}
+	if len(failed) == 0 {
+		slog.Info("No failed commits to retry", "repo_id", cmd.RepoID)
+		release()
+		return nil, analysis.ErrNoFailedCommits
+	}
+
+	run, err := h.pipeline.startRun(ctx, foundRepo)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	slog.Info("Retrying failed commits", "repo_id", cmd.RepoID, "run_id", run.ID(), "failed_commits", len(failed))
+	return &preparedAnalysis{codeHost: codeHost, repo: foundRepo, run: run, release: release}, nil
+}
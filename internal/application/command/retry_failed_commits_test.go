@@ -0,0 +1,102 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/octokerbs/chronocode/internal/adapters/memory"
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+	"github.com/octokerbs/chronocode/internal/domain/analysis"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+	"github.com/octokerbs/chronocode/internal/domain/subcommit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type RetryFailedCommitsTestSuite struct {
+	suite.Suite
+	repoRepository      repo.Repository
+	subcommitRepository subcommit.Repository
+	runRepository       analysis.RunRepository
+	commitStates        analysis.CommitStateRepository
+	locker              analysis.Locker
+	analyzeHandler      AnalyzeRepoHandler
+	handler             RetryFailedCommitsHandler
+}
+
+func TestRetryFailedCommitsTestSuite(t *testing.T) {
+	suite.Run(t, new(RetryFailedCommitsTestSuite))
+}
+
+func (s *RetryFailedCommitsTestSuite) SetupTest() {
+	s.repoRepository = memory.NewRepoRepository()
+	s.subcommitRepository = memory.NewSubcommitRepository()
+	s.runRepository = memory.NewAnalysisRunRepository()
+	s.commitStates = memory.NewCommitStateRepository()
+	s.locker = memory.NewInMemoryLocker()
+	memoryAgent := memory.NewAgent()
+	codeHostFactory := memory.NewCodeHostFactory()
+	progressBus := memory.NewInMemoryProgressBus()
+	cancelRegistry := memory.NewInMemoryCancelRegistry()
+
+	s.analyzeHandler = NewAnalyzeRepoHandler(s.repoRepository, s.subcommitRepository, s.runRepository, s.commitStates, memoryAgent, codeHostFactory, s.locker, progressBus, cancelRegistry, agent.Budget{})
+	s.handler = NewRetryFailedCommitsHandler(s.repoRepository, s.subcommitRepository, s.runRepository, s.commitStates, memoryAgent, codeHostFactory, s.locker, progressBus, cancelRegistry, agent.Budget{})
+}
+
+func (s *RetryFailedCommitsTestSuite) TestCannotRetryUnknownRepo() {
+	_, err := s.handler.Handle(context.Background(), RetryFailedCommits{memory.ValidRepoID, memory.ValidAccessToken})
+	assert.True(s.T(), errors.Is(err, repo.ErrRepositoryNotFound))
+}
+
+func (s *RetryFailedCommitsTestSuite) TestCannotRetryWithoutAccessToken() {
+	_, _ = s.analyzeHandler.Handle(context.Background(), AnalyzeRepo{memory.PartialFailureRepoURL, memory.ValidAccessToken})
+	_, err := s.handler.Handle(context.Background(), RetryFailedCommits{memory.PartialFailureRepoID, ""})
+	assert.NotNil(s.T(), err)
+}
+
+func (s *RetryFailedCommitsTestSuite) TestNothingToRetryWhenNoCommitFailed() {
+	_, _ = s.analyzeHandler.Handle(context.Background(), AnalyzeRepo{memory.ValidRepoURL, memory.ValidAccessToken})
+	_, err := s.handler.Handle(context.Background(), RetryFailedCommits{memory.ValidRepoID, memory.ValidAccessToken})
+	runs, _ := s.runRepository.ListRuns(context.Background(), memory.ValidRepoID)
+
+	assert.True(s.T(), errors.Is(err, analysis.ErrNoFailedCommits))
+	assert.Len(s.T(), runs, 1)
+}
+
+func (s *RetryFailedCommitsTestSuite) TestRetryAnalyzesOnlyFailedCommits() {
+	_, _ = s.analyzeHandler.Handle(context.Background(), AnalyzeRepo{memory.PartialFailureRepoURL, memory.ValidAccessToken})
+	runID, err := s.handler.Handle(context.Background(), RetryFailedCommits{memory.PartialFailureRepoID, memory.ValidAccessToken})
+	run, _ := s.runRepository.GetRun(context.Background(), runID)
+
+	assert.True(s.T(), errors.Is(err, agent.ErrAnalysisFailed))
+	assert.Equal(s.T(), analysis.CommitCounts{Total: 1, Failed: 1}, run.Counts())
+}
+
+func (s *RetryFailedCommitsTestSuite) TestRetryIgnoresAutomaticAttemptLimit() {
+	for i := 0; i < maxAutomaticAttempts; i++ {
+		_, _ = s.analyzeHandler.Handle(context.Background(), AnalyzeRepo{memory.PartialFailureRepoURL, memory.ValidAccessToken})
+	}
+	_, _ = s.handler.Handle(context.Background(), RetryFailedCommits{memory.PartialFailureRepoID, memory.ValidAccessToken})
+	failed, _ := s.commitStates.ListCommitStates(context.Background(), memory.PartialFailureRepoID, analysis.CommitFailed)
+
+	assert.Len(s.T(), failed, 1)
+	assert.Equal(s.T(), maxAutomaticAttempts+1, failed[0].Attempts())
+}
+
+func (s *RetryFailedCommitsTestSuite) TestRetryDoesNotChangeLastAnalyzedSHA() {
+	_, _ = s.analyzeHandler.Handle(context.Background(), AnalyzeRepo{memory.PartialFailureRepoURL, memory.ValidAccessToken})
+	_, _ = s.handler.Handle(context.Background(), RetryFailedCommits{memory.PartialFailureRepoID, memory.ValidAccessToken})
+	r, _ := s.repoRepository.GetRepoByID(context.Background(), memory.PartialFailureRepoID)
+
+	assert.Equal(s.T(), memory.ValidRepoCommitSHA, r.LastAnalyzedCommitSHA())
+}
+
+func (s *RetryFailedCommitsTestSuite) TestCannotRetryWhileAnalysisInProgress() {
+	_, _ = s.analyzeHandler.Handle(context.Background(), AnalyzeRepo{memory.PartialFailureRepoURL, memory.ValidAccessToken})
+	release, _ := s.locker.Acquire(context.Background(), memory.PartialFailureRepoURL)
+	defer release()
+
+	_, err := s.handler.Handle(context.Background(), RetryFailedCommits{memory.PartialFailureRepoID, memory.ValidAccessToken})
+	assert.True(s.T(), errors.Is(err, analysis.ErrAnalysisInProgress))
+}
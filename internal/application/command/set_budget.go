@@ -0,0 +1,51 @@
+package command
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+)
+
+// SetBudget
+// Replaces the usage budget of a repository, a zero Budget removes it. Takes effect
+// from the next analysis run.
+type SetBudget struct {
+	RepoID      int64
+	Budget      agent.Budget
+	AccessToken string
+}
+
+type SetBudgetHandler struct {
+	repoRepository  repo.Repository
+	codeHostFactory codehost.CodeHostFactory
+}
+
+func NewSetBudgetHandler(repoRepository repo.Repository, codeHostFactory codehost.CodeHostFactory) SetBudgetHandler {
+	return SetBudgetHandler{repoRepository: repoRepository, codeHostFactory: codeHostFactory}
+}
+
+func (h *SetBudgetHandler) Handle(ctx context.Context, cmd SetBudget) (*repo.Repo, error) {
+	slog.Info("SetBudget command received", "repo_id", cmd.RepoID, "max_tokens", cmd.Budget.MaxTokens, "max_cost_usd", cmd.Budget.MaxCostUSD)
+
+	if err := cmd.Budget.Validate(); err != nil {
+		slog.Warn("Rejected invalid budget", "repo_id", cmd.RepoID, "error", err)
+		return nil, err
+	}
+
+	foundRepo, _, err := accessibleRepo(ctx, h.repoRepository, h.codeHostFactory, cmd.RepoID, cmd.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.repoRepository.UpdateBudget(ctx, foundRepo.ID(), cmd.Budget); err != nil {
+		slog.Error("Failed to store budget", "repo_id", cmd.RepoID, "error", err)
+		return nil, err
+	}
+	foundRepo.SetBudget(cmd.Budget)
+
+	slog.Info("SetBudget command completed", "repo_id", cmd.RepoID, "unlimited", cmd.Budget.IsZero())
+	return foundRepo, nil
+}
@@ -0,0 +1,70 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/octokerbs/chronocode/internal/adapters/memory"
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type SetBudgetTestSuite struct {
+	suite.Suite
+	repoRepository  repo.Repository
+	codeHostFactory codehost.CodeHostFactory
+	handler         SetBudgetHandler
+}
+
+func TestSetBudgetTestSuite(t *testing.T) {
+	suite.Run(t, new(SetBudgetTestSuite))
+}
+
+func (s *SetBudgetTestSuite) SetupTest() {
+	s.repoRepository, s.codeHostFactory = newStoredReposFixture()
+	s.handler = NewSetBudgetHandler(s.repoRepository, s.codeHostFactory)
+}
+
+func (s *SetBudgetTestSuite) TestRepositoriesStartWithoutBudget() {
+	r, _ := s.repoRepository.GetRepoByID(context.Background(), memory.ValidRepoID)
+	assert.True(s.T(), r.Budget().IsZero())
+}
+
+func (s *SetBudgetTestSuite) TestStoresBudget() {
+	budget := agent.Budget{MaxTokens: 1_000_000, MaxCostUSD: 5}
+	updated, err := s.handler.Handle(context.Background(), SetBudget{memory.ValidRepoID, budget, memory.ValidAccessToken})
+	stored, _ := s.repoRepository.GetRepoByID(context.Background(), memory.ValidRepoID)
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), budget, updated.Budget())
+	assert.Equal(s.T(), budget, stored.Budget())
+}
+
+func (s *SetBudgetTestSuite) TestZeroBudgetRemovesLimits() {
+	_, _ = s.handler.Handle(context.Background(), SetBudget{memory.ValidRepoID, agent.Budget{MaxTokens: 1000}, memory.ValidAccessToken})
+	_, err := s.handler.Handle(context.Background(), SetBudget{memory.ValidRepoID, agent.Budget{}, memory.ValidAccessToken})
+	stored, _ := s.repoRepository.GetRepoByID(context.Background(), memory.ValidRepoID)
+
+	assert.Nil(s.T(), err)
+	assert.True(s.T(), stored.Budget().IsZero())
+}
+
+func (s *SetBudgetTestSuite) TestNegativeBudgetIsRejected() {
+	_, err := s.handler.Handle(context.Background(), SetBudget{memory.ValidRepoID, agent.Budget{MaxCostUSD: -1}, memory.ValidAccessToken})
+	stored, _ := s.repoRepository.GetRepoByID(context.Background(), memory.ValidRepoID)
+
+	assert.True(s.T(), errors.Is(err, agent.ErrInvalidBudget))
+	assert.True(s.T(), stored.Budget().IsZero())
+}
+
+func (s *SetBudgetTestSuite) TestCannotChangeBudgetOfInaccessibleRepo() {
+	_, err := s.handler.Handle(context.Background(), SetBudget{memory.ForbiddenRepoID, agent.Budget{MaxTokens: 1000}, memory.ValidAccessToken})
+	stored, _ := s.repoRepository.GetRepoByID(context.Background(), memory.ForbiddenRepoID)
+
+	assert.True(s.T(), errors.Is(err, codehost.ErrAccessDenied))
+	assert.True(s.T(), stored.Budget().IsZero())
+}
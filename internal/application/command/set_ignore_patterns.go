@@ -0,0 +1,50 @@
+package command
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+)
+
+// SetIgnorePatterns
+// Replaces the ignore patterns of a repository, nil Patterns restores the defaults.
+// Takes effect from the next analysis run.
+type SetIgnorePatterns struct {
+	RepoID      int64
+	Patterns    []string
+	AccessToken string
+}
+
+type SetIgnorePatternsHandler struct {
+	repoRepository  repo.Repository
+	codeHostFactory codehost.CodeHostFactory
+}
+
+func NewSetIgnorePatternsHandler(repoRepository repo.Repository, codeHostFactory codehost.CodeHostFactory) SetIgnorePatternsHandler {
+	return SetIgnorePatternsHandler{repoRepository: repoRepository, codeHostFactory: codeHostFactory}
+}
+
+func (h *SetIgnorePatternsHandler) Handle(ctx context.Context, cmd SetIgnorePatterns) (*repo.Repo, error) {
+	slog.Info("SetIgnorePatterns command received", "repo_id", cmd.RepoID, "patterns", len(cmd.Patterns), "defaults", cmd.Patterns == nil)
+
+	if _, err := repo.CompileIgnorePatterns(cmd.Patterns); err != nil {
+		slog.Warn("Rejected invalid ignore patterns", "repo_id", cmd.RepoID, "error", err)
+		return nil, err
+	}
+
+	foundRepo, _, err := accessibleRepo(ctx, h.repoRepository, h.codeHostFactory, cmd.RepoID, cmd.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.repoRepository.UpdateIgnorePatterns(ctx, foundRepo.ID(), cmd.Patterns); err != nil {
+		slog.Error("Failed to store ignore patterns", "repo_id", cmd.RepoID, "error", err)
+		return nil, err
+	}
+	foundRepo.SetIgnorePatterns(cmd.Patterns)
+
+	slog.Info("SetIgnorePatterns command completed", "repo_id", cmd.RepoID, "custom", foundRepo.HasCustomIgnorePatterns())
+	return foundRepo, nil
+}
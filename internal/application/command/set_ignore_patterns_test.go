@@ -0,0 +1,85 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/octokerbs/chronocode/internal/adapters/memory"
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type SetIgnorePatternsTestSuite struct {
+	suite.Suite
+	repoRepository  repo.Repository
+	codeHostFactory codehost.CodeHostFactory
+	handler         SetIgnorePatternsHandler
+}
+
+func TestSetIgnorePatternsTestSuite(t *testing.T) {
+	suite.Run(t, new(SetIgnorePatternsTestSuite))
+}
+
+func (s *SetIgnorePatternsTestSuite) SetupTest() {
+	s.repoRepository, s.codeHostFactory = newStoredReposFixture()
+	s.handler = NewSetIgnorePatternsHandler(s.repoRepository, s.codeHostFactory)
+}
+
+func (s *SetIgnorePatternsTestSuite) TestRepositoriesStartWithDefaultPatterns() {
+	r, _ := s.repoRepository.GetRepoByID(context.Background(), memory.ValidRepoID)
+
+	assert.False(s.T(), r.HasCustomIgnorePatterns())
+	assert.Equal(s.T(), repo.DefaultIgnorePatterns, r.IgnorePatterns())
+}
+
+func (s *SetIgnorePatternsTestSuite) TestStoresCustomPatterns() {
+	updated, err := s.handler.Handle(context.Background(), SetIgnorePatterns{memory.ValidRepoID, []string{"*.lock", "!Cargo.lock"}, memory.ValidAccessToken})
+	stored, _ := s.repoRepository.GetRepoByID(context.Background(), memory.ValidRepoID)
+
+	assert.Nil(s.T(), err)
+	assert.True(s.T(), updated.HasCustomIgnorePatterns())
+	assert.Equal(s.T(), []string{"*.lock", "!Cargo.lock"}, stored.IgnorePatterns())
+}
+
+func (s *SetIgnorePatternsTestSuite) TestEmptyPatternsIgnoreNothing() {
+	_, err := s.handler.Handle(context.Background(), SetIgnorePatterns{memory.ValidRepoID, []string{}, memory.ValidAccessToken})
+	stored, _ := s.repoRepository.GetRepoByID(context.Background(), memory.ValidRepoID)
+
+	assert.Nil(s.T(), err)
+	assert.True(s.T(), stored.HasCustomIgnorePatterns())
+	assert.Empty(s.T(), stored.IgnorePatterns())
+}
+
+func (s *SetIgnorePatternsTestSuite) TestNilPatternsRestoreDefaults() {
+	_, _ = s.handler.Handle(context.Background(), SetIgnorePatterns{memory.ValidRepoID, []string{"*.lock"}, memory.ValidAccessToken})
+	_, err := s.handler.Handle(context.Background(), SetIgnorePatterns{memory.ValidRepoID, nil, memory.ValidAccessToken})
+	stored, _ := s.repoRepository.GetRepoByID(context.Background(), memory.ValidRepoID)
+
+	assert.Nil(s.T(), err)
+	assert.False(s.T(), stored.HasCustomIgnorePatterns())
+	assert.Equal(s.T(), repo.DefaultIgnorePatterns, stored.IgnorePatterns())
+}
+
+func (s *SetIgnorePatternsTestSuite) TestInvalidPatternsAreNotStored() {
+	_, err := s.handler.Handle(context.Background(), SetIgnorePatterns{memory.ValidRepoID, []string{"[unterminated"}, memory.ValidAccessToken})
+	stored, _ := s.repoRepository.GetRepoByID(context.Background(), memory.ValidRepoID)
+
+	assert.True(s.T(), errors.Is(err, repo.ErrInvalidIgnorePattern))
+	assert.False(s.T(), stored.HasCustomIgnorePatterns())
+}
+
+func (s *SetIgnorePatternsTestSuite) TestCannotChangePatternsOfInaccessibleRepo() {
+	_, err := s.handler.Handle(context.Background(), SetIgnorePatterns{memory.ForbiddenRepoID, []string{"*.lock"}, memory.ValidAccessToken})
+	stored, _ := s.repoRepository.GetRepoByID(context.Background(), memory.ForbiddenRepoID)
+
+	assert.True(s.T(), errors.Is(err, codehost.ErrAccessDenied))
+	assert.False(s.T(), stored.HasCustomIgnorePatterns())
+}
+
+func (s *SetIgnorePatternsTestSuite) TestCannotChangePatternsOfUnknownRepo() {
+	_, err := s.handler.Handle(context.Background(), SetIgnorePatterns{memory.ValidEmptyRepoID, []string{"*.lock"}, memory.ValidAccessToken})
+	assert.True(s.T(), errors.Is(err, repo.ErrRepositoryNotFound))
+}
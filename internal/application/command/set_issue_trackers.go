@@ -0,0 +1,65 @@
+package command
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+)
+
+// SetIssueTrackers
+// Replaces the trackers issue references of a repository are resolved with, nil
+// IssueTrackers restores repo.DefaultIssueTrackers and an empty list turns extraction
+// off. Only commits analyzed afterwards are linked with them.
+type SetIssueTrackers struct {
+	RepoID        int64
+	IssueTrackers []repo.IssueTracker
+	AccessToken   string
+}
+
+type SetIssueTrackersHandler struct {
+	repoRepository  repo.Repository
+	codeHostFactory codehost.CodeHostFactory
+}
+
+func NewSetIssueTrackersHandler(repoRepository repo.Repository, codeHostFactory codehost.CodeHostFactory) SetIssueTrackersHandler {
+	return SetIssueTrackersHandler{repoRepository: repoRepository, codeHostFactory: codeHostFactory}
+}
+
+func (h *SetIssueTrackersHandler) Handle(ctx context.Context, cmd SetIssueTrackers) (*repo.Repo, error) {
+	slog.Info("SetIssueTrackers command received", "repo_id", cmd.RepoID, "trackers", len(cmd.IssueTrackers), "defaults", cmd.IssueTrackers == nil)
+
+	trackers := normalizeIssueTrackers(cmd.IssueTrackers)
+	if _, err := repo.CompileIssueTrackers(trackers); err != nil {
+		slog.Warn("Rejected invalid issue trackers", "repo_id", cmd.RepoID, "error", err)
+		return nil, err
+	}
+
+	foundRepo, _, err := accessibleRepo(ctx, h.repoRepository, h.codeHostFactory, cmd.RepoID, cmd.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.repoRepository.UpdateIssueTrackers(ctx, foundRepo.ID(), trackers); err != nil {
+		slog.Error("Failed to store issue trackers", "repo_id", cmd.RepoID, "error", err)
+		return nil, err
+	}
+	foundRepo.SetIssueTrackers(trackers)
+
+	slog.Info("SetIssueTrackers command completed", "repo_id", cmd.RepoID, "custom", foundRepo.HasCustomIssueTrackers())
+	return foundRepo, nil
+}
+
+func normalizeIssueTrackers(trackers []repo.IssueTracker) []repo.IssueTracker {
+	if trackers == nil {
+		return nil
+	}
+
+	normalized := make([]repo.IssueTracker, len(trackers))
+	for i, tracker := range trackers {
+		normalized[i] = repo.IssueTracker{Pattern: tracker.Pattern, URLTemplate: strings.TrimSpace(tracker.URLTemplate)}
+	}
+	return normalized
+}
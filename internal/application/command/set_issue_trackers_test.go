@@ -0,0 +1,93 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/octokerbs/chronocode/internal/adapters/memory"
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type SetIssueTrackersTestSuite struct {
+	suite.Suite
+	repoRepository  repo.Repository
+	codeHostFactory codehost.CodeHostFactory
+	handler         SetIssueTrackersHandler
+}
+
+func TestSetIssueTrackersTestSuite(t *testing.T) {
+	suite.Run(t, new(SetIssueTrackersTestSuite))
+}
+
+func (s *SetIssueTrackersTestSuite) SetupTest() {
+	s.repoRepository, s.codeHostFactory = newStoredReposFixture()
+	s.handler = NewSetIssueTrackersHandler(s.repoRepository, s.codeHostFactory)
+}
+
+func (s *SetIssueTrackersTestSuite) TestRepositoriesStartWithTheCodeHostTrackers() {
+	r, _ := s.repoRepository.GetRepoByID(context.Background(), memory.ValidRepoID)
+
+	assert.False(s.T(), r.HasCustomIssueTrackers())
+	assert.Equal(s.T(), repo.DefaultIssueTrackers, r.IssueTrackers())
+}
+
+func (s *SetIssueTrackersTestSuite) TestStoresTrackers() {
+	jira := repo.IssueTracker{Pattern: `JIRA-\d+`, URLTemplate: " https://example.atlassian.net/browse/{ref} "}
+	updated, err := s.handler.Handle(context.Background(), SetIssueTrackers{memory.ValidRepoID, []repo.IssueTracker{jira}, memory.ValidAccessToken})
+	stored, _ := s.repoRepository.GetRepoByID(context.Background(), memory.ValidRepoID)
+
+	assert.Nil(s.T(), err)
+	assert.True(s.T(), updated.HasCustomIssueTrackers())
+	assert.Equal(s.T(), []repo.IssueTracker{{Pattern: `JIRA-\d+`, URLTemplate: "https://example.atlassian.net/browse/{ref}"}}, stored.IssueTrackers())
+}
+
+func (s *SetIssueTrackersTestSuite) TestEmptyListTurnsExtractionOff() {
+	_, err := s.handler.Handle(context.Background(), SetIssueTrackers{memory.ValidRepoID, []repo.IssueTracker{}, memory.ValidAccessToken})
+	stored, _ := s.repoRepository.GetRepoByID(context.Background(), memory.ValidRepoID)
+	matcher, _ := repo.CompileIssueTrackers(stored.IssueTrackers())
+
+	assert.Nil(s.T(), err)
+	assert.True(s.T(), stored.HasCustomIssueTrackers())
+	assert.Empty(s.T(), matcher.References(memory.ValidRepoURL, "fixes #1"))
+}
+
+func (s *SetIssueTrackersTestSuite) TestNilTrackersRestoreDefaults() {
+	_, _ = s.handler.Handle(context.Background(), SetIssueTrackers{memory.ValidRepoID, []repo.IssueTracker{}, memory.ValidAccessToken})
+	_, err := s.handler.Handle(context.Background(), SetIssueTrackers{memory.ValidRepoID, nil, memory.ValidAccessToken})
+	stored, _ := s.repoRepository.GetRepoByID(context.Background(), memory.ValidRepoID)
+
+	assert.Nil(s.T(), err)
+	assert.False(s.T(), stored.HasCustomIssueTrackers())
+}
+
+func (s *SetIssueTrackersTestSuite) TestInvalidTrackersAreNotStored() {
+	for _, tracker := range []repo.IssueTracker{
+		{Pattern: "", URLTemplate: "https://example.com/{ref}"},
+		{Pattern: `JIRA-(\d+`, URLTemplate: "https://example.com/{ref}"},
+		{Pattern: `\d*`, URLTemplate: "https://example.com/{ref}"},
+		{Pattern: `JIRA-\d+`, URLTemplate: " "},
+		{Pattern: `JIRA-(\d+)`, URLTemplate: "https://example.com/{2}"},
+		{Pattern: `JIRA-\d+`, URLTemplate: "javascript:{ref}"},
+		{Pattern: `JIRA-\d+`, URLTemplate: "{ref}"},
+		{Pattern: `(\w+):JIRA-\d+`, URLTemplate: "{1}://example.com/{ref}"},
+		{Pattern: `JIRA-\d+`, URLTemplate: "/browse/{ref}"},
+	} {
+		_, err := s.handler.Handle(context.Background(), SetIssueTrackers{memory.ValidRepoID, []repo.IssueTracker{tracker}, memory.ValidAccessToken})
+		assert.True(s.T(), errors.Is(err, repo.ErrInvalidIssueTracker), "%+v", tracker)
+	}
+
+	stored, _ := s.repoRepository.GetRepoByID(context.Background(), memory.ValidRepoID)
+	assert.False(s.T(), stored.HasCustomIssueTrackers())
+}
+
+func (s *SetIssueTrackersTestSuite) TestCannotChangeTrackersOfInaccessibleRepo() {
+	_, err := s.handler.Handle(context.Background(), SetIssueTrackers{memory.ForbiddenRepoID, []repo.IssueTracker{}, memory.ValidAccessToken})
+	stored, _ := s.repoRepository.GetRepoByID(context.Background(), memory.ForbiddenRepoID)
+
+	assert.True(s.T(), errors.Is(err, codehost.ErrAccessDenied))
+	assert.False(s.T(), stored.HasCustomIssueTrackers())
+}
@@ -0,0 +1,66 @@
+package command
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+)
+
+// SetModificationTypes
+// Replaces the taxonomy subcommits of a repository are classified with, nil
+// ModificationTypes restores agent.DefaultModificationTypes. Names are upper-cased.
+// Only commits analyzed afterwards are affected.
+type SetModificationTypes struct {
+	RepoID            int64
+	ModificationTypes []string
+	AccessToken       string
+}
+
+type SetModificationTypesHandler struct {
+	repoRepository  repo.Repository
+	codeHostFactory codehost.CodeHostFactory
+}
+
+func NewSetModificationTypesHandler(repoRepository repo.Repository, codeHostFactory codehost.CodeHostFactory) SetModificationTypesHandler {
+	return SetModificationTypesHandler{repoRepository: repoRepository, codeHostFactory: codeHostFactory}
+}
+
+func (h *SetModificationTypesHandler) Handle(ctx context.Context, cmd SetModificationTypes) (*repo.Repo, error) {
+	slog.Info("SetModificationTypes command received", "repo_id", cmd.RepoID, "types", cmd.ModificationTypes, "defaults", cmd.ModificationTypes == nil)
+
+	types := normalizeModificationTypes(cmd.ModificationTypes)
+	if err := agent.ValidateModificationTypes(types); err != nil {
+		slog.Warn("Rejected invalid modification types", "repo_id", cmd.RepoID, "error", err)
+		return nil, err
+	}
+
+	foundRepo, _, err := accessibleRepo(ctx, h.repoRepository, h.codeHostFactory, cmd.RepoID, cmd.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.repoRepository.UpdateModificationTypes(ctx, foundRepo.ID(), types); err != nil {
+		slog.Error("Failed to store modification types", "repo_id", cmd.RepoID, "error", err)
+		return nil, err
+	}
+	foundRepo.SetModificationTypes(types)
+
+	slog.Info("SetModificationTypes command completed", "repo_id", cmd.RepoID, "custom", foundRepo.HasCustomModificationTypes())
+	return foundRepo, nil
+}
+
+func normalizeModificationTypes(types []string) []string {
+	if types == nil {
+		return nil
+	}
+
+	normalized := make([]string, len(types))
+	for i, name := range types {
+		normalized[i] = strings.ToUpper(strings.TrimSpace(name))
+	}
+	return normalized
+}
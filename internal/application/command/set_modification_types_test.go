@@ -0,0 +1,75 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/octokerbs/chronocode/internal/adapters/memory"
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type SetModificationTypesTestSuite struct {
+	suite.Suite
+	repoRepository  repo.Repository
+	codeHostFactory codehost.CodeHostFactory
+	handler         SetModificationTypesHandler
+}
+
+func TestSetModificationTypesTestSuite(t *testing.T) {
+	suite.Run(t, new(SetModificationTypesTestSuite))
+}
+
+func (s *SetModificationTypesTestSuite) SetupTest() {
+	s.repoRepository, s.codeHostFactory = newStoredReposFixture()
+	s.handler = NewSetModificationTypesHandler(s.repoRepository, s.codeHostFactory)
+}
+
+func (s *SetModificationTypesTestSuite) TestRepositoriesStartWithDefaultTypes() {
+	r, _ := s.repoRepository.GetRepoByID(context.Background(), memory.ValidRepoID)
+
+	assert.False(s.T(), r.HasCustomModificationTypes())
+	assert.Equal(s.T(), agent.DefaultModificationTypes, r.ModificationTypes())
+}
+
+func (s *SetModificationTypesTestSuite) TestStoresUpperCasedTypes() {
+	updated, err := s.handler.Handle(context.Background(), SetModificationTypes{memory.ValidRepoID, []string{"security", " Perf", "DEPS"}, memory.ValidAccessToken})
+	stored, _ := s.repoRepository.GetRepoByID(context.Background(), memory.ValidRepoID)
+
+	assert.Nil(s.T(), err)
+	assert.True(s.T(), updated.HasCustomModificationTypes())
+	assert.Equal(s.T(), []string{"SECURITY", "PERF", "DEPS"}, stored.ModificationTypes())
+	assert.Equal(s.T(), []string{"SECURITY", "PERF", "DEPS"}, stored.AnalysisPrompt().Taxonomy())
+}
+
+func (s *SetModificationTypesTestSuite) TestNilTypesRestoreDefaults() {
+	_, _ = s.handler.Handle(context.Background(), SetModificationTypes{memory.ValidRepoID, []string{"INFRA"}, memory.ValidAccessToken})
+	_, err := s.handler.Handle(context.Background(), SetModificationTypes{memory.ValidRepoID, nil, memory.ValidAccessToken})
+	stored, _ := s.repoRepository.GetRepoByID(context.Background(), memory.ValidRepoID)
+
+	assert.Nil(s.T(), err)
+	assert.False(s.T(), stored.HasCustomModificationTypes())
+	assert.True(s.T(), stored.AnalysisPrompt().IsZero())
+}
+
+func (s *SetModificationTypesTestSuite) TestInvalidTaxonomiesAreNotStored() {
+	for _, types := range [][]string{{}, {"PERF", "perf"}, {"CI/CD"}, {""}} {
+		_, err := s.handler.Handle(context.Background(), SetModificationTypes{memory.ValidRepoID, types, memory.ValidAccessToken})
+		assert.True(s.T(), errors.Is(err, agent.ErrInvalidModificationTypes), "%q", types)
+	}
+
+	stored, _ := s.repoRepository.GetRepoByID(context.Background(), memory.ValidRepoID)
+	assert.False(s.T(), stored.HasCustomModificationTypes())
+}
+
+func (s *SetModificationTypesTestSuite) TestCannotChangeTypesOfInaccessibleRepo() {
+	_, err := s.handler.Handle(context.Background(), SetModificationTypes{memory.ForbiddenRepoID, []string{"INFRA"}, memory.ValidAccessToken})
+	stored, _ := s.repoRepository.GetRepoByID(context.Background(), memory.ForbiddenRepoID)
+
+	assert.True(s.T(), errors.Is(err, codehost.ErrAccessDenied))
+	assert.False(s.T(), stored.HasCustomModificationTypes())
+}
@@ -0,0 +1,52 @@
+package command
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+)
+
+// SetPromptOverrides
+// Replaces the glossary, title style and instructions added to the analysis prompt of
+// a repository, zero PromptOverrides removes them. Only commits analyzed afterwards
+// are affected.
+type SetPromptOverrides struct {
+	RepoID      int64
+	Overrides   agent.PromptOverrides
+	AccessToken string
+}
+
+type SetPromptOverridesHandler struct {
+	repoRepository  repo.Repository
+	codeHostFactory codehost.CodeHostFactory
+}
+
+func NewSetPromptOverridesHandler(repoRepository repo.Repository, codeHostFactory codehost.CodeHostFactory) SetPromptOverridesHandler {
+	return SetPromptOverridesHandler{repoRepository: repoRepository, codeHostFactory: codeHostFactory}
+}
+
+func (h *SetPromptOverridesHandler) Handle(ctx context.Context, cmd SetPromptOverrides) (*repo.Repo, error) {
+	slog.Info("SetPromptOverrides command received", "repo_id", cmd.RepoID, "fingerprint", cmd.Overrides.Fingerprint())
+
+	if err := cmd.Overrides.Validate(); err != nil {
+		slog.Warn("Rejected invalid prompt overrides", "repo_id", cmd.RepoID, "error", err)
+		return nil, err
+	}
+
+	foundRepo, _, err := accessibleRepo(ctx, h.repoRepository, h.codeHostFactory, cmd.RepoID, cmd.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.repoRepository.UpdatePromptOverrides(ctx, foundRepo.ID(), cmd.Overrides); err != nil {
+		slog.Error("Failed to store prompt overrides", "repo_id", cmd.RepoID, "error", err)
+		return nil, err
+	}
+	foundRepo.SetPromptOverrides(cmd.Overrides)
+
+	slog.Info("SetPromptOverrides command completed", "repo_id", cmd.RepoID, "default_prompt", cmd.Overrides.IsZero())
+	return foundRepo, nil
+}
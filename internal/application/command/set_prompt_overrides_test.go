@@ -0,0 +1,67 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/octokerbs/chronocode/internal/adapters/memory"
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type SetPromptOverridesTestSuite struct {
+	suite.Suite
+	repoRepository  repo.Repository
+	codeHostFactory codehost.CodeHostFactory
+	handler         SetPromptOverridesHandler
+}
+
+func TestSetPromptOverridesTestSuite(t *testing.T) {
+	suite.Run(t, new(SetPromptOverridesTestSuite))
+}
+
+func (s *SetPromptOverridesTestSuite) SetupTest() {
+	s.repoRepository, s.codeHostFactory = newStoredReposFixture()
+	s.handler = NewSetPromptOverridesHandler(s.repoRepository, s.codeHostFactory)
+}
+
+func (s *SetPromptOverridesTestSuite) TestStoresOverrides() {
+	overrides := agent.PromptOverrides{Glossary: "Ledger: the accounting service", TitleStyle: "Conventional commits"}
+	updated, err := s.handler.Handle(context.Background(), SetPromptOverrides{memory.ValidRepoID, overrides, memory.ValidAccessToken})
+	stored, _ := s.repoRepository.GetRepoByID(context.Background(), memory.ValidRepoID)
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), overrides, updated.PromptOverrides())
+	assert.Equal(s.T(), overrides, stored.PromptOverrides())
+}
+
+func (s *SetPromptOverridesTestSuite) TestZeroOverridesRestoreDefaultPrompt() {
+	_, _ = s.handler.Handle(context.Background(), SetPromptOverrides{memory.ValidRepoID, agent.PromptOverrides{Instructions: "Ignore generated code"}, memory.ValidAccessToken})
+	_, err := s.handler.Handle(context.Background(), SetPromptOverrides{memory.ValidRepoID, agent.PromptOverrides{}, memory.ValidAccessToken})
+	stored, _ := s.repoRepository.GetRepoByID(context.Background(), memory.ValidRepoID)
+
+	assert.Nil(s.T(), err)
+	assert.True(s.T(), stored.PromptOverrides().IsZero())
+}
+
+func (s *SetPromptOverridesTestSuite) TestOversizedOverridesAreRejected() {
+	overrides := agent.PromptOverrides{Glossary: strings.Repeat("x", 5000)}
+	_, err := s.handler.Handle(context.Background(), SetPromptOverrides{memory.ValidRepoID, overrides, memory.ValidAccessToken})
+	stored, _ := s.repoRepository.GetRepoByID(context.Background(), memory.ValidRepoID)
+
+	assert.True(s.T(), errors.Is(err, agent.ErrInvalidPromptOverrides))
+	assert.True(s.T(), stored.PromptOverrides().IsZero())
+}
+
+func (s *SetPromptOverridesTestSuite) TestCannotChangePromptOfInaccessibleRepo() {
+	_, err := s.handler.Handle(context.Background(), SetPromptOverrides{memory.ForbiddenRepoID, agent.PromptOverrides{Glossary: "x"}, memory.ValidAccessToken})
+	stored, _ := s.repoRepository.GetRepoByID(context.Background(), memory.ForbiddenRepoID)
+
+	assert.True(s.T(), errors.Is(err, codehost.ErrAccessDenied))
+	assert.True(s.T(), stored.PromptOverrides().IsZero())
+}
@@ -0,0 +1,95 @@
+package query
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/octokerbs/chronocode/internal/domain/analysis"
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+)
+
+type GetAnalysisRuns struct {
+	RepoID      int64
+	AccessToken string
+}
+
+type GetAnalysisRunsHandler struct {
+	repoRepository  repo.Repository
+	runRepository   analysis.RunRepository
+	codeHostFactory codehost.CodeHostFactory
+}
+
+func NewGetAnalysisRunsHandler(repoRepository repo.Repository, runRepository analysis.RunRepository, codeHostFactory codehost.CodeHostFactory) GetAnalysisRunsHandler {
+	return GetAnalysisRunsHandler{repoRepository: repoRepository, runRepository: runRepository, codeHostFactory: codeHostFactory}
+}
+
+func (h *GetAnalysisRunsHandler) Handle(ctx context.Context, cmd GetAnalysisRuns) ([]*analysis.Run, error) {
+	slog.Info("GetAnalysisRuns query received", "repo_id", cmd.RepoID)
+
+	if _, err := accessibleRepo(ctx, h.repoRepository, h.codeHostFactory, cmd.RepoID, cmd.AccessToken); err != nil {
+		return nil, err
+	}
+
+	runs, err := h.runRepository.ListRuns(ctx, cmd.RepoID)
+	if err != nil {
+		slog.Error("Failed to list analysis runs", "repo_id", cmd.RepoID, "error", err)
+		return nil, err
+	}
+
+	slog.Info("GetAnalysisRuns query completed", "repo_id", cmd.RepoID, "count", len(runs))
+	return runs, nil
+}
+
+type GetAnalysisRun struct {
+	RunID       int64
+	AccessToken string
+}
+
+type GetAnalysisRunHandler struct {
+	repoRepository  repo.Repository
+	runRepository   analysis.RunRepository
+	codeHostFactory codehost.CodeHostFactory
+}
+
+func NewGetAnalysisRunHandler(repoRepository repo.Repository, runRepository analysis.RunRepository, codeHostFactory codehost.CodeHostFactory) GetAnalysisRunHandler {
+	return GetAnalysisRunHandler{repoRepository: repoRepository, runRepository: runRepository, codeHostFactory: codeHostFactory}
+}
+
+func (h *GetAnalysisRunHandler) Handle(ctx context.Context, cmd GetAnalysisRun) (*analysis.Run, error) {
+	slog.Info("GetAnalysisRun query received", "run_id", cmd.RunID)
+
+	run, err := h.runRepository.GetRun(ctx, cmd.RunID)
+	if err != nil {
+		slog.Warn("Analysis run not found", "run_id", cmd.RunID, "error", err)
+		return nil, err
+	}
+
+	if _, err := accessibleRepo(ctx, h.repoRepository, h.codeHostFactory, run.RepoID(), cmd.AccessToken); err != nil {
+		return nil, err
+	}
+
+	slog.Info("GetAnalysisRun query completed", "run_id", run.ID(), "repo_id", run.RepoID(), "status", run.Status())
+	return run, nil
+}
+
+func accessibleRepo(ctx context.Context, repoRepository repo.Repository, codeHostFactory codehost.CodeHostFactory, repoID int64, accessToken string) (*repo.Repo, error) {
+	foundRepo, err := repoRepository.GetRepoByID(ctx, repoID)
+	if err != nil {
+		slog.Warn("Repository not found by ID", "repo_id", repoID, "error", err)
+		return nil, err
+	}
+
+	codeHost, err := codeHostFactory.Create(ctx, accessToken)
+	if err != nil {
+		slog.Error("Failed to create code host client for access check", "repo_id", repoID, "error", err)
+		return nil, err
+	}
+
+	if err := codeHost.CanAccessRepo(ctx, foundRepo.URL()); err != nil {
+		slog.Warn("Access denied to repository", "repo_id", repoID, "repo_url", foundRepo.URL(), "error", err)
+		return nil, err
+	}
+
+	return foundRepo, nil
+}
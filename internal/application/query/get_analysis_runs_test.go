@@ -0,0 +1,85 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/octokerbs/chronocode/internal/adapters/memory"
+	"github.com/octokerbs/chronocode/internal/domain/analysis"
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type GetAnalysisRunsTestSuite struct {
+	suite.Suite
+	repoRepository  repo.Repository
+	runRepository   analysis.RunRepository
+	codeHostFactory codehost.CodeHostFactory
+	listHandler     GetAnalysisRunsHandler
+	getHandler      GetAnalysisRunHandler
+}
+
+func TestGetAnalysisRunsTestSuite(t *testing.T) {
+	suite.Run(t, new(GetAnalysisRunsTestSuite))
+}
+
+func (s *GetAnalysisRunsTestSuite) SetupTest() {
+	s.repoRepository = memory.NewRepoRepository()
+	s.runRepository = memory.NewAnalysisRunRepository()
+	s.codeHostFactory = memory.NewCodeHostFactory()
+	s.listHandler = NewGetAnalysisRunsHandler(s.repoRepository, s.runRepository, s.codeHostFactory)
+	s.getHandler = NewGetAnalysisRunHandler(s.repoRepository, s.runRepository, s.codeHostFactory)
+
+	_ = s.repoRepository.StoreRepo(context.Background(), repo.NewRepo(memory.ValidRepoID, "chronocode", memory.ValidRepoURL, "", time.Time{}))
+	_ = s.repoRepository.StoreRepo(context.Background(), repo.NewRepo(memory.ForbiddenRepoID, "forbidden", memory.ForbiddenRepoURL, "", time.Time{}))
+}
+
+func (s *GetAnalysisRunsTestSuite) TestListsRunsNewestFirst() {
+	first := analysis.NewRun(memory.ValidRepoID, time.Now())
+	second := analysis.NewRun(memory.ValidRepoID, time.Now())
+	_ = s.runRepository.StoreRun(context.Background(), first)
+	_ = s.runRepository.StoreRun(context.Background(), second)
+
+	runs, err := s.listHandler.Handle(context.Background(), GetAnalysisRuns{memory.ValidRepoID, memory.ValidAccessToken})
+
+	assert.Nil(s.T(), err)
+	assert.Len(s.T(), runs, 2)
+	assert.Equal(s.T(), second.ID(), runs[0].ID())
+}
+
+func (s *GetAnalysisRunsTestSuite) TestCannotListRunsForInaccessibleRepo() {
+	_, err := s.listHandler.Handle(context.Background(), GetAnalysisRuns{memory.ForbiddenRepoID, memory.ValidAccessToken})
+	assert.True(s.T(), errors.Is(err, codehost.ErrAccessDenied))
+}
+
+func (s *GetAnalysisRunsTestSuite) TestCannotListRunsForNonExistentRepo() {
+	_, err := s.listHandler.Handle(context.Background(), GetAnalysisRuns{memory.ValidEmptyRepoID, memory.ValidAccessToken})
+	assert.True(s.T(), errors.Is(err, repo.ErrRepositoryNotFound))
+}
+
+func (s *GetAnalysisRunsTestSuite) TestGetsRunByID() {
+	run := analysis.NewRun(memory.ValidRepoID, time.Now())
+	_ = s.runRepository.StoreRun(context.Background(), run)
+
+	found, err := s.getHandler.Handle(context.Background(), GetAnalysisRun{run.ID(), memory.ValidAccessToken})
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), analysis.RunStatusRunning, found.Status())
+}
+
+func (s *GetAnalysisRunsTestSuite) TestCannotGetUnknownRun() {
+	_, err := s.getHandler.Handle(context.Background(), GetAnalysisRun{42, memory.ValidAccessToken})
+	assert.True(s.T(), errors.Is(err, analysis.ErrRunNotFound))
+}
+
+func (s *GetAnalysisRunsTestSuite) TestCannotGetRunOfInaccessibleRepo() {
+	run := analysis.NewRun(memory.ForbiddenRepoID, time.Now())
+	_ = s.runRepository.StoreRun(context.Background(), run)
+
+	_, err := s.getHandler.Handle(context.Background(), GetAnalysisRun{run.ID(), memory.ValidAccessToken})
+	assert.True(s.T(), errors.Is(err, codehost.ErrAccessDenied))
+}
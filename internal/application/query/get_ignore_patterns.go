@@ -0,0 +1,35 @@
+package query
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+)
+
+type GetIgnorePatterns struct {
+	RepoID      int64
+	AccessToken string
+}
+
+type GetIgnorePatternsHandler struct {
+	repoRepository  repo.Repository
+	codeHostFactory codehost.CodeHostFactory
+}
+
+func NewGetIgnorePatternsHandler(repoRepository repo.Repository, codeHostFactory codehost.CodeHostFactory) GetIgnorePatternsHandler {
+	return GetIgnorePatternsHandler{repoRepository: repoRepository, codeHostFactory: codeHostFactory}
+}
+
+func (h *GetIgnorePatternsHandler) Handle(ctx context.Context, cmd GetIgnorePatterns) (*repo.Repo, error) {
+	slog.Info("GetIgnorePatterns query received", "repo_id", cmd.RepoID)
+
+	foundRepo, err := accessibleRepo(ctx, h.repoRepository, h.codeHostFactory, cmd.RepoID, cmd.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("GetIgnorePatterns query completed", "repo_id", cmd.RepoID, "custom", foundRepo.HasCustomIgnorePatterns())
+	return foundRepo, nil
+}
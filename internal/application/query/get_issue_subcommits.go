@@ -0,0 +1,54 @@
+package query
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+	"github.com/octokerbs/chronocode/internal/domain/subcommit"
+)
+
+// GetIssueSubcommits
+// Everything done for an issue. Ref is the reference as commits write it, like
+// "#123", "owner/repo#9" or "JIRA-456"; a bare number stands for "#number".
+type GetIssueSubcommits struct {
+	RepoID      int64
+	Ref         string
+	AccessToken string
+}
+
+type GetIssueSubcommitsResult struct {
+	Ref        string
+	Subcommits []subcommit.Subcommit
+	RepoURL    string
+}
+
+type GetIssueSubcommitsHandler struct {
+	repoRepository      repo.Repository
+	subcommitRepository subcommit.Repository
+	codeHostFactory     codehost.CodeHostFactory
+}
+
+func NewGetIssueSubcommitsHandler(repoRepository repo.Repository, subcommitRepository subcommit.Repository, codeHostFactory codehost.CodeHostFactory) GetIssueSubcommitsHandler {
+	return GetIssueSubcommitsHandler{repoRepository: repoRepository, subcommitRepository: subcommitRepository, codeHostFactory: codeHostFactory}
+}
+
+func (h *GetIssueSubcommitsHandler) Handle(ctx context.Context, cmd GetIssueSubcommits) (GetIssueSubcommitsResult, error) {
+	ref := repo.NormalizeIssueRef(cmd.Ref)
+	slog.Info("GetIssueSubcommits query received", "repo_id", cmd.RepoID, "ref", ref)
+
+	foundRepo, err := accessibleRepo(ctx, h.repoRepository, h.codeHostFactory, cmd.RepoID, cmd.AccessToken)
+	if err != nil {
+		return GetIssueSubcommitsResult{}, err
+	}
+
+	issueSubcommits, err := h.subcommitRepository.GetSubcommitsByIssue(ctx, foundRepo.ID(), ref)
+	if err != nil {
+		slog.Error("Failed to fetch subcommits of issue from database", "repo_id", foundRepo.ID(), "ref", ref, "error", err)
+		return GetIssueSubcommitsResult{}, err
+	}
+
+	slog.Info("GetIssueSubcommits query completed", "repo_id", foundRepo.ID(), "ref", ref, "count", len(issueSubcommits))
+	return GetIssueSubcommitsResult{Ref: ref, Subcommits: issueSubcommits, RepoURL: foundRepo.URL()}, nil
+}
@@ -0,0 +1,86 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/octokerbs/chronocode/internal/adapters/memory"
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+	"github.com/octokerbs/chronocode/internal/domain/subcommit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type GetIssueSubcommitsTestSuite struct {
+	suite.Suite
+	repoRepository      repo.Repository
+	subcommitRepository subcommit.Repository
+	codeHostFactory     codehost.CodeHostFactory
+	handler             GetIssueSubcommitsHandler
+}
+
+func TestGetIssueSubcommitsTestSuite(t *testing.T) {
+	suite.Run(t, new(GetIssueSubcommitsTestSuite))
+}
+
+func (s *GetIssueSubcommitsTestSuite) SetupTest() {
+	s.repoRepository = memory.NewRepoRepository()
+	s.subcommitRepository = memory.NewSubcommitRepository()
+	s.codeHostFactory = memory.NewCodeHostFactory()
+	s.handler = NewGetIssueSubcommitsHandler(s.repoRepository, s.subcommitRepository, s.codeHostFactory)
+}
+
+func (s *GetIssueSubcommitsTestSuite) storeSubcommits(subcommits ...subcommit.Subcommit) {
+	ch := make(chan subcommit.Subcommit, len(subcommits))
+	for _, sc := range subcommits {
+		ch <- sc
+	}
+	close(ch)
+	_ = s.subcommitRepository.StoreSubcommits(context.Background(), ch)
+}
+
+func (s *GetIssueSubcommitsTestSuite) TestCannotGetSubcommitsOfIssueInInaccessibleRepo() {
+	_ = s.repoRepository.StoreRepo(context.Background(), repo.NewRepo(memory.ForbiddenRepoID, "forbidden", memory.ForbiddenRepoURL, "", time.Time{}))
+	_, err := s.handler.Handle(context.Background(), GetIssueSubcommits{memory.ForbiddenRepoID, "#1", memory.ValidAccessToken})
+	assert.True(s.T(), errors.Is(err, codehost.ErrAccessDenied))
+}
+
+func (s *GetIssueSubcommitsTestSuite) TestCannotGetSubcommitsOfIssueInNonExistentRepo() {
+	_, err := s.handler.Handle(context.Background(), GetIssueSubcommits{memory.ValidRepoID, "#1", memory.ValidAccessToken})
+	assert.True(s.T(), errors.Is(err, repo.ErrRepositoryNotFound))
+}
+
+func (s *GetIssueSubcommitsTestSuite) TestReturnsTheSubcommitsLinkedToTheIssue() {
+	_ = s.repoRepository.StoreRepo(context.Background(), repo.NewRepo(memory.ValidRepoID, "chronocode", memory.ValidRepoURL, "", time.Time{}))
+	payments := []subcommit.Issue{{Ref: "#12"}, {Ref: "JIRA-456", URL: "https://example.atlassian.net/browse/JIRA-456", Closes: true}}
+	s.storeSubcommits(
+		subcommit.NewSubcommit("Retry payments", "", "", "", "BUG", "c1", nil, memory.ValidRepoID, time.Time{}, "", "", subcommit.Author{}, nil, nil, payments),
+		subcommit.NewSubcommit("Test retries", "", "", "", "BUG", "c1", nil, memory.ValidRepoID, time.Time{}, "", "", subcommit.Author{}, nil, nil, payments),
+		subcommit.NewSubcommit("Bump deps", "", "", "", "CHORE", "c2", nil, memory.ValidRepoID, time.Time{}, "", "", subcommit.Author{}, nil, nil, []subcommit.Issue{{Ref: "#7"}}),
+		subcommit.NewSubcommit("Retry refunds", "", "", "", "BUG", "c3", nil, memory.ForbiddenRepoID, time.Time{}, "", "", subcommit.Author{}, nil, nil, payments),
+	)
+
+	result, err := s.handler.Handle(context.Background(), GetIssueSubcommits{memory.ValidRepoID, "JIRA-456", memory.ValidAccessToken})
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), "JIRA-456", result.Ref)
+	assert.Equal(s.T(), memory.ValidRepoURL, result.RepoURL)
+	assert.Len(s.T(), result.Subcommits, 2)
+
+	unknown, _ := s.handler.Handle(context.Background(), GetIssueSubcommits{memory.ValidRepoID, "JIRA-457", memory.ValidAccessToken})
+	assert.Empty(s.T(), unknown.Subcommits)
+}
+
+func (s *GetIssueSubcommitsTestSuite) TestBareNumberIsAnIssueOfTheRepository() {
+	_ = s.repoRepository.StoreRepo(context.Background(), repo.NewRepo(memory.ValidRepoID, "chronocode", memory.ValidRepoURL, "", time.Time{}))
+	s.storeSubcommits(subcommit.NewSubcommit("Bump deps", "", "", "", "CHORE", "c2", nil, memory.ValidRepoID, time.Time{}, "", "", subcommit.Author{}, nil, nil, []subcommit.Issue{{Ref: "#7"}}))
+
+	result, err := s.handler.Handle(context.Background(), GetIssueSubcommits{memory.ValidRepoID, " 7", memory.ValidAccessToken})
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), "#7", result.Ref)
+	assert.Len(s.T(), result.Subcommits, 1)
+}
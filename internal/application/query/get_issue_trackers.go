@@ -0,0 +1,35 @@
+package query
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+)
+
+type GetIssueTrackers struct {
+	RepoID      int64
+	AccessToken string
+}
+
+type GetIssueTrackersHandler struct {
+	repoRepository  repo.Repository
+	codeHostFactory codehost.CodeHostFactory
+}
+
+func NewGetIssueTrackersHandler(repoRepository repo.Repository, codeHostFactory codehost.CodeHostFactory) GetIssueTrackersHandler {
+	return GetIssueTrackersHandler{repoRepository: repoRepository, codeHostFactory: codeHostFactory}
+}
+
+func (h *GetIssueTrackersHandler) Handle(ctx context.Context, cmd GetIssueTrackers) (*repo.Repo, error) {
+	slog.Info("GetIssueTrackers query received", "repo_id", cmd.RepoID)
+
+	foundRepo, err := accessibleRepo(ctx, h.repoRepository, h.codeHostFactory, cmd.RepoID, cmd.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("GetIssueTrackers query completed", "repo_id", cmd.RepoID, "custom", foundRepo.HasCustomIssueTrackers())
+	return foundRepo, nil
+}
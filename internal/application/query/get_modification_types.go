@@ -0,0 +1,35 @@
+package query
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+)
+
+type GetModificationTypes struct {
+	RepoID      int64
+	AccessToken string
+}
+
+type GetModificationTypesHandler struct {
+	repoRepository  repo.Repository
+	codeHostFactory codehost.CodeHostFactory
+}
+
+func NewGetModificationTypesHandler(repoRepository repo.Repository, codeHostFactory codehost.CodeHostFactory) GetModificationTypesHandler {
+	return GetModificationTypesHandler{repoRepository: repoRepository, codeHostFactory: codeHostFactory}
+}
+
+func (h *GetModificationTypesHandler) Handle(ctx context.Context, cmd GetModificationTypes) (*repo.Repo, error) {
+	slog.Info("GetModificationTypes query received", "repo_id", cmd.RepoID)
+
+	foundRepo, err := accessibleRepo(ctx, h.repoRepository, h.codeHostFactory, cmd.RepoID, cmd.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("GetModificationTypes query completed", "repo_id", cmd.RepoID, "custom", foundRepo.HasCustomModificationTypes())
+	return foundRepo, nil
+}
@@ -0,0 +1,35 @@
+package query
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+)
+
+type GetPromptOverrides struct {
+	RepoID      int64
+	AccessToken string
+}
+
+type GetPromptOverridesHandler struct {
+	repoRepository  repo.Repository
+	codeHostFactory codehost.CodeHostFactory
+}
+
+func NewGetPromptOverridesHandler(repoRepository repo.Repository, codeHostFactory codehost.CodeHostFactory) GetPromptOverridesHandler {
+	return GetPromptOverridesHandler{repoRepository: repoRepository, codeHostFactory: codeHostFactory}
+}
+
+func (h *GetPromptOverridesHandler) Handle(ctx context.Context, cmd GetPromptOverrides) (*repo.Repo, error) {
+	slog.Info("GetPromptOverrides query received", "repo_id", cmd.RepoID)
+
+	foundRepo, err := accessibleRepo(ctx, h.repoRepository, h.codeHostFactory, cmd.RepoID, cmd.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("GetPromptOverrides query completed", "repo_id", cmd.RepoID, "fingerprint", foundRepo.PromptOverrides().Fingerprint())
+	return foundRepo, nil
+}
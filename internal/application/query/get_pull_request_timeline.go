@@ -0,0 +1,50 @@
+package query
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+	"github.com/octokerbs/chronocode/internal/domain/subcommit"
+)
+
+type GetPullRequestTimeline struct {
+	RepoID      int64
+	AccessToken string
+}
+
+type GetPullRequestTimelineResult struct {
+	Groups  []subcommit.PullRequestGroup
+	RepoURL string
+}
+
+type GetPullRequestTimelineHandler struct {
+	repoRepository      repo.Repository
+	subcommitRepository subcommit.Repository
+	codeHostFactory     codehost.CodeHostFactory
+}
+
+func NewGetPullRequestTimelineHandler(repoRepository repo.Repository, subcommitRepository subcommit.Repository, codeHostFactory codehost.CodeHostFactory) GetPullRequestTimelineHandler {
+	return GetPullRequestTimelineHandler{repoRepository: repoRepository, subcommitRepository: subcommitRepository, codeHostFactory: codeHostFactory}
+}
+
+func (h *GetPullRequestTimelineHandler) Handle(ctx context.Context, cmd GetPullRequestTimeline) (GetPullRequestTimelineResult, error) {
+	slog.Info("GetPullRequestTimeline query received", "repo_id", cmd.RepoID)
+
+	foundRepo, err := accessibleRepo(ctx, h.repoRepository, h.codeHostFactory, cmd.RepoID, cmd.AccessToken)
+	if err != nil {
+		return GetPullRequestTimelineResult{}, err
+	}
+
+	repoSubcommits, err := h.subcommitRepository.GetSubcommits(ctx, foundRepo.ID())
+	if err != nil {
+		slog.Error("Failed to fetch subcommits from database", "repo_id", foundRepo.ID(), "error", err)
+		return GetPullRequestTimelineResult{}, err
+	}
+
+	groups := subcommit.GroupByPullRequest(repoSubcommits)
+
+	slog.Info("GetPullRequestTimeline query completed", "repo_id", foundRepo.ID(), "groups", len(groups), "subcommits", len(repoSubcommits))
+	return GetPullRequestTimelineResult{Groups: groups, RepoURL: foundRepo.URL()}, nil
+}
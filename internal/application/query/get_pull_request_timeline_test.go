@@ -0,0 +1,86 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/octokerbs/chronocode/internal/adapters/memory"
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+	"github.com/octokerbs/chronocode/internal/domain/subcommit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type GetPullRequestTimelineTestSuite struct {
+	suite.Suite
+	repoRepository      repo.Repository
+	subcommitRepository subcommit.Repository
+	codeHostFactory     codehost.CodeHostFactory
+	handler             GetPullRequestTimelineHandler
+}
+
+func TestGetPullRequestTimelineTestSuite(t *testing.T) {
+	suite.Run(t, new(GetPullRequestTimelineTestSuite))
+}
+
+func (s *GetPullRequestTimelineTestSuite) SetupTest() {
+	s.repoRepository = memory.NewRepoRepository()
+	s.subcommitRepository = memory.NewSubcommitRepository()
+	s.codeHostFactory = memory.NewCodeHostFactory()
+	s.handler = NewGetPullRequestTimelineHandler(s.repoRepository, s.subcommitRepository, s.codeHostFactory)
+}
+
+func (s *GetPullRequestTimelineTestSuite) storeSubcommits(subcommits ...subcommit.Subcommit) {
+	ch := make(chan subcommit.Subcommit, len(subcommits))
+	for _, sc := range subcommits {
+		ch <- sc
+	}
+	close(ch)
+	_ = s.subcommitRepository.StoreSubcommits(context.Background(), ch)
+}
+
+func (s *GetPullRequestTimelineTestSuite) TestCannotGetTimelineForInaccessibleRepo() {
+	_ = s.repoRepository.StoreRepo(context.Background(), repo.NewRepo(memory.ForbiddenRepoID, "forbidden", memory.ForbiddenRepoURL, "", time.Time{}))
+	_, err := s.handler.Handle(context.Background(), GetPullRequestTimeline{memory.ForbiddenRepoID, memory.ValidAccessToken})
+	assert.True(s.T(), errors.Is(err, codehost.ErrAccessDenied))
+}
+
+func (s *GetPullRequestTimelineTestSuite) TestCannotGetTimelineForNonExistentRepo() {
+	_, err := s.handler.Handle(context.Background(), GetPullRequestTimeline{memory.ValidRepoID, memory.ValidAccessToken})
+	assert.True(s.T(), errors.Is(err, repo.ErrRepositoryNotFound))
+}
+
+func (s *GetPullRequestTimelineTestSuite) TestGroupsSubcommitsByPullRequest() {
+	_ = s.repoRepository.StoreRepo(context.Background(), repo.NewRepo(memory.ValidRepoID, "chronocode", memory.ValidRepoURL, "", time.Time{}))
+	login := &subcommit.PullRequest{Number: 4, Title: "Login", URL: "https://github.com/octokerbs/chronocode/pull/4", MergedAt: time.Date(2025, 1, 12, 0, 0, 0, 0, time.UTC)}
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 0, 0, 0, 0, time.UTC) }
+	s.storeSubcommits(
+		subcommit.NewSubcommit("Add login form", "", "", "", "FEATURE", "c1", nil, memory.ValidRepoID, day(10), "", "", subcommit.Author{}, []subcommit.FileStat{{Path: "login.go", Additions: 30}}, login, nil),
+		subcommit.NewSubcommit("Validate password", "", "", "", "FEATURE", "c2", nil, memory.ValidRepoID, day(11), "", "", subcommit.Author{}, []subcommit.FileStat{{Path: "login.go", Additions: 5, Deletions: 2}}, login, nil),
+		subcommit.NewSubcommit("Test login", "", "", "", "FEATURE", "c2", nil, memory.ValidRepoID, day(11), "", "", subcommit.Author{}, nil, login, nil),
+		subcommit.NewSubcommit("Bump deps", "", "", "", "CHORE", "c3", nil, memory.ValidRepoID, day(13), "", "", subcommit.Author{}, nil, nil, nil),
+		subcommit.NewSubcommit("Fix typo", "", "", "", "DOCS", "c0", nil, memory.ValidRepoID, day(9), "", "", subcommit.Author{}, nil, nil, nil),
+	)
+
+	result, err := s.handler.Handle(context.Background(), GetPullRequestTimeline{memory.ValidRepoID, memory.ValidAccessToken})
+
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), memory.ValidRepoURL, result.RepoURL)
+	require.Len(s.T(), result.Groups, 3)
+
+	assert.Nil(s.T(), result.Groups[0].PullRequest)
+	assert.Equal(s.T(), []string{"c3"}, result.Groups[0].CommitSHAs)
+
+	assert.Equal(s.T(), login, result.Groups[1].PullRequest)
+	assert.ElementsMatch(s.T(), []string{"c1", "c2"}, result.Groups[1].CommitSHAs)
+	assert.Len(s.T(), result.Groups[1].Subcommits, 3)
+	assert.Equal(s.T(), 35, result.Groups[1].Additions())
+	assert.Equal(s.T(), 37, result.Groups[1].Churn())
+	assert.Equal(s.T(), login.MergedAt, result.Groups[1].Date())
+
+	assert.Equal(s.T(), []string{"c0"}, result.Groups[2].CommitSHAs)
+}
@@ -12,6 +12,9 @@ import (
 type GetSubcommits struct {
 	RepoID      int64
 	AccessToken string
+	// Author keeps only the subcommits of the author with this login, email or name,
+	// all of them when empty
+	Author string
 }
 
 type GetSubcommitsResult struct {
@@ -30,7 +33,7 @@ func NewGetSubcommitsHandler(repoRepository repo.Repository, subcommitRepository
 }
 
 func (gs *GetSubcommitsHandler) Handle(ctx context.Context, cmd GetSubcommits) (GetSubcommitsResult, error) {
-	slog.Info("GetSubcommits query received", "repo_id", cmd.RepoID)
+	slog.Info("GetSubcommits query received", "repo_id", cmd.RepoID, "author", cmd.Author)
 
 	foundRepo, err := gs.repoRepository.GetRepoByID(ctx, cmd.RepoID)
 	if err != nil {
@@ -55,9 +58,26 @@ func (gs *GetSubcommitsHandler) Handle(ctx context.Context, cmd GetSubcommits) (
 		return GetSubcommitsResult{}, err
 	}
 
+	if cmd.Author != "" {
+		repoSubcommits = filterByAuthor(repoSubcommits, cmd.Author)
+	}
+
 	slog.Info("GetSubcommits query completed", "repo_id", foundRepo.ID(), "count", len(repoSubcommits))
 	return GetSubcommitsResult{
 		Subcommits: repoSubcommits,
 		RepoURL:    foundRepo.URL(),
 	}, nil
 }
+
+// filterByAuthor
+// Matching the login as well as the email gathers the commits a person made under
+// every email their account knows
+func filterByAuthor(subcommits []subcommit.Subcommit, identity string) []subcommit.Subcommit {
+	filtered := []subcommit.Subcommit{}
+	for _, sc := range subcommits {
+		if sc.Author().Matches(identity) {
+			filtered = append(filtered, sc)
+		}
+	}
+	return filtered
+}
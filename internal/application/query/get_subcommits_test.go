@@ -35,26 +35,58 @@ func (s *GetSubcommitsTestSuite) SetupTest() {
 
 func (s *GetSubcommitsTestSuite) TestCannotGetSubcommitsWithoutAccessToken() {
 	_ = s.repoRepository.StoreRepo(context.Background(), repo.NewRepo(memory.ValidRepoID, "chronocode", memory.ValidRepoURL, "", time.Time{}))
-	_, err := s.handler.Handle(context.Background(), GetSubcommits{memory.ValidRepoID, ""})
+	_, err := s.handler.Handle(context.Background(), GetSubcommits{memory.ValidRepoID, "", ""})
 	assert.NotNil(s.T(), err)
 }
 
 func (s *GetSubcommitsTestSuite) TestCannotGetSubcommitsForInaccessibleRepo() {
 	_ = s.repoRepository.StoreRepo(context.Background(), repo.NewRepo(memory.ForbiddenRepoID, "forbidden", memory.ForbiddenRepoURL, "", time.Time{}))
-	_, err := s.handler.Handle(context.Background(), GetSubcommits{memory.ForbiddenRepoID, memory.ValidAccessToken})
+	_, err := s.handler.Handle(context.Background(), GetSubcommits{memory.ForbiddenRepoID, memory.ValidAccessToken, ""})
 	assert.True(s.T(), errors.Is(err, codehost.ErrAccessDenied))
 }
 
 func (s *GetSubcommitsTestSuite) TestCannotGetSubcommitsForNonExistentRepo() {
-	_, err := s.handler.Handle(context.Background(), GetSubcommits{memory.ValidRepoID, memory.ValidAccessToken})
+	_, err := s.handler.Handle(context.Background(), GetSubcommits{memory.ValidRepoID, memory.ValidAccessToken, ""})
 	assert.True(s.T(), errors.Is(err, repo.ErrRepositoryNotFound))
 }
 
 func (s *GetSubcommitsTestSuite) TestReturnsSubcommitsForExistingRepo() {
 	_ = s.repoRepository.StoreRepo(context.Background(), repo.NewRepo(memory.ValidRepoID, "chronocode", memory.ValidRepoURL, "FFFFFF", time.Time{}))
-	result, err := s.handler.Handle(context.Background(), GetSubcommits{memory.ValidRepoID, memory.ValidAccessToken})
+	result, err := s.handler.Handle(context.Background(), GetSubcommits{memory.ValidRepoID, memory.ValidAccessToken, ""})
 
 	assert.Nil(s.T(), err)
 	assert.Empty(s.T(), result.Subcommits)
 	assert.Equal(s.T(), memory.ValidRepoURL, result.RepoURL)
 }
+
+func (s *GetSubcommitsTestSuite) storeSubcommits(subcommits ...subcommit.Subcommit) {
+	ch := make(chan subcommit.Subcommit, len(subcommits))
+	for _, sc := range subcommits {
+		ch <- sc
+	}
+	close(ch)
+	_ = s.subcommitRepository.StoreSubcommits(context.Background(), ch)
+}
+
+func (s *GetSubcommitsTestSuite) TestFiltersSubcommitsByAuthor() {
+	_ = s.repoRepository.StoreRepo(context.Background(), repo.NewRepo(memory.ValidRepoID, "chronocode", memory.ValidRepoURL, "", time.Time{}))
+	ana := subcommit.Author{Name: "Ana", Email: "ana@work.example", Login: "ana"}
+	anaAtHome := subcommit.Author{Name: "Ana", Email: "ana@home.example", Login: "ana"}
+	bob := subcommit.Author{Name: "Bob", Email: "bob@work.example"}
+	s.storeSubcommits(
+		subcommit.NewSubcommit("Add login", "", "", "", "FEATURE", "c1", nil, memory.ValidRepoID, time.Time{}, "", "", ana, nil, nil, nil),
+		subcommit.NewSubcommit("Fix logout", "", "", "", "BUG", "c2", nil, memory.ValidRepoID, time.Time{}, "", "", anaAtHome, nil, nil, nil),
+		subcommit.NewSubcommit("Bump deps", "", "", "", "CHORE", "c3", nil, memory.ValidRepoID, time.Time{}, "", "", bob, nil, nil, nil),
+	)
+
+	byLogin, err := s.handler.Handle(context.Background(), GetSubcommits{memory.ValidRepoID, memory.ValidAccessToken, "ANA"})
+	assert.Nil(s.T(), err)
+	assert.Len(s.T(), byLogin.Subcommits, 2)
+
+	byEmail, _ := s.handler.Handle(context.Background(), GetSubcommits{memory.ValidRepoID, memory.ValidAccessToken, "bob@work.example"})
+	assert.Len(s.T(), byEmail.Subcommits, 1)
+	assert.Equal(s.T(), "Bump deps", byEmail.Subcommits[0].Title())
+
+	unknown, _ := s.handler.Handle(context.Background(), GetSubcommits{memory.ValidRepoID, memory.ValidAccessToken, "carol"})
+	assert.Empty(s.T(), unknown.Subcommits)
+}
@@ -0,0 +1,51 @@
+package query
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+	"github.com/octokerbs/chronocode/internal/domain/analysis"
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+)
+
+type GetUsage struct {
+	RepoID      int64
+	AccessToken string
+}
+
+// RepoUsage
+// What analyzing a repository has cost so far, against its budget
+type RepoUsage struct {
+	Repo  *repo.Repo
+	Usage agent.Usage
+}
+
+type GetUsageHandler struct {
+	repoRepository        repo.Repository
+	commitStateRepository analysis.CommitStateRepository
+	codeHostFactory       codehost.CodeHostFactory
+}
+
+func NewGetUsageHandler(repoRepository repo.Repository, commitStateRepository analysis.CommitStateRepository, codeHostFactory codehost.CodeHostFactory) GetUsageHandler {
+	return GetUsageHandler{repoRepository: repoRepository, commitStateRepository: commitStateRepository, codeHostFactory: codeHostFactory}
+}
+
+func (h *GetUsageHandler) Handle(ctx context.Context, cmd GetUsage) (RepoUsage, error) {
+	slog.Info("GetUsage query received", "repo_id", cmd.RepoID)
+
+	foundRepo, err := accessibleRepo(ctx, h.repoRepository, h.codeHostFactory, cmd.RepoID, cmd.AccessToken)
+	if err != nil {
+		return RepoUsage{}, err
+	}
+
+	usage, err := h.commitStateRepository.RepoUsage(ctx, foundRepo.ID())
+	if err != nil {
+		slog.Error("Failed to fetch repository usage", "repo_id", cmd.RepoID, "error", err)
+		return RepoUsage{}, err
+	}
+
+	slog.Info("GetUsage query completed", "repo_id", cmd.RepoID, "total_tokens", usage.TotalTokens(), "cost_usd", usage.CostUSD)
+	return RepoUsage{Repo: foundRepo, Usage: usage}, nil
+}
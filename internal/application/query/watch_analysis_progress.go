@@ -0,0 +1,40 @@
+package query
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/octokerbs/chronocode/internal/domain/analysis"
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+)
+
+type WatchAnalysisProgress struct {
+	RepoID      int64
+	AccessToken string
+}
+
+type WatchAnalysisProgressHandler struct {
+	repoRepository  repo.Repository
+	progressBus     analysis.ProgressBus
+	codeHostFactory codehost.CodeHostFactory
+}
+
+func NewWatchAnalysisProgressHandler(repoRepository repo.Repository, progressBus analysis.ProgressBus, codeHostFactory codehost.CodeHostFactory) WatchAnalysisProgressHandler {
+	return WatchAnalysisProgressHandler{repoRepository: repoRepository, progressBus: progressBus, codeHostFactory: codeHostFactory}
+}
+
+// Handle
+// The caller must call unsubscribe once it stops reading events
+func (h *WatchAnalysisProgressHandler) Handle(ctx context.Context, cmd WatchAnalysisProgress) (<-chan analysis.ProgressEvent, func(), error) {
+	slog.Info("WatchAnalysisProgress query received", "repo_id", cmd.RepoID)
+
+	if _, err := accessibleRepo(ctx, h.repoRepository, h.codeHostFactory, cmd.RepoID, cmd.AccessToken); err != nil {
+		return nil, nil, err
+	}
+
+	events, unsubscribe := h.progressBus.Subscribe(ctx, cmd.RepoID)
+
+	slog.Info("WatchAnalysisProgress subscription opened", "repo_id", cmd.RepoID)
+	return events, unsubscribe, nil
+}
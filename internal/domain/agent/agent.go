@@ -6,7 +6,10 @@ import (
 )
 
 var (
-	ErrAnalysisFailed = errors.New("agent analysis failed")
+	ErrAnalysisFailed           = errors.New("agent analysis failed")
+	ErrInvalidBudget            = errors.New("invalid budget")
+	ErrInvalidPromptOverrides   = errors.New("invalid prompt overrides")
+	ErrInvalidModificationTypes = errors.New("invalid modification types")
 )
 
 type AnalysisResult struct {
@@ -16,8 +19,56 @@ type AnalysisResult struct {
 	Epic             string
 	ModificationType string
 	Files            []string
+	// Agent names the agent that produced the result when several can answer
+	Agent string
+	// PromptVersion identifies the prompt the result was produced with, empty for
+	// agents that use none
+	PromptVersion string
+	// Cached is set when the result was reused from an earlier analysis of the same diff
+	Cached bool
+}
+
+// AnalysisInput
+// A commit as agents see it. Only Diff is always set, the rest is whatever the code
+// host knows about the commit; the message and pull request often state the intent
+// better than the diff does.
+type AnalysisInput struct {
+	Diff    string
+	SHA     string
+	Message string
+	// Author is "Name <email>", or whichever of the two is known
+	Author  string
+	Parents []string
+	Files   []ChangedFile
+	// PullRequest is the pull request the commit was merged through, nil when unknown
+	PullRequest *PullRequest
+}
+
+// ChangedFile
+// Line counts of one file of the diff
+type ChangedFile struct {
+	Path string
+	// PreviousPath is set for renamed files
+	PreviousPath string
+	// Status is added, modified, removed or renamed
+	Status    string
+	Additions int
+	Deletions int
+}
+
+type PullRequest struct {
+	Number int
+	Title  string
+	Body   string
+	URL    string
+}
+
+// HasMetadata
+// Whether anything besides the diff is known
+func (i AnalysisInput) HasMetadata() bool {
+	return i.Message != "" || i.Author != "" || len(i.Parents) > 0 || len(i.Files) > 0 || i.PullRequest != nil
 }
 
 type Agent interface {
-	AnalyzeDiff(ctx context.Context, diff string) ([]AnalysisResult, error)
+	AnalyzeCommit(ctx context.Context, input AnalysisInput) ([]AnalysisResult, error)
 }
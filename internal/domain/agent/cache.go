@@ -0,0 +1,11 @@
+package agent
+
+import "context"
+
+// AnalysisCache
+// Analysis results stored under a content-addressed key, so that identical diffs
+// reached through cherry-picks, rebases or forks are only analyzed once
+type AnalysisCache interface {
+	GetAnalysis(ctx context.Context, key string) ([]AnalysisResult, bool, error)
+	StoreAnalysis(ctx context.Context, key string, results []AnalysisResult) error
+}
@@ -0,0 +1,81 @@
+package agent
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// maxPromptOverrideLength bounds every override, they are sent with each diff
+const maxPromptOverrideLength = 4000
+
+// PromptOverrides
+// Per-repository additions to the analysis prompt, empty fields add nothing
+type PromptOverrides struct {
+	// Glossary explains the domain terms of the project
+	Glossary string
+	// TitleStyle is the house style subcommit titles should follow
+	TitleStyle string
+	// Instructions is anything else the model should take into account
+	Instructions string
+	// ModificationTypes replaces DefaultModificationTypes when not nil
+	ModificationTypes []string
+}
+
+func (o PromptOverrides) IsZero() bool {
+	return o.Glossary == "" && o.TitleStyle == "" && o.Instructions == "" && o.ModificationTypes == nil
+}
+
+func (o PromptOverrides) Validate() error {
+	for _, field := range []string{o.Glossary, o.TitleStyle, o.Instructions} {
+		if len(field) > maxPromptOverrideLength {
+			return fmt.Errorf("%w: fields are limited to %d bytes", ErrInvalidPromptOverrides, maxPromptOverrideLength)
+		}
+	}
+	return ValidateModificationTypes(o.ModificationTypes)
+}
+
+// Taxonomy
+// The types subcommits may have under these overrides
+func (o PromptOverrides) Taxonomy() []string {
+	if o.ModificationTypes == nil {
+		return DefaultModificationTypes
+	}
+	return o.ModificationTypes
+}
+
+// Fingerprint
+// Short hash telling overrides apart in prompt versions and cache keys, "" when zero
+func (o PromptOverrides) Fingerprint() string {
+	if o.IsZero() {
+		return ""
+	}
+
+	hash := sha256.New()
+	for _, field := range []string{o.Glossary, o.TitleStyle, o.Instructions} {
+		hash.Write([]byte(field))
+		hash.Write([]byte{0})
+	}
+	// Only hashed when set, so that overrides from before taxonomies keep their fingerprint
+	if o.ModificationTypes != nil {
+		hash.Write([]byte(strings.Join(o.ModificationTypes, ",")))
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:12]
+}
+
+type promptOverridesKey struct{}
+
+// WithPromptOverrides
+// Returns a context whose agent calls add overrides to their prompt
+func WithPromptOverrides(ctx context.Context, overrides PromptOverrides) context.Context {
+	return context.WithValue(ctx, promptOverridesKey{}, overrides)
+}
+
+// PromptOverridesFrom
+// The overrides of ctx, zero when there are none
+func PromptOverridesFrom(ctx context.Context) PromptOverrides {
+	overrides, _ := ctx.Value(promptOverridesKey{}).(PromptOverrides)
+	return overrides
+}
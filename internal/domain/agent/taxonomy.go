@@ -0,0 +1,52 @@
+package agent
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+// DefaultModificationTypes
+// The types a subcommit may have unless its repository defines its own
+var DefaultModificationTypes = []string{"FEATURE", "BUG", "REFACTOR", "DOCS", "CHORE", "MILESTONE", "WARNING"}
+
+// maxModificationTypes keeps the taxonomy small enough for a model to tell apart
+const maxModificationTypes = 20
+
+var modificationTypeName = regexp.MustCompile(`^[A-Z][A-Z0-9_]{0,31}$`)
+
+// ValidateModificationTypes
+// A taxonomy holds between 1 and 20 distinct upper-case names such as SECURITY or
+// CI_CD. nil stands for DefaultModificationTypes and is valid.
+func ValidateModificationTypes(types []string) error {
+	if types == nil {
+		return nil
+	}
+	if len(types) == 0 || len(types) > maxModificationTypes {
+		return fmt.Errorf("%w: between 1 and %d types are required", ErrInvalidModificationTypes, maxModificationTypes)
+	}
+
+	for i, name := range types {
+		if !modificationTypeName.MatchString(name) {
+			return fmt.Errorf("%w: %q must be upper-case letters, digits and underscores", ErrInvalidModificationTypes, name)
+		}
+		if slices.Contains(types[:i], name) {
+			return fmt.Errorf("%w: %q is listed twice", ErrInvalidModificationTypes, name)
+		}
+	}
+	return nil
+}
+
+// CoerceModificationType
+// candidate when types has it, otherwise the closest catch-all: CHORE when types has
+// it, the first type when not. For agents that cannot be held to the taxonomy.
+func CoerceModificationType(types []string, candidate string) string {
+	switch {
+	case slices.Contains(types, candidate):
+		return candidate
+	case slices.Contains(types, "CHORE"):
+		return "CHORE"
+	default:
+		return types[0]
+	}
+}
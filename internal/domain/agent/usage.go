@@ -0,0 +1,99 @@
+package agent
+
+import (
+	"context"
+	"sync"
+)
+
+// Usage
+// Tokens billed by the models behind an analysis and what they cost
+type Usage struct {
+	PromptTokens   int64
+	ResponseTokens int64
+	CostUSD        float64
+}
+
+func (u Usage) TotalTokens() int64 {
+	return u.PromptTokens + u.ResponseTokens
+}
+
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		PromptTokens:   u.PromptTokens + other.PromptTokens,
+		ResponseTokens: u.ResponseTokens + other.ResponseTokens,
+		CostUSD:        u.CostUSD + other.CostUSD,
+	}
+}
+
+// Price
+// USD per million tokens of a model
+type Price struct {
+	PromptPerMillion   float64
+	ResponsePerMillion float64
+}
+
+func (p Price) Cost(promptTokens, responseTokens int64) float64 {
+	return (float64(promptTokens)*p.PromptPerMillion + float64(responseTokens)*p.ResponsePerMillion) / 1e6
+}
+
+// Budget
+// Caps the usage of analyses, zero fields are unlimited
+type Budget struct {
+	MaxTokens  int64
+	MaxCostUSD float64
+}
+
+func (b Budget) IsZero() bool {
+	return b.MaxTokens == 0 && b.MaxCostUSD == 0
+}
+
+// Validate
+// Negative limits are rejected rather than read as unlimited
+func (b Budget) Validate() error {
+	if b.MaxTokens < 0 || b.MaxCostUSD < 0 {
+		return ErrInvalidBudget
+	}
+	return nil
+}
+
+func (b Budget) ExceededBy(u Usage) bool {
+	return (b.MaxTokens > 0 && u.TotalTokens() >= b.MaxTokens) ||
+		(b.MaxCostUSD > 0 && u.CostUSD >= b.MaxCostUSD)
+}
+
+// UsageMeter
+// Collects the usage reported while analyzing one diff. Agents report through
+// RecordUsage, so decorators calling the model several times, or not at all, add up
+// naturally.
+type UsageMeter struct {
+	mu    sync.Mutex
+	usage Usage
+}
+
+type usageMeterKey struct{}
+
+// WithUsageMeter
+// Returns a context whose agent calls report their usage to the returned meter
+func WithUsageMeter(ctx context.Context) (context.Context, *UsageMeter) {
+	meter := &UsageMeter{}
+	return context.WithValue(ctx, usageMeterKey{}, meter), meter
+}
+
+// RecordUsage
+// Adds usage to the meter of ctx, if any
+func RecordUsage(ctx context.Context, usage Usage) {
+	meter, ok := ctx.Value(usageMeterKey{}).(*UsageMeter)
+	if !ok {
+		return
+	}
+
+	meter.mu.Lock()
+	defer meter.mu.Unlock()
+	meter.usage = meter.usage.Add(usage)
+}
+
+func (m *UsageMeter) Usage() Usage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.usage
+}
@@ -0,0 +1,18 @@
+package analysis
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrAnalysisCancelled = errors.New("analysis cancelled")
+	ErrNoAnalysisRunning = errors.New("no analysis running for this repository")
+)
+
+type CancelRegistry interface {
+	// Register makes the pipeline of a repository cancellable until unregister is called.
+	Register(ctx context.Context, repoID int64, cancel context.CancelCauseFunc) (unregister func())
+	// Cancel stops the running pipeline of a repository with ErrAnalysisCancelled as cause.
+	Cancel(ctx context.Context, repoID int64) error
+}
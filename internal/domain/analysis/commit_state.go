@@ -0,0 +1,103 @@
+package analysis
+
+import (
+	"time"
+
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+)
+
+type CommitStatus string
+
+const (
+	CommitPending  CommitStatus = "PENDING"
+	CommitAnalyzed CommitStatus = "ANALYZED"
+	CommitFailed   CommitStatus = "FAILED"
+	// CommitSkipped commits had nothing left to analyze once ignored files were filtered out
+	CommitSkipped CommitStatus = "SKIPPED"
+)
+
+// CommitState
+// Tracks the analysis of a single commit across runs, so that failed or
+// interrupted commits can be retried without walking the history again
+type CommitState struct {
+	repoID      int64
+	commitSHA   string
+	committedAt time.Time
+	status      CommitStatus
+	attempts    int
+	lastError   string
+	updatedAt   time.Time
+	// usage adds up every attempt, failed ones included
+	usage agent.Usage
+}
+
+func NewPendingCommitState(repoID int64, commitSHA string, committedAt, updatedAt time.Time) *CommitState {
+	return &CommitState{repoID: repoID, commitSHA: commitSHA, committedAt: committedAt, status: CommitPending, updatedAt: updatedAt}
+}
+
+func NewCommitStateFromDB(repoID int64, commitSHA string, committedAt time.Time, status CommitStatus, attempts int, lastError string, updatedAt time.Time, usage agent.Usage) *CommitState {
+	return &CommitState{repoID, commitSHA, committedAt, status, attempts, lastError, updatedAt, usage}
+}
+
+func (c *CommitState) AddUsage(usage agent.Usage) {
+	c.usage = c.usage.Add(usage)
+}
+
+func (c *CommitState) MarkAnalyzed(at time.Time) {
+	c.status = CommitAnalyzed
+	c.attempts++
+	c.lastError = ""
+	c.updatedAt = at
+}
+
+func (c *CommitState) MarkSkipped(at time.Time) {
+	c.status = CommitSkipped
+	c.attempts++
+	c.lastError = ""
+	c.updatedAt = at
+}
+
+// IsFinished
+// Analyzed and skipped commits are never analyzed again
+func (c *CommitState) IsFinished() bool {
+	return c.status == CommitAnalyzed || c.status == CommitSkipped
+}
+
+func (c *CommitState) MarkFailed(err error, at time.Time) {
+	c.status = CommitFailed
+	c.attempts++
+	c.lastError = err.Error()
+	c.updatedAt = at
+}
+
+func (c *CommitState) RepoID() int64 {
+	return c.repoID
+}
+
+func (c *CommitState) CommitSHA() string {
+	return c.commitSHA
+}
+
+func (c *CommitState) CommittedAt() time.Time {
+	return c.committedAt
+}
+
+func (c *CommitState) Status() CommitStatus {
+	return c.status
+}
+
+func (c *CommitState) Attempts() int {
+	return c.attempts
+}
+
+func (c *CommitState) LastError() string {
+	return c.lastError
+}
+
+func (c *CommitState) UpdatedAt() time.Time {
+	return c.updatedAt
+}
+
+func (c *CommitState) Usage() agent.Usage {
+	return c.usage
+}
@@ -0,0 +1,33 @@
+package analysis
+
+import (
+	"context"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventCommitFetched  EventType = "commit-fetched"
+	EventCommitSkipped  EventType = "commit-skipped"
+	EventCommitAnalyzed EventType = "commit-analyzed"
+	EventCommitFailed   EventType = "commit-failed"
+	EventRunFinished    EventType = "run-finished"
+)
+
+type ProgressEvent struct {
+	Type       EventType
+	RepoID     int64
+	RunID      int64
+	CommitSHA  string
+	Counts     CommitCounts
+	Error      string
+	OccurredAt time.Time
+}
+
+type ProgressBus interface {
+	// Publish never blocks the pipeline; subscribers that fall behind miss events.
+	Publish(ctx context.Context, event ProgressEvent)
+	// Subscribe delivers the events of a repository until unsubscribe is called.
+	Subscribe(ctx context.Context, repoID int64) (events <-chan ProgressEvent, unsubscribe func())
+}
@@ -0,0 +1,34 @@
+package analysis
+
+import (
+	"context"
+	"errors"
+
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+)
+
+var (
+	ErrRunNotFound     = errors.New("analysis run not found")
+	ErrNoFailedCommits = errors.New("no failed commits to retry")
+	ErrBudgetExceeded  = errors.New("analysis budget exceeded")
+)
+
+type RunRepository interface {
+	// StoreRun inserts the run when it has no ID yet (assigning one) and
+	// updates it otherwise.
+	StoreRun(ctx context.Context, run *Run) error
+	GetRun(ctx context.Context, id int64) (*Run, error)
+	// ListRuns returns the runs of a repository newest-first.
+	ListRuns(ctx context.Context, repoID int64) ([]*Run, error)
+}
+
+type CommitStateRepository interface {
+	// ListCommitStates returns the states of a repository, restricted to the
+	// given statuses when any are passed.
+	ListCommitStates(ctx context.Context, repoID int64, statuses ...CommitStatus) ([]*CommitState, error)
+	StoreCommitState(ctx context.Context, state *CommitState) error
+	// RepoUsage adds up the usage of every commit of a repository.
+	RepoUsage(ctx context.Context, repoID int64) (agent.Usage, error)
+	// TotalUsage adds up the usage of every commit of every repository.
+	TotalUsage(ctx context.Context) (agent.Usage, error)
+}
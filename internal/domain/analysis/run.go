@@ -0,0 +1,125 @@
+package analysis
+
+import (
+	"errors"
+	"time"
+
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+)
+
+type RunStatus string
+
+const (
+	RunStatusRunning   RunStatus = "RUNNING"
+	RunStatusSucceeded RunStatus = "SUCCEEDED"
+	RunStatusFailed    RunStatus = "FAILED"
+	RunStatusCancelled RunStatus = "CANCELLED"
+	// RunStatusPaused runs stopped because a usage budget was exhausted, their
+	// remaining commits stay pending for the next run
+	RunStatusPaused RunStatus = "PAUSED"
+)
+
+type CommitCounts struct {
+	Total    int64
+	Analyzed int64
+	Skipped  int64
+	Failed   int64
+	// Cached counts the analyzed commits answered from the analysis cache
+	Cached int64
+}
+
+// CacheHitRate
+// Share of the analyzed commits answered from the analysis cache, 0 when none were analyzed
+func (c CommitCounts) CacheHitRate() float64 {
+	if c.Analyzed == 0 {
+		return 0
+	}
+	return float64(c.Cached) / float64(c.Analyzed)
+}
+
+type Run struct {
+	id         int64
+	repoID     int64
+	status     RunStatus
+	headSHA    string
+	counts     CommitCounts
+	usage      agent.Usage
+	err        string
+	startedAt  time.Time
+	finishedAt time.Time
+}
+
+func NewRun(repoID int64, startedAt time.Time) *Run {
+	return &Run{repoID: repoID, status: RunStatusRunning, startedAt: startedAt}
+}
+
+func NewRunFromDB(id, repoID int64, status RunStatus, headSHA string, counts CommitCounts, usage agent.Usage, err string, startedAt, finishedAt time.Time) *Run {
+	return &Run{id, repoID, status, headSHA, counts, usage, err, startedAt, finishedAt}
+}
+
+// Finish
+// Records the outcome of the pipeline. A non-nil err marks the run as failed
+// (cancelled when it wraps ErrAnalysisCancelled, paused when it wraps
+// ErrBudgetExceeded) and keeps its message so it can be inspected after the fact.
+func (r *Run) Finish(headSHA string, counts CommitCounts, usage agent.Usage, err error, finishedAt time.Time) {
+	r.headSHA = headSHA
+	r.counts = counts
+	r.usage = usage
+	r.finishedAt = finishedAt
+	r.status = RunStatusSucceeded
+	if err != nil {
+		r.status = RunStatusFailed
+		if errors.Is(err, ErrAnalysisCancelled) {
+			r.status = RunStatusCancelled
+		} else if errors.Is(err, ErrBudgetExceeded) {
+			r.status = RunStatusPaused
+		}
+		r.err = err.Error()
+	}
+}
+
+func (r *Run) ID() int64 {
+	return r.id
+}
+
+func (r *Run) SetID(id int64) {
+	r.id = id
+}
+
+func (r *Run) RepoID() int64 {
+	return r.repoID
+}
+
+func (r *Run) Status() RunStatus {
+	return r.status
+}
+
+func (r *Run) IsFinished() bool {
+	return r.status != RunStatusRunning
+}
+
+func (r *Run) HeadSHA() string {
+	return r.headSHA
+}
+
+func (r *Run) Counts() CommitCounts {
+	return r.counts
+}
+
+func (r *Run) Usage() agent.Usage {
+	return r.usage
+}
+
+func (r *Run) Error() string {
+	return r.err
+}
+
+func (r *Run) StartedAt() time.Time {
+	return r.startedAt
+}
+
+// FinishedAt
+// Zero while the run is still in progress
+func (r *Run) FinishedAt() time.Time {
+	return r.finishedAt
+}
@@ -14,9 +14,93 @@ var (
 	ErrDiffFetchFailed = errors.New("failed to fetch commit diff")
 )
 
+// CommitReference
+// A commit as far as it is known. Listings fill in what their endpoint returns, commits
+// resent from stored analysis state only carry SHA and CommittedAt.
 type CommitReference struct {
 	SHA         string
 	CommittedAt time.Time
+	Message     string
+	Author      CommitAuthor
+	Parents     []string
+}
+
+// CommitAuthor
+// Name and Email come from the commit itself, Login and AvatarURL from the account the
+// code host linked it to, when it does
+type CommitAuthor struct {
+	Name      string
+	Email     string
+	Login     string
+	AvatarURL string
+}
+
+// String
+// "Name <email>", or whichever of the two is known
+func (a CommitAuthor) String() string {
+	switch {
+	case a.Name == "":
+		return a.Email
+	case a.Email == "":
+		return a.Name
+	}
+	return a.Name + " <" + a.Email + ">"
+}
+
+// CommitDiff
+// The patch of a commit, with the commit as the code host reports it alongside
+type CommitDiff struct {
+	CommitReference
+	// Text is either "File: <path>" sections or a git diff, see SplitDiff
+	Text  string
+	Files []FileStat
+}
+
+type FileStatus string
+
+const (
+	FileAdded    FileStatus = "added"
+	FileModified FileStatus = "modified"
+	FileRemoved  FileStatus = "removed"
+	FileRenamed  FileStatus = "renamed"
+)
+
+// FileStat
+// What a commit did to one file
+type FileStat struct {
+	Path         string
+	PreviousPath string
+	Status       FileStatus
+	Additions    int
+	Deletions    int
+}
+
+// PullRequest
+// A pull request, or merge request, a commit was merged through. MergedAt is zero
+// for pull requests that are not merged.
+type PullRequest struct {
+	Number   int
+	Title    string
+	Body     string
+	URL      string
+	MergedAt time.Time
+}
+
+// PreferredPullRequest
+// The pull request a commit most likely landed through: the first one merged, the
+// first one listed when none is, nil when there are none
+func PreferredPullRequest(pullRequests []PullRequest) *PullRequest {
+	var preferred *PullRequest
+	for i := range pullRequests {
+		pr := &pullRequests[i]
+		switch {
+		case preferred == nil:
+			preferred = pr
+		case !pr.MergedAt.IsZero() && (preferred.MergedAt.IsZero() || pr.MergedAt.Before(preferred.MergedAt)):
+			preferred = pr
+		}
+	}
+	return preferred
 }
 
 type UserProfile struct {
@@ -44,7 +128,10 @@ type CodeHost interface {
 	// at repo.LastAnalyzedCommitSHA() (exclusive). Returns the head SHA (first commit
 	// sent) or "" if no commits were sent.
 	GetRepoCommitSHAsIntoChannel(ctx context.Context, repo *repo.Repo, commits chan<- CommitReference) (headSHA string, err error)
-	GetCommitDiff(ctx context.Context, repo *repo.Repo, commitSHA string) (string, error)
+	GetCommitDiff(ctx context.Context, repo *repo.Repo, commitSHA string) (*CommitDiff, error)
+	// GetCommitPullRequest returns the pull request the commit was merged through, see
+	// PreferredPullRequest, or nil when it was pushed directly
+	GetCommitPullRequest(ctx context.Context, repo *repo.Repo, commitSHA string) (*PullRequest, error)
 	GetAuthenticatedUser(ctx context.Context) (*UserProfile, error)
 	SearchRepositories(ctx context.Context, query string) ([]RepoSearchResult, error)
 }
@@ -0,0 +1,27 @@
+package codehost
+
+import (
+	"context"
+	"strings"
+)
+
+type hostTokensKey struct{}
+
+// WithHostTokens
+// Returns a context carrying the caller's own tokens for code hosts other than the one
+// they signed in with, keyed by host. The sign-in token must never be sent to those
+// hosts: it belongs to another server.
+func WithHostTokens(ctx context.Context, tokens map[string]string) context.Context {
+	normalized := make(map[string]string, len(tokens))
+	for host, token := range tokens {
+		normalized[strings.ToLower(host)] = token
+	}
+	return context.WithValue(ctx, hostTokensKey{}, normalized)
+}
+
+// HostToken
+// The caller's token for host, empty when they have none
+func HostToken(ctx context.Context, host string) string {
+	tokens, _ := ctx.Value(hostTokensKey{}).(map[string]string)
+	return tokens[strings.ToLower(host)]
+}
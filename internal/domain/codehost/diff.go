@@ -0,0 +1,93 @@
+package codehost
+
+import (
+	"regexp"
+	"strings"
+)
+
+var gitDiffHeader = regexp.MustCompile(`^diff --git a/.+ b/(.+)$`)
+
+// DiffFile
+// The section of a commit diff touching one file, header line included
+type DiffFile struct {
+	Path string
+	Text string
+}
+
+// SplitDiff
+// Cuts a diff at every file header, "File: <path>" from the API code hosts or
+// "diff --git" from git. The preamble is the text preceding the first header.
+func SplitDiff(diff string) (string, []DiffFile) {
+	var preamble string
+	var files []DiffFile
+	var current strings.Builder
+	var path string
+	started := false
+
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		if header, ok := fileHeaderPath(line); ok {
+			if started {
+				files = append(files, DiffFile{Path: path, Text: current.String()})
+			} else {
+				preamble = current.String()
+			}
+			current.Reset()
+			path = header
+			started = true
+		}
+		current.WriteString(line)
+	}
+
+	if started {
+		files = append(files, DiffFile{Path: path, Text: current.String()})
+	} else {
+		preamble = current.String()
+	}
+	return preamble, files
+}
+
+func fileHeaderPath(line string) (string, bool) {
+	line = strings.TrimRight(line, "\r\n")
+	if path, ok := strings.CutPrefix(line, "File: "); ok {
+		return path, true
+	}
+	if strings.HasPrefix(line, "diff --git ") {
+		if m := gitDiffHeader.FindStringSubmatch(line); m != nil {
+			return m[1], true
+		}
+		return "", true
+	}
+	return "", false
+}
+
+// DiffStats
+// Per-file stats counted from the diff, for code hosts that do not report them. Git
+// diffs tell added, removed and renamed files apart; "File:" sections are taken as
+// modified unless the code host knows better.
+func DiffStats(diff string) []FileStat {
+	_, files := SplitDiff(diff)
+	stats := make([]FileStat, 0, len(files))
+	for _, file := range files {
+		stat := FileStat{Path: file.Path, Status: FileModified}
+		inHunk := false
+		for _, line := range strings.Split(file.Text, "\n") {
+			switch {
+			case strings.HasPrefix(line, "@@"):
+				inHunk = true
+			case !inHunk && strings.HasPrefix(line, "new file mode"):
+				stat.Status = FileAdded
+			case !inHunk && strings.HasPrefix(line, "deleted file mode"):
+				stat.Status = FileRemoved
+			case !inHunk && strings.HasPrefix(line, "rename from "):
+				stat.Status = FileRenamed
+				stat.PreviousPath = strings.TrimPrefix(line, "rename from ")
+			case inHunk && strings.HasPrefix(line, "+"):
+				stat.Additions++
+			case inHunk && strings.HasPrefix(line, "-"):
+				stat.Deletions++
+			}
+		}
+		stats = append(stats, stat)
+	}
+	return stats
+}
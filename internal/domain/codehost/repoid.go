@@ -0,0 +1,24 @@
+package codehost
+
+import (
+	"hash/fnv"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// HashRepoID
+// A positive repository ID derived from key, for repositories whose code host assigns
+// none that is unique across hosts
+func HashRepoID(key string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return int64(h.Sum64() & math.MaxInt64)
+}
+
+// HostRepoID
+// Self-hosted instances number their repositories sequentially, so their IDs collide
+// with each other and with github.com ones unless namespaced by host
+func HostRepoID(host string, id int64) int64 {
+	return HashRepoID(strings.ToLower(host) + ":" + strconv.FormatInt(id, 10))
+}
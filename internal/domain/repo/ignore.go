@@ -0,0 +1,186 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var ErrInvalidIgnorePattern = errors.New("invalid ignore pattern")
+
+// DefaultIgnorePatterns
+// Lock files, vendored dependencies and generated or minified output: they cost
+// tokens without saying anything about the intent of a commit
+var DefaultIgnorePatterns = []string{
+	"package-lock.json",
+	"yarn.lock",
+	"pnpm-lock.yaml",
+	"go.sum",
+	"Cargo.lock",
+	"poetry.lock",
+	"Gemfile.lock",
+	"composer.lock",
+	"vendor/",
+	"node_modules/",
+	"third_party/",
+	"dist/",
+	"*.min.js",
+	"*.min.css",
+	"*.map",
+	"*.pb.go",
+	"*_generated.go",
+	"*.snap",
+}
+
+type ignoreRule struct {
+	pattern *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// IgnoreRules
+// Compiled gitignore-style patterns, matched against slash-separated paths relative
+// to the repository root
+type IgnoreRules struct {
+	rules []ignoreRule
+}
+
+// CompileIgnorePatterns
+// Understands the gitignore syntax: blank lines and "#" comments are skipped, "!"
+// re-includes, a trailing "/" only matches directories, a "/" anywhere else anchors
+// the pattern to the root, and "*", "?", "[...]" and "**" work as in git
+func CompileIgnorePatterns(patterns []string) (*IgnoreRules, error) {
+	rules := make([]ignoreRule, 0, len(patterns))
+	for _, pattern := range patterns {
+		rule, ok, err := compileIgnorePattern(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %v", ErrInvalidIgnorePattern, pattern, err)
+		}
+		if ok {
+			rules = append(rules, rule)
+		}
+	}
+	return &IgnoreRules{rules: rules}, nil
+}
+
+func compileIgnorePattern(pattern string) (ignoreRule, bool, error) {
+	var rule ignoreRule
+
+	pattern = strings.TrimRight(pattern, " \t\r")
+	if pattern == "" || strings.HasPrefix(pattern, "#") {
+		return rule, false, nil
+	}
+
+	if strings.HasPrefix(pattern, "!") {
+		rule.negate = true
+		pattern = pattern[1:]
+	} else if strings.HasPrefix(pattern, `\!`) || strings.HasPrefix(pattern, `\#`) {
+		pattern = pattern[1:]
+	}
+
+	if strings.HasSuffix(pattern, "/") {
+		rule.dirOnly = true
+		pattern = strings.TrimRight(pattern, "/")
+	}
+
+	if pattern == "" {
+		return rule, false, errors.New("empty pattern")
+	}
+
+	prefix := `^(?:.*/)?`
+	if strings.Contains(pattern, "/") {
+		prefix = "^"
+		pattern = strings.TrimPrefix(pattern, "/")
+	}
+
+	expr, err := globToRegexp(pattern)
+	if err != nil {
+		return rule, false, err
+	}
+
+	rule.pattern, err = regexp.Compile(prefix + expr + "$")
+	return rule, true, err
+}
+
+func globToRegexp(glob string) (string, error) {
+	var expr strings.Builder
+
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				atStart := i == 0 || glob[i-1] == '/'
+				switch {
+				case atStart && i+2 < len(glob) && glob[i+2] == '/':
+					expr.WriteString(`(?:.*/)?`)
+					i += 2
+				case atStart && i+2 == len(glob):
+					expr.WriteString(`.*`)
+					i++
+				default:
+					expr.WriteString(`[^/]*`)
+					i++
+				}
+				continue
+			}
+			expr.WriteString(`[^/]*`)
+		case '?':
+			expr.WriteString(`[^/]`)
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end == 0 && i+2 < len(glob) {
+				// A "]" right after the opening bracket is part of the set
+				end = strings.IndexByte(glob[i+2:], ']') + 1
+				if end == 0 {
+					end = -1
+				}
+			}
+			if end < 0 {
+				return "", errors.New("unterminated character class")
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+			}
+			expr.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	return expr.String(), nil
+}
+
+// Ignores
+// Whether path is ignored. As in git, a file inside an ignored directory cannot be
+// re-included; otherwise the last matching pattern decides.
+func (r *IgnoreRules) Ignores(path string) bool {
+	path = strings.TrimPrefix(path, "/")
+
+	for i := 0; i < len(path); i++ {
+		if path[i] == '/' && r.matches(path[:i], true) {
+			return true
+		}
+	}
+	return r.matches(path, false)
+}
+
+func (r *IgnoreRules) matches(path string, isDir bool) bool {
+	ignored := false
+	for _, rule := range r.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.pattern.MatchString(path) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
@@ -0,0 +1,207 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var ErrInvalidIssueTracker = errors.New("invalid issue tracker")
+
+// IssueTracker
+// Where references of one kind lead. Pattern is a regular expression for the reference
+// as it is written; URLTemplate builds its link, with {ref} replaced by the reference,
+// {1}, {2}... by the groups of Pattern, {repo} by the repository URL and {host} by the
+// scheme and host of it.
+type IssueTracker struct {
+	Pattern     string
+	URLTemplate string
+}
+
+// DefaultIssueTrackers
+// The issues of the code host: "#123" in the repository itself and "owner/repo#123"
+// in another repository of the same host
+var DefaultIssueTrackers = []IssueTracker{
+	{Pattern: `([\w.-]+/[\w.-]+)#(\d+)`, URLTemplate: "{host}/{1}/issues/{2}"},
+	{Pattern: `#(\d+)`, URLTemplate: "{repo}/issues/{1}"},
+}
+
+// IssueReference
+// An issue a commit mentions, Closes when it does so after a closing keyword like
+// "fixes"
+type IssueReference struct {
+	Ref    string
+	URL    string
+	Closes bool
+}
+
+var (
+	templatePlaceholder = regexp.MustCompile(`\{(\w+)\}`)
+	closingKeyword      = regexp.MustCompile(`(?i)\b(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?):?\s+$`)
+)
+
+type issueTracker struct {
+	pattern     *regexp.Regexp
+	urlTemplate string
+}
+
+// IssueMatcher
+// Compiled issue trackers
+type IssueMatcher struct {
+	trackers []issueTracker
+}
+
+// CompileIssueTrackers
+// Rejects patterns that do not compile or match the empty string, and templates that
+// are empty, name a group the pattern does not have or do not build an http(s) link,
+// since links are followed by whoever browses the timeline
+func CompileIssueTrackers(trackers []IssueTracker) (*IssueMatcher, error) {
+	compiled := make([]issueTracker, 0, len(trackers))
+	for _, tracker := range trackers {
+		pattern, err := compileIssueTracker(tracker)
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %v", ErrInvalidIssueTracker, tracker.Pattern, err)
+		}
+		compiled = append(compiled, issueTracker{pattern: pattern, urlTemplate: tracker.URLTemplate})
+	}
+	return &IssueMatcher{trackers: compiled}, nil
+}
+
+func compileIssueTracker(tracker IssueTracker) (*regexp.Regexp, error) {
+	if strings.TrimSpace(tracker.Pattern) == "" {
+		return nil, errors.New("empty pattern")
+	}
+
+	pattern, err := regexp.Compile(tracker.Pattern)
+	if err != nil {
+		return nil, err
+	}
+	if pattern.MatchString("") {
+		return nil, errors.New("pattern matches an empty reference")
+	}
+
+	if strings.TrimSpace(tracker.URLTemplate) == "" {
+		return nil, errors.New("empty URL template")
+	}
+	for _, placeholder := range templatePlaceholder.FindAllStringSubmatch(tracker.URLTemplate, -1) {
+		group, err := strconv.Atoi(placeholder[1])
+		if err == nil && (group < 1 || group > pattern.NumSubexp()) {
+			return nil, fmt.Errorf("URL template names group %d of a pattern with %d", group, pattern.NumSubexp())
+		}
+	}
+	if !isWebURLTemplate(tracker.URLTemplate) {
+		return nil, errors.New("URL template must start with {repo}, {host} or http(s)://")
+	}
+	return pattern, nil
+}
+
+// isWebURLTemplate
+// Templates starting with {repo} or {host} extend the repository URL. Any other must
+// be an http(s) URL whatever the placeholders expand to.
+func isWebURLTemplate(template string) bool {
+	template = strings.TrimSpace(template)
+	if strings.HasPrefix(template, "{repo}") || strings.HasPrefix(template, "{host}") {
+		return true
+	}
+
+	parsed, err := url.Parse(templatePlaceholder.ReplaceAllString(template, "x"))
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// References
+// The issues mentioned in texts, in the order they first appear. A reference is only
+// taken when it is not glued to a word, so "#1" is not found again in "owner/repo#1".
+func (m *IssueMatcher) References(repoURL string, texts ...string) []IssueReference {
+	type mention struct {
+		start     int
+		reference IssueReference
+	}
+
+	var references []IssueReference
+	index := make(map[string]int)
+	for _, text := range texts {
+		var mentions []mention
+		for _, tracker := range m.trackers {
+			for _, match := range tracker.pattern.FindAllStringSubmatchIndex(text, -1) {
+				start, end := match[0], match[1]
+				if !standsAlone(text, start, end) {
+					continue
+				}
+				ref := text[start:end]
+				mentions = append(mentions, mention{start: start, reference: IssueReference{
+					Ref:    ref,
+					URL:    expandIssueURL(tracker.urlTemplate, ref, text, match, repoURL),
+					Closes: closingKeyword.MatchString(text[:start]),
+				}})
+			}
+		}
+		sort.SliceStable(mentions, func(i, j int) bool { return mentions[i].start < mentions[j].start })
+
+		for _, mention := range mentions {
+			if i, ok := index[mention.reference.Ref]; ok {
+				references[i].Closes = references[i].Closes || mention.reference.Closes
+				continue
+			}
+			index[mention.reference.Ref] = len(references)
+			references = append(references, mention.reference)
+		}
+	}
+	return references
+}
+
+func standsAlone(text string, start, end int) bool {
+	before, _ := utf8.DecodeLastRuneInString(text[:start])
+	after, _ := utf8.DecodeRuneInString(text[end:])
+	return !isWordRune(before) && !isWordRune(after)
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func expandIssueURL(template, ref, text string, match []int, repoURL string) string {
+	return templatePlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		name := placeholder[1 : len(placeholder)-1]
+		switch name {
+		case "ref":
+			return ref
+		case "repo":
+			return strings.TrimSuffix(repoURL, "/")
+		case "host":
+			return hostURL(repoURL)
+		}
+
+		group, err := strconv.Atoi(name)
+		if err != nil {
+			return placeholder
+		}
+		if match[2*group] < 0 {
+			return ""
+		}
+		return text[match[2*group]:match[2*group+1]]
+	})
+}
+
+func hostURL(repoURL string) string {
+	parsed, err := url.Parse(repoURL)
+	if err != nil || parsed.Host == "" {
+		return ""
+	}
+	return parsed.Scheme + "://" + parsed.Host
+}
+
+// NormalizeIssueRef
+// A bare issue number stands for "#number", since "#" has to be escaped in URLs
+func NormalizeIssueRef(ref string) string {
+	ref = strings.TrimSpace(ref)
+	if _, err := strconv.ParseUint(ref, 10, 64); err == nil {
+		return "#" + ref
+	}
+	return ref
+}
@@ -1,6 +1,10 @@
 package repo
 
-import "time"
+import (
+	"time"
+
+	"github.com/octokerbs/chronocode/internal/domain/agent"
+)
 
 type Repo struct {
 	id                    int64
@@ -8,10 +12,18 @@ type Repo struct {
 	url                   string
 	lastAnalyzedCommitSHA string
 	createdAt             time.Time
+	// ignorePatterns is nil while the repository uses DefaultIgnorePatterns
+	ignorePatterns  []string
+	budget          agent.Budget
+	promptOverrides agent.PromptOverrides
+	// modificationTypes is nil while the repository uses agent.DefaultModificationTypes
+	modificationTypes []string
+	// issueTrackers is nil while the repository uses DefaultIssueTrackers
+	issueTrackers []IssueTracker
 }
 
 func NewRepo(id int64, name, url, lastAnalyzedCommit string, createdAt time.Time) *Repo {
-	return &Repo{id, name, url, lastAnalyzedCommit, createdAt}
+	return &Repo{id: id, name: name, url: url, lastAnalyzedCommitSHA: lastAnalyzedCommit, createdAt: createdAt}
 }
 
 // IsURL
@@ -43,3 +55,88 @@ func (r *Repo) LastAnalyzedCommitSHA() string {
 func (r *Repo) SetLastAnalyzedCommitSHA(sha string) {
 	r.lastAnalyzedCommitSHA = sha
 }
+
+// IgnorePatterns
+// Gitignore-style patterns of the files left out of the analysis
+func (r *Repo) IgnorePatterns() []string {
+	if r.ignorePatterns == nil {
+		return DefaultIgnorePatterns
+	}
+	return r.ignorePatterns
+}
+
+func (r *Repo) HasCustomIgnorePatterns() bool {
+	return r.ignorePatterns != nil
+}
+
+// SetIgnorePatterns
+// nil restores the defaults, an empty list ignores nothing
+func (r *Repo) SetIgnorePatterns(patterns []string) {
+	r.ignorePatterns = patterns
+}
+
+// Budget
+// Caps what analyzing this repository may spend, the zero value is unlimited
+func (r *Repo) Budget() agent.Budget {
+	return r.budget
+}
+
+func (r *Repo) SetBudget(budget agent.Budget) {
+	r.budget = budget
+}
+
+// PromptOverrides
+// What this repository adds to the analysis prompt
+func (r *Repo) PromptOverrides() agent.PromptOverrides {
+	return r.promptOverrides
+}
+
+func (r *Repo) SetPromptOverrides(overrides agent.PromptOverrides) {
+	r.promptOverrides = overrides
+}
+
+// ModificationTypes
+// The taxonomy subcommits of this repository are classified with
+func (r *Repo) ModificationTypes() []string {
+	if r.modificationTypes == nil {
+		return agent.DefaultModificationTypes
+	}
+	return r.modificationTypes
+}
+
+func (r *Repo) HasCustomModificationTypes() bool {
+	return r.modificationTypes != nil
+}
+
+// SetModificationTypes
+// nil restores the defaults
+func (r *Repo) SetModificationTypes(types []string) {
+	r.modificationTypes = types
+}
+
+// IssueTrackers
+// The trackers issue references in the commits of this repository are resolved with
+func (r *Repo) IssueTrackers() []IssueTracker {
+	if r.issueTrackers == nil {
+		return DefaultIssueTrackers
+	}
+	return r.issueTrackers
+}
+
+func (r *Repo) HasCustomIssueTrackers() bool {
+	return r.issueTrackers != nil
+}
+
+// SetIssueTrackers
+// nil restores the defaults
+func (r *Repo) SetIssueTrackers(trackers []IssueTracker) {
+	r.issueTrackers = trackers
+}
+
+// AnalysisPrompt
+// The prompt overrides together with the taxonomy, as the agent receives them
+func (r *Repo) AnalysisPrompt() agent.PromptOverrides {
+	overrides := r.promptOverrides
+	overrides.ModificationTypes = r.modificationTypes
+	return overrides
+}
@@ -3,6 +3,8 @@ package repo
 import (
 	"context"
 	"errors"
+
+	"github.com/octokerbs/chronocode/internal/domain/agent"
 )
 
 var (
@@ -14,4 +16,17 @@ type Repository interface {
 	GetRepoByID(ctx context.Context, id int64) (*Repo, error)
 	ListRepos(ctx context.Context) ([]*Repo, error)
 	StoreRepo(ctx context.Context, aRepo *Repo) error
+	// UpdateIgnorePatterns stores patterns apart from StoreRepo, so that a running
+	// analysis storing its repository does not revert them. nil restores the defaults.
+	UpdateIgnorePatterns(ctx context.Context, id int64, patterns []string) error
+	// UpdateBudget stores the budget apart from StoreRepo, like UpdateIgnorePatterns.
+	UpdateBudget(ctx context.Context, id int64, budget agent.Budget) error
+	// UpdatePromptOverrides stores the overrides apart from StoreRepo, like UpdateIgnorePatterns.
+	UpdatePromptOverrides(ctx context.Context, id int64, overrides agent.PromptOverrides) error
+	// UpdateModificationTypes stores the taxonomy apart from StoreRepo, like UpdateIgnorePatterns.
+	// nil restores the defaults.
+	UpdateModificationTypes(ctx context.Context, id int64, types []string) error
+	// UpdateIssueTrackers stores the trackers apart from StoreRepo, like UpdateIgnorePatterns.
+	// nil restores the defaults.
+	UpdateIssueTrackers(ctx context.Context, id int64, trackers []IssueTracker) error
 }
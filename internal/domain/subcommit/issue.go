@@ -0,0 +1,10 @@
+package subcommit
+
+// Issue
+// An issue the commit of a subcommit refers to, like "#123", "owner/repo#9" or
+// "JIRA-456". URL is empty when the tracker of the reference is unknown.
+type Issue struct {
+	Ref    string
+	URL    string
+	Closes bool
+}
@@ -0,0 +1,95 @@
+package subcommit
+
+import (
+	"slices"
+	"sort"
+	"time"
+)
+
+// PullRequest
+// The pull request, or merge request, a commit was merged through
+type PullRequest struct {
+	Number int
+	Title  string
+	URL    string
+	// MergedAt is zero for pull requests that were not merged
+	MergedAt time.Time
+}
+
+// PullRequestGroup
+// The subcommits of one pull request, or of one commit pushed without any
+type PullRequestGroup struct {
+	// PullRequest is nil for a commit pushed directly
+	PullRequest *PullRequest
+	CommitSHAs  []string
+	Subcommits  []Subcommit
+}
+
+// Date
+// When the group landed: the merge of its pull request, or its latest commit
+func (g PullRequestGroup) Date() time.Time {
+	if g.PullRequest != nil && !g.PullRequest.MergedAt.IsZero() {
+		return g.PullRequest.MergedAt
+	}
+
+	var latest time.Time
+	for _, sc := range g.Subcommits {
+		if sc.CommittedAt().After(latest) {
+			latest = sc.CommittedAt()
+		}
+	}
+	return latest
+}
+
+func (g PullRequestGroup) Additions() int {
+	var additions int
+	for _, sc := range g.Subcommits {
+		additions += sc.Additions()
+	}
+	return additions
+}
+
+func (g PullRequestGroup) Deletions() int {
+	var deletions int
+	for _, sc := range g.Subcommits {
+		deletions += sc.Deletions()
+	}
+	return deletions
+}
+
+func (g PullRequestGroup) Churn() int {
+	return g.Additions() + g.Deletions()
+}
+
+// GroupByPullRequest
+// Groups subcommits by the pull request of their commit, newest group first. Commits
+// without a pull request each get a group of their own so the timeline keeps them
+// in place.
+func GroupByPullRequest(subcommits []Subcommit) []PullRequestGroup {
+	groups := []PullRequestGroup{}
+	byKey := make(map[string]int)
+	for _, sc := range subcommits {
+		key := "commit:" + sc.CommitSHA()
+		if pr := sc.PullRequest(); pr != nil {
+			key = "pr:" + pr.URL
+		}
+
+		i, ok := byKey[key]
+		if !ok {
+			i = len(groups)
+			byKey[key] = i
+			groups = append(groups, PullRequestGroup{PullRequest: sc.PullRequest()})
+		}
+
+		group := &groups[i]
+		if !slices.Contains(group.CommitSHAs, sc.CommitSHA()) {
+			group.CommitSHAs = append(group.CommitSHAs, sc.CommitSHA())
+		}
+		group.Subcommits = append(group.Subcommits, sc)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Date().After(groups[j].Date())
+	})
+	return groups
+}
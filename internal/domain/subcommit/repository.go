@@ -4,6 +4,8 @@ import "context"
 
 type Repository interface {
 	GetSubcommits(ctx context.Context, repoID int64) ([]Subcommit, error)
+	// GetSubcommitsByIssue returns the subcommits linked to the issue with this reference
+	GetSubcommitsByIssue(ctx context.Context, repoID int64, ref string) ([]Subcommit, error)
 	HasSubcommitsForCommit(ctx context.Context, repoID int64, commitSHA string) (bool, error)
 	StoreSubcommits(ctx context.Context, subcommits <-chan Subcommit) error
 }
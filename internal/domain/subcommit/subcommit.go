@@ -1,6 +1,9 @@
 package subcommit
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 type Subcommit struct {
 	id               int64
@@ -13,9 +16,53 @@ type Subcommit struct {
 	files            []string
 	repoID           int64
 	committedAt      time.Time
+	agent            string
+	promptVersion    string
+	author           Author
+	fileStats        []FileStat
+	pullRequest      *PullRequest
+	issues           []Issue
 }
 
-func NewSubcommit(title, idea, description, epic, modificationType, commitSHA string, files []string, repoID int64, committedAt time.Time) Subcommit {
+// FileStat
+// Line counts of one of the files of a subcommit, as the code host reported them for
+// the whole commit
+type FileStat struct {
+	Path string
+	// PreviousPath is set for renamed files
+	PreviousPath string
+	// Status is added, modified, removed or renamed
+	Status    string
+	Additions int
+	Deletions int
+}
+
+// Author
+// Who made the commit. Login and AvatarURL are only known on code hosts that link
+// commits to accounts, and are what ties the different emails of one person together.
+type Author struct {
+	Name      string
+	Email     string
+	Login     string
+	AvatarURL string
+}
+
+// Matches
+// Whether identity is the login, email or name of the author, ignoring case
+func (a Author) Matches(identity string) bool {
+	identity = strings.TrimSpace(identity)
+	if identity == "" {
+		return false
+	}
+	for _, known := range []string{a.Login, a.Email, a.Name} {
+		if known != "" && strings.EqualFold(known, identity) {
+			return true
+		}
+	}
+	return false
+}
+
+func NewSubcommit(title, idea, description, epic, modificationType, commitSHA string, files []string, repoID int64, committedAt time.Time, agent, promptVersion string, author Author, fileStats []FileStat, pullRequest *PullRequest, issues []Issue) Subcommit {
 	return Subcommit{
 		title:            title,
 		idea:             idea,
@@ -26,11 +73,17 @@ func NewSubcommit(title, idea, description, epic, modificationType, commitSHA st
 		files:            files,
 		repoID:           repoID,
 		committedAt:      committedAt,
+		agent:            agent,
+		promptVersion:    promptVersion,
+		author:           author,
+		fileStats:        fileStats,
+		pullRequest:      pullRequest,
+		issues:           issues,
 	}
 }
 
-func NewSubcommitFromDB(id int64, title, idea, description, epic, modificationType, commitSHA string, files []string, repoID int64, committedAt time.Time) Subcommit {
-	sc := NewSubcommit(title, idea, description, epic, modificationType, commitSHA, files, repoID, committedAt)
+func NewSubcommitFromDB(id int64, title, idea, description, epic, modificationType, commitSHA string, files []string, repoID int64, committedAt time.Time, agent, promptVersion string, author Author, fileStats []FileStat, pullRequest *PullRequest, issues []Issue) Subcommit {
+	sc := NewSubcommit(title, idea, description, epic, modificationType, commitSHA, files, repoID, committedAt, agent, promptVersion, author, fileStats, pullRequest, issues)
 	sc.id = id
 	return sc
 }
@@ -74,3 +127,70 @@ func (s *Subcommit) CommitSHA() string {
 func (s *Subcommit) CommittedAt() time.Time {
 	return s.committedAt
 }
+
+// Agent
+// Name of the agent that produced the subcommit, empty when only one agent is configured
+func (s *Subcommit) Agent() string {
+	return s.agent
+}
+
+// PromptVersion
+// Version of the prompt the subcommit was produced with, empty for agents without one
+func (s *Subcommit) PromptVersion() string {
+	return s.promptVersion
+}
+
+func (s *Subcommit) Author() Author {
+	return s.author
+}
+
+// PullRequest
+// The pull request the commit was merged through, nil when it was pushed directly
+func (s *Subcommit) PullRequest() *PullRequest {
+	return s.pullRequest
+}
+
+// Issues
+// The issues the commit or its pull request mention
+func (s *Subcommit) Issues() []Issue {
+	return s.issues
+}
+
+// MentionsIssue
+// Whether the subcommit is linked to the issue with this reference
+func (s *Subcommit) MentionsIssue(ref string) bool {
+	for _, issue := range s.issues {
+		if issue.Ref == ref {
+			return true
+		}
+	}
+	return false
+}
+
+// FileStats
+// Stats of the files the code host reported, files it did not are left out
+func (s *Subcommit) FileStats() []FileStat {
+	return s.fileStats
+}
+
+func (s *Subcommit) Additions() int {
+	var additions int
+	for _, stat := range s.fileStats {
+		additions += stat.Additions
+	}
+	return additions
+}
+
+func (s *Subcommit) Deletions() int {
+	var deletions int
+	for _, stat := range s.fileStats {
+		deletions += stat.Deletions
+	}
+	return deletions
+}
+
+// Churn
+// Lines added and removed, a rough measure of the size of the subcommit
+func (s *Subcommit) Churn() int {
+	return s.Additions() + s.Deletions()
+}
@@ -6,11 +6,16 @@ import (
 	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/octokerbs/chronocode/internal/application"
 	"github.com/octokerbs/chronocode/internal/application/command"
 	"github.com/octokerbs/chronocode/internal/application/query"
+	"github.com/octokerbs/chronocode/internal/domain/agent"
 	"github.com/octokerbs/chronocode/internal/domain/analysis"
+	"github.com/octokerbs/chronocode/internal/domain/repo"
+	"github.com/octokerbs/chronocode/internal/ports/http/model"
 	"github.com/octokerbs/chronocode/internal/ports/http/utils"
 )
 
@@ -61,6 +66,66 @@ func (h *ApplicationHandler) AnalyzeRepoCommand(w http.ResponseWriter, r *http.R
 	})
 }
 
+func (h *ApplicationHandler) CancelAnalysisCommand(w http.ResponseWriter, r *http.Request) {
+	repoIDStr := r.PathValue("repoId")
+	repoID, err := strconv.ParseInt(repoIDStr, 10, 64)
+	if err != nil {
+		slog.Warn("Invalid repoId in cancel request", "repo_id_raw", repoIDStr, "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid repo_id"})
+		return
+	}
+
+	slog.Info("Cancelling repository analysis", "repo_id", repoID)
+
+	token := utils.AccessTokenFromContext(r.Context())
+	if err := h.application.Commands.CancelAnalysis.Handle(r.Context(), command.CancelAnalysis{
+		RepoID:      repoID,
+		AccessToken: token,
+	}); err != nil {
+		slog.Error("Failed to cancel repository analysis", "repo_id", repoID, "error", err)
+		utils.WriteError(w, err)
+		return
+	}
+
+	slog.Info("Repository analysis cancellation requested", "repo_id", repoID)
+
+	utils.WriteJSON(w, http.StatusAccepted, map[string]any{
+		"message": "analysis cancellation requested",
+		"repoId":  repoID,
+	})
+}
+
+func (h *ApplicationHandler) RetryFailedCommitsCommand(w http.ResponseWriter, r *http.Request) {
+	repoIDStr := r.PathValue("repoId")
+	repoID, err := strconv.ParseInt(repoIDStr, 10, 64)
+	if err != nil {
+		slog.Warn("Invalid repoId in retry request", "repo_id_raw", repoIDStr, "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid repo_id"})
+		return
+	}
+
+	slog.Info("Retrying failed commits", "repo_id", repoID)
+
+	token := utils.AccessTokenFromContext(r.Context())
+	runID, err := h.application.Commands.RetryFailedCommits.HandleAsync(r.Context(), command.RetryFailedCommits{
+		RepoID:      repoID,
+		AccessToken: token,
+	})
+	if err != nil {
+		slog.Error("Failed to retry failed commits", "repo_id", repoID, "error", err)
+		utils.WriteError(w, err)
+		return
+	}
+
+	slog.Info("Retry of failed commits started", "repo_id", repoID, "run_id", runID)
+
+	utils.WriteJSON(w, http.StatusAccepted, map[string]any{
+		"message": "retry of failed commits started",
+		"repoId":  repoID,
+		"runId":   runID,
+	})
+}
+
 func (h *ApplicationHandler) GetReposQuery(w http.ResponseWriter, r *http.Request) {
 	slog.Info("Listing all repositories")
 
@@ -87,12 +152,15 @@ func (h *ApplicationHandler) GetSubcommitsQuery(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	slog.Info("Fetching subcommits timeline", "repo_id", repoID)
+	author := r.URL.Query().Get("author")
+
+	slog.Info("Fetching subcommits timeline", "repo_id", repoID, "author", author)
 
 	token := utils.AccessTokenFromContext(r.Context())
 	result, err := h.application.Queries.GetSubcommits.Handle(r.Context(), query.GetSubcommits{
 		RepoID:      repoID,
 		AccessToken: token,
+		Author:      author,
 	})
 	if err != nil {
 		slog.Error("Failed to fetch subcommits timeline", "repo_id", repoID, "error", err)
@@ -112,6 +180,40 @@ func (h *ApplicationHandler) GetSubcommitsQuery(w http.ResponseWriter, r *http.R
 	})
 }
 
+func (h *ApplicationHandler) GetPullRequestTimelineQuery(w http.ResponseWriter, r *http.Request) {
+	repoIDStr := r.PathValue("repoId")
+	repoID, err := strconv.ParseInt(repoIDStr, 10, 64)
+	if err != nil {
+		slog.Warn("Invalid repoId in pull request timeline request", "repo_id_raw", repoIDStr, "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid repo_id"})
+		return
+	}
+
+	slog.Info("Fetching pull request timeline", "repo_id", repoID)
+
+	token := utils.AccessTokenFromContext(r.Context())
+	result, err := h.application.Queries.GetPullRequestTimeline.Handle(r.Context(), query.GetPullRequestTimeline{
+		RepoID:      repoID,
+		AccessToken: token,
+	})
+	if err != nil {
+		slog.Error("Failed to fetch pull request timeline", "repo_id", repoID, "error", err)
+		utils.WriteError(w, err)
+		return
+	}
+
+	isAnalyzing := h.application.Locker.IsLocked(r.Context(), result.RepoURL)
+
+	slog.Info("Pull request timeline fetched", "repo_id", repoID, "groups", len(result.Groups), "is_analyzing", isAnalyzing)
+
+	utils.WriteJSON(w, http.StatusOK, map[string]any{
+		"pullRequests": utils.MapPullRequestGroups(result.Groups),
+		"repoId":       repoIDStr,
+		"repoUrl":      result.RepoURL,
+		"isAnalyzing":  isAnalyzing,
+	})
+}
+
 func (h *ApplicationHandler) GetUserProfileQuery(w http.ResponseWriter, r *http.Request) {
 	slog.Info("Fetching user profile")
 
@@ -166,3 +268,538 @@ func (h *ApplicationHandler) SearchReposQuery(w http.ResponseWriter, r *http.Req
 		"repositories": repos,
 	})
 }
+
+func (h *ApplicationHandler) GetAnalysisRunsQuery(w http.ResponseWriter, r *http.Request) {
+	repoIDStr := r.URL.Query().Get("repo_id")
+	repoID, err := strconv.ParseInt(repoIDStr, 10, 64)
+	if err != nil {
+		slog.Warn("Invalid repo_id in analyses request", "repo_id_raw", repoIDStr, "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid repo_id"})
+		return
+	}
+
+	slog.Info("Listing analysis runs", "repo_id", repoID)
+
+	token := utils.AccessTokenFromContext(r.Context())
+	runs, err := h.application.Queries.GetAnalysisRuns.Handle(r.Context(), query.GetAnalysisRuns{
+		RepoID:      repoID,
+		AccessToken: token,
+	})
+	if err != nil {
+		slog.Error("Failed to list analysis runs", "repo_id", repoID, "error", err)
+		utils.WriteError(w, err)
+		return
+	}
+
+	slog.Info("Analysis runs listed", "repo_id", repoID, "count", len(runs))
+
+	utils.WriteJSON(w, http.StatusOK, map[string]any{
+		"analyses": utils.MapAnalysisRuns(runs),
+	})
+}
+
+func (h *ApplicationHandler) GetAnalysisRunQuery(w http.ResponseWriter, r *http.Request) {
+	runIDStr := r.PathValue("id")
+	runID, err := strconv.ParseInt(runIDStr, 10, 64)
+	if err != nil {
+		slog.Warn("Invalid analysis run id", "run_id_raw", runIDStr, "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid analysis id"})
+		return
+	}
+
+	slog.Info("Fetching analysis run", "run_id", runID)
+
+	token := utils.AccessTokenFromContext(r.Context())
+	run, err := h.application.Queries.GetAnalysisRun.Handle(r.Context(), query.GetAnalysisRun{
+		RunID:       runID,
+		AccessToken: token,
+	})
+	if err != nil {
+		slog.Error("Failed to fetch analysis run", "run_id", runID, "error", err)
+		utils.WriteError(w, err)
+		return
+	}
+
+	slog.Info("Analysis run fetched", "run_id", runID, "status", run.Status())
+
+	utils.WriteJSON(w, http.StatusOK, utils.MapAnalysisRun(run))
+}
+
+func (h *ApplicationHandler) GetIgnorePatternsQuery(w http.ResponseWriter, r *http.Request) {
+	repoIDStr := r.PathValue("repoId")
+	repoID, err := strconv.ParseInt(repoIDStr, 10, 64)
+	if err != nil {
+		slog.Warn("Invalid repoId in ignore patterns request", "repo_id_raw", repoIDStr, "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid repo_id"})
+		return
+	}
+
+	slog.Info("Fetching ignore patterns", "repo_id", repoID)
+
+	token := utils.AccessTokenFromContext(r.Context())
+	foundRepo, err := h.application.Queries.GetIgnorePatterns.Handle(r.Context(), query.GetIgnorePatterns{
+		RepoID:      repoID,
+		AccessToken: token,
+	})
+	if err != nil {
+		slog.Error("Failed to fetch ignore patterns", "repo_id", repoID, "error", err)
+		utils.WriteError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, utils.MapIgnorePatterns(foundRepo))
+}
+
+func (h *ApplicationHandler) SetIgnorePatternsCommand(w http.ResponseWriter, r *http.Request) {
+	repoIDStr := r.PathValue("repoId")
+	repoID, err := strconv.ParseInt(repoIDStr, 10, 64)
+	if err != nil {
+		slog.Warn("Invalid repoId in ignore patterns update", "repo_id_raw", repoIDStr, "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid repo_id"})
+		return
+	}
+
+	var body struct {
+		Patterns []string `json:"patterns"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Patterns == nil {
+		slog.Warn("Ignore patterns update failed - invalid request body", "repo_id", repoID, "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	h.setIgnorePatterns(w, r, repoID, body.Patterns)
+}
+
+func (h *ApplicationHandler) ResetIgnorePatternsCommand(w http.ResponseWriter, r *http.Request) {
+	repoIDStr := r.PathValue("repoId")
+	repoID, err := strconv.ParseInt(repoIDStr, 10, 64)
+	if err != nil {
+		slog.Warn("Invalid repoId in ignore patterns reset", "repo_id_raw", repoIDStr, "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid repo_id"})
+		return
+	}
+
+	h.setIgnorePatterns(w, r, repoID, nil)
+}
+
+func (h *ApplicationHandler) setIgnorePatterns(w http.ResponseWriter, r *http.Request, repoID int64, patterns []string) {
+	slog.Info("Updating ignore patterns", "repo_id", repoID, "patterns", len(patterns), "defaults", patterns == nil)
+
+	token := utils.AccessTokenFromContext(r.Context())
+	updated, err := h.application.Commands.SetIgnorePatterns.Handle(r.Context(), command.SetIgnorePatterns{
+		RepoID:      repoID,
+		Patterns:    patterns,
+		AccessToken: token,
+	})
+	if err != nil {
+		slog.Error("Failed to update ignore patterns", "repo_id", repoID, "error", err)
+		utils.WriteError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, utils.MapIgnorePatterns(updated))
+}
+
+func (h *ApplicationHandler) GetUsageQuery(w http.ResponseWriter, r *http.Request) {
+	repoIDStr := r.PathValue("repoId")
+	repoID, err := strconv.ParseInt(repoIDStr, 10, 64)
+	if err != nil {
+		slog.Warn("Invalid repoId in usage request", "repo_id_raw", repoIDStr, "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid repo_id"})
+		return
+	}
+
+	slog.Info("Fetching repository usage", "repo_id", repoID)
+
+	token := utils.AccessTokenFromContext(r.Context())
+	repoUsage, err := h.application.Queries.GetUsage.Handle(r.Context(), query.GetUsage{
+		RepoID:      repoID,
+		AccessToken: token,
+	})
+	if err != nil {
+		slog.Error("Failed to fetch repository usage", "repo_id", repoID, "error", err)
+		utils.WriteError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, utils.MapUsage(repoUsage.Repo, repoUsage.Usage))
+}
+
+func (h *ApplicationHandler) SetBudgetCommand(w http.ResponseWriter, r *http.Request) {
+	repoIDStr := r.PathValue("repoId")
+	repoID, err := strconv.ParseInt(repoIDStr, 10, 64)
+	if err != nil {
+		slog.Warn("Invalid repoId in budget update", "repo_id_raw", repoIDStr, "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid repo_id"})
+		return
+	}
+
+	var body struct {
+		MaxTokens  int64   `json:"maxTokens"`
+		MaxCostUSD float64 `json:"maxCostUsd"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		slog.Warn("Budget update failed - invalid request body", "repo_id", repoID, "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	h.setBudget(w, r, repoID, agent.Budget{MaxTokens: body.MaxTokens, MaxCostUSD: body.MaxCostUSD})
+}
+
+func (h *ApplicationHandler) ResetBudgetCommand(w http.ResponseWriter, r *http.Request) {
+	repoIDStr := r.PathValue("repoId")
+	repoID, err := strconv.ParseInt(repoIDStr, 10, 64)
+	if err != nil {
+		slog.Warn("Invalid repoId in budget reset", "repo_id_raw", repoIDStr, "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid repo_id"})
+		return
+	}
+
+	h.setBudget(w, r, repoID, agent.Budget{})
+}
+
+func (h *ApplicationHandler) setBudget(w http.ResponseWriter, r *http.Request, repoID int64, budget agent.Budget) {
+	slog.Info("Updating budget", "repo_id", repoID, "max_tokens", budget.MaxTokens, "max_cost_usd", budget.MaxCostUSD)
+
+	token := utils.AccessTokenFromContext(r.Context())
+	updated, err := h.application.Commands.SetBudget.Handle(r.Context(), command.SetBudget{
+		RepoID:      repoID,
+		Budget:      budget,
+		AccessToken: token,
+	})
+	if err != nil {
+		slog.Error("Failed to update budget", "repo_id", repoID, "error", err)
+		utils.WriteError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, utils.MapBudget(updated))
+}
+
+func (h *ApplicationHandler) GetPromptOverridesQuery(w http.ResponseWriter, r *http.Request) {
+	repoIDStr := r.PathValue("repoId")
+	repoID, err := strconv.ParseInt(repoIDStr, 10, 64)
+	if err != nil {
+		slog.Warn("Invalid repoId in prompt overrides request", "repo_id_raw", repoIDStr, "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid repo_id"})
+		return
+	}
+
+	slog.Info("Fetching prompt overrides", "repo_id", repoID)
+
+	token := utils.AccessTokenFromContext(r.Context())
+	foundRepo, err := h.application.Queries.GetPromptOverrides.Handle(r.Context(), query.GetPromptOverrides{
+		RepoID:      repoID,
+		AccessToken: token,
+	})
+	if err != nil {
+		slog.Error("Failed to fetch prompt overrides", "repo_id", repoID, "error", err)
+		utils.WriteError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, utils.MapPromptOverrides(foundRepo))
+}
+
+func (h *ApplicationHandler) SetPromptOverridesCommand(w http.ResponseWriter, r *http.Request) {
+	repoIDStr := r.PathValue("repoId")
+	repoID, err := strconv.ParseInt(repoIDStr, 10, 64)
+	if err != nil {
+		slog.Warn("Invalid repoId in prompt overrides update", "repo_id_raw", repoIDStr, "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid repo_id"})
+		return
+	}
+
+	var body struct {
+		Glossary     string `json:"glossary"`
+		TitleStyle   string `json:"titleStyle"`
+		Instructions string `json:"instructions"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		slog.Warn("Prompt overrides update failed - invalid request body", "repo_id", repoID, "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	h.setPromptOverrides(w, r, repoID, agent.PromptOverrides{Glossary: body.Glossary, TitleStyle: body.TitleStyle, Instructions: body.Instructions})
+}
+
+func (h *ApplicationHandler) ResetPromptOverridesCommand(w http.ResponseWriter, r *http.Request) {
+	repoIDStr := r.PathValue("repoId")
+	repoID, err := strconv.ParseInt(repoIDStr, 10, 64)
+	if err != nil {
+		slog.Warn("Invalid repoId in prompt overrides reset", "repo_id_raw", repoIDStr, "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid repo_id"})
+		return
+	}
+
+	h.setPromptOverrides(w, r, repoID, agent.PromptOverrides{})
+}
+
+func (h *ApplicationHandler) setPromptOverrides(w http.ResponseWriter, r *http.Request, repoID int64, overrides agent.PromptOverrides) {
+	slog.Info("Updating prompt overrides", "repo_id", repoID, "fingerprint", overrides.Fingerprint())
+
+	token := utils.AccessTokenFromContext(r.Context())
+	updated, err := h.application.Commands.SetPromptOverrides.Handle(r.Context(), command.SetPromptOverrides{
+		RepoID:      repoID,
+		Overrides:   overrides,
+		AccessToken: token,
+	})
+	if err != nil {
+		slog.Error("Failed to update prompt overrides", "repo_id", repoID, "error", err)
+		utils.WriteError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, utils.MapPromptOverrides(updated))
+}
+
+func (h *ApplicationHandler) GetModificationTypesQuery(w http.ResponseWriter, r *http.Request) {
+	repoIDStr := r.PathValue("repoId")
+	repoID, err := strconv.ParseInt(repoIDStr, 10, 64)
+	if err != nil {
+		slog.Warn("Invalid repoId in modification types request", "repo_id_raw", repoIDStr, "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid repo_id"})
+		return
+	}
+
+	slog.Info("Fetching modification types", "repo_id", repoID)
+
+	token := utils.AccessTokenFromContext(r.Context())
+	foundRepo, err := h.application.Queries.GetModificationTypes.Handle(r.Context(), query.GetModificationTypes{
+		RepoID:      repoID,
+		AccessToken: token,
+	})
+	if err != nil {
+		slog.Error("Failed to fetch modification types", "repo_id", repoID, "error", err)
+		utils.WriteError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, utils.MapModificationTypes(foundRepo))
+}
+
+func (h *ApplicationHandler) SetModificationTypesCommand(w http.ResponseWriter, r *http.Request) {
+	repoIDStr := r.PathValue("repoId")
+	repoID, err := strconv.ParseInt(repoIDStr, 10, 64)
+	if err != nil {
+		slog.Warn("Invalid repoId in modification types update", "repo_id_raw", repoIDStr, "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid repo_id"})
+		return
+	}
+
+	var body struct {
+		ModificationTypes []string `json:"modificationTypes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.ModificationTypes == nil {
+		slog.Warn("Modification types update failed - invalid request body", "repo_id", repoID, "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	h.setModificationTypes(w, r, repoID, body.ModificationTypes)
+}
+
+func (h *ApplicationHandler) ResetModificationTypesCommand(w http.ResponseWriter, r *http.Request) {
+	repoIDStr := r.PathValue("repoId")
+	repoID, err := strconv.ParseInt(repoIDStr, 10, 64)
+	if err != nil {
+		slog.Warn("Invalid repoId in modification types reset", "repo_id_raw", repoIDStr, "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid repo_id"})
+		return
+	}
+
+	h.setModificationTypes(w, r, repoID, nil)
+}
+
+func (h *ApplicationHandler) setModificationTypes(w http.ResponseWriter, r *http.Request, repoID int64, types []string) {
+	slog.Info("Updating modification types", "repo_id", repoID, "types", types, "defaults", types == nil)
+
+	token := utils.AccessTokenFromContext(r.Context())
+	updated, err := h.application.Commands.SetModificationTypes.Handle(r.Context(), command.SetModificationTypes{
+		RepoID:            repoID,
+		ModificationTypes: types,
+		AccessToken:       token,
+	})
+	if err != nil {
+		slog.Error("Failed to update modification types", "repo_id", repoID, "error", err)
+		utils.WriteError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, utils.MapModificationTypes(updated))
+}
+
+func (h *ApplicationHandler) GetIssueTrackersQuery(w http.ResponseWriter, r *http.Request) {
+	repoIDStr := r.PathValue("repoId")
+	repoID, err := strconv.ParseInt(repoIDStr, 10, 64)
+	if err != nil {
+		slog.Warn("Invalid repoId in issue trackers request", "repo_id_raw", repoIDStr, "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid repo_id"})
+		return
+	}
+
+	slog.Info("Fetching issue trackers", "repo_id", repoID)
+
+	token := utils.AccessTokenFromContext(r.Context())
+	foundRepo, err := h.application.Queries.GetIssueTrackers.Handle(r.Context(), query.GetIssueTrackers{
+		RepoID:      repoID,
+		AccessToken: token,
+	})
+	if err != nil {
+		slog.Error("Failed to fetch issue trackers", "repo_id", repoID, "error", err)
+		utils.WriteError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, utils.MapIssueTrackers(foundRepo))
+}
+
+func (h *ApplicationHandler) SetIssueTrackersCommand(w http.ResponseWriter, r *http.Request) {
+	repoIDStr := r.PathValue("repoId")
+	repoID, err := strconv.ParseInt(repoIDStr, 10, 64)
+	if err != nil {
+		slog.Warn("Invalid repoId in issue trackers update", "repo_id_raw", repoIDStr, "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid repo_id"})
+		return
+	}
+
+	var body struct {
+		IssueTrackers []model.IssueTrackerJSON `json:"issueTrackers"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.IssueTrackers == nil {
+		slog.Warn("Issue trackers update failed - invalid request body", "repo_id", repoID, "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	trackers := make([]repo.IssueTracker, len(body.IssueTrackers))
+	for i, tracker := range body.IssueTrackers {
+		trackers[i] = repo.IssueTracker(tracker)
+	}
+
+	h.setIssueTrackers(w, r, repoID, trackers)
+}
+
+func (h *ApplicationHandler) ResetIssueTrackersCommand(w http.ResponseWriter, r *http.Request) {
+	repoIDStr := r.PathValue("repoId")
+	repoID, err := strconv.ParseInt(repoIDStr, 10, 64)
+	if err != nil {
+		slog.Warn("Invalid repoId in issue trackers reset", "repo_id_raw", repoIDStr, "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid repo_id"})
+		return
+	}
+
+	h.setIssueTrackers(w, r, repoID, nil)
+}
+
+func (h *ApplicationHandler) setIssueTrackers(w http.ResponseWriter, r *http.Request, repoID int64, trackers []repo.IssueTracker) {
+	slog.Info("Updating issue trackers", "repo_id", repoID, "trackers", len(trackers), "defaults", trackers == nil)
+
+	token := utils.AccessTokenFromContext(r.Context())
+	updated, err := h.application.Commands.SetIssueTrackers.Handle(r.Context(), command.SetIssueTrackers{
+		RepoID:        repoID,
+		IssueTrackers: trackers,
+		AccessToken:   token,
+	})
+	if err != nil {
+		slog.Error("Failed to update issue trackers", "repo_id", repoID, "error", err)
+		utils.WriteError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, utils.MapIssueTrackers(updated))
+}
+
+func (h *ApplicationHandler) GetIssueSubcommitsQuery(w http.ResponseWriter, r *http.Request) {
+	repoIDStr := r.PathValue("repoId")
+	repoID, err := strconv.ParseInt(repoIDStr, 10, 64)
+	if err != nil {
+		slog.Warn("Invalid repoId in issue subcommits request", "repo_id_raw", repoIDStr, "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid repo_id"})
+		return
+	}
+
+	// "#" and "/" in references arrive escaped as %23 and %2F
+	ref := r.PathValue("ref")
+	if strings.TrimSpace(ref) == "" {
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid issue reference"})
+		return
+	}
+
+	slog.Info("Fetching subcommits of issue", "repo_id", repoID, "ref", ref)
+
+	token := utils.AccessTokenFromContext(r.Context())
+	result, err := h.application.Queries.GetIssueSubcommits.Handle(r.Context(), query.GetIssueSubcommits{
+		RepoID:      repoID,
+		Ref:         ref,
+		AccessToken: token,
+	})
+	if err != nil {
+		slog.Error("Failed to fetch subcommits of issue", "repo_id", repoID, "ref", ref, "error", err)
+		utils.WriteError(w, err)
+		return
+	}
+
+	slog.Info("Subcommits of issue fetched", "repo_id", repoID, "ref", result.Ref, "count", len(result.Subcommits))
+
+	utils.WriteJSON(w, http.StatusOK, map[string]any{
+		"ref":        result.Ref,
+		"subcommits": utils.MapSubcommits(result.Subcommits),
+		"repoId":     repoIDStr,
+		"repoUrl":    result.RepoURL,
+	})
+}
+
+const analysisEventsHeartbeatInterval = 15 * time.Second
+
+func (h *ApplicationHandler) StreamAnalysisEvents(w http.ResponseWriter, r *http.Request) {
+	repoIDStr := r.PathValue("repoId")
+	repoID, err := strconv.ParseInt(repoIDStr, 10, 64)
+	if err != nil {
+		slog.Warn("Invalid repoId in analysis events request", "repo_id_raw", repoIDStr, "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid repo_id"})
+		return
+	}
+
+	token := utils.AccessTokenFromContext(r.Context())
+	events, unsubscribe, err := h.application.Queries.WatchAnalysisProgress.Handle(r.Context(), query.WatchAnalysisProgress{
+		RepoID:      repoID,
+		AccessToken: token,
+	})
+	if err != nil {
+		slog.Error("Failed to subscribe to analysis events", "repo_id", repoID, "error", err)
+		utils.WriteError(w, err)
+		return
+	}
+	defer unsubscribe()
+
+	slog.Info("Streaming analysis events", "repo_id", repoID)
+
+	rc := utils.StartSSE(w)
+	heartbeat := time.NewTicker(analysisEventsHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			slog.Info("Analysis events client disconnected", "repo_id", repoID)
+			return
+		case <-heartbeat.C:
+			if err := utils.WriteSSEHeartbeat(w, rc); err != nil {
+				slog.Warn("Failed to write analysis events heartbeat", "repo_id", repoID, "error", err)
+				return
+			}
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if err := utils.WriteSSE(w, rc, string(event.Type), utils.MapAnalysisEvent(event)); err != nil {
+				slog.Warn("Failed to write analysis event", "repo_id", repoID, "type", event.Type, "error", err)
+				return
+			}
+		}
+	}
+}
@@ -3,8 +3,10 @@ package auth
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"encoding/json"
 	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/octokerbs/chronocode/internal/ports/http/utils"
@@ -89,10 +91,46 @@ func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
 		Path:   "/",
 		MaxAge: -1,
 	})
+	utils.SetHostTokensCookie(w, nil)
 	slog.Info("User logged out", "remote_addr", r.RemoteAddr)
 	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "logged out"})
 }
 
+// SetHostToken
+// Stores the caller's own token for a code host other than the one they signed in
+// with, such as a GitLab personal access token. Repositories on that host are reached
+// with it instead of the sign-in token, which is never sent there.
+func (h *Handler) SetHostToken(w http.ResponseWriter, r *http.Request) {
+	host := strings.ToLower(r.PathValue("host"))
+	var body struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || strings.TrimSpace(body.Token) == "" || host == "" {
+		slog.Warn("Set host token request failed - invalid request body", "host", host, "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	tokens := utils.HostTokensFromRequest(r)
+	tokens[host] = strings.TrimSpace(body.Token)
+	utils.SetHostTokensCookie(w, tokens)
+
+	slog.Info("Host token stored", "host", host, "remote_addr", r.RemoteAddr)
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "token stored"})
+}
+
+// ClearHostToken
+// Forgets the caller's token for a code host
+func (h *Handler) ClearHostToken(w http.ResponseWriter, r *http.Request) {
+	host := strings.ToLower(r.PathValue("host"))
+	tokens := utils.HostTokensFromRequest(r)
+	delete(tokens, host)
+	utils.SetHostTokensCookie(w, tokens)
+
+	slog.Info("Host token cleared", "host", host, "remote_addr", r.RemoteAddr)
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "token cleared"})
+}
+
 func generateState() string {
 	b := make([]byte, 16)
 	rand.Read(b)
@@ -0,0 +1,16 @@
+package model
+
+type AnalysisEventJSON struct {
+	Type            string  `json:"type"`
+	RepoID          string  `json:"repoId"`
+	RunID           int64   `json:"runId"`
+	CommitSHA       string  `json:"commitSha,omitempty"`
+	CommitsTotal    int64   `json:"commitsTotal"`
+	CommitsAnalyzed int64   `json:"commitsAnalyzed"`
+	CommitsSkipped  int64   `json:"commitsSkipped"`
+	CommitsFailed   int64   `json:"commitsFailed"`
+	CommitsCached   int64   `json:"commitsCached"`
+	CacheHitRate    float64 `json:"cacheHitRate"`
+	Error           string  `json:"error,omitempty"`
+	OccurredAt      string  `json:"occurredAt"`
+}
@@ -0,0 +1,20 @@
+package model
+
+type AnalysisRunJSON struct {
+	ID              int64   `json:"id"`
+	RepoID          string  `json:"repoId"`
+	Status          string  `json:"status"`
+	HeadSHA         string  `json:"headSha"`
+	CommitsTotal    int64   `json:"commitsTotal"`
+	CommitsAnalyzed int64   `json:"commitsAnalyzed"`
+	CommitsSkipped  int64   `json:"commitsSkipped"`
+	CommitsFailed   int64   `json:"commitsFailed"`
+	CommitsCached   int64   `json:"commitsCached"`
+	CacheHitRate    float64 `json:"cacheHitRate"`
+	PromptTokens    int64   `json:"promptTokens"`
+	ResponseTokens  int64   `json:"responseTokens"`
+	CostUSD         float64 `json:"costUsd"`
+	Error           string  `json:"error"`
+	StartedAt       string  `json:"startedAt"`
+	FinishedAt      string  `json:"finishedAt,omitempty"`
+}
@@ -6,3 +6,60 @@ type RepoJSON struct {
 	URL     string `json:"url"`
 	AddedAt string `json:"addedAt"`
 }
+
+type IgnorePatternsJSON struct {
+	RepoID   string   `json:"repoId"`
+	Patterns []string `json:"patterns"`
+	Custom   bool     `json:"custom"`
+}
+
+// BudgetJSON
+// Zero limits are unlimited
+type BudgetJSON struct {
+	RepoID     string  `json:"repoId"`
+	MaxTokens  int64   `json:"maxTokens"`
+	MaxCostUSD float64 `json:"maxCostUsd"`
+}
+
+// PromptOverridesJSON
+// Empty fields leave the corresponding part of the default prompt untouched
+type PromptOverridesJSON struct {
+	RepoID       string `json:"repoId"`
+	Glossary     string `json:"glossary"`
+	TitleStyle   string `json:"titleStyle"`
+	Instructions string `json:"instructions"`
+}
+
+// ModificationTypesJSON
+// The taxonomy subcommits of the repository are classified with, the defaults unless
+// Custom
+type ModificationTypesJSON struct {
+	RepoID            string   `json:"repoId"`
+	ModificationTypes []string `json:"modificationTypes"`
+	Custom            bool     `json:"custom"`
+}
+
+// IssueTrackersJSON
+// The trackers issue references of the repository are resolved with, the defaults
+// unless Custom
+type IssueTrackersJSON struct {
+	RepoID        string             `json:"repoId"`
+	IssueTrackers []IssueTrackerJSON `json:"issueTrackers"`
+	Custom        bool               `json:"custom"`
+}
+
+type IssueTrackerJSON struct {
+	Pattern     string `json:"pattern"`
+	URLTemplate string `json:"urlTemplate"`
+}
+
+type UsageJSON struct {
+	RepoID         string  `json:"repoId"`
+	PromptTokens   int64   `json:"promptTokens"`
+	ResponseTokens int64   `json:"responseTokens"`
+	TotalTokens    int64   `json:"totalTokens"`
+	CostUSD        float64 `json:"costUsd"`
+	MaxTokens      int64   `json:"maxTokens"`
+	MaxCostUSD     float64 `json:"maxCostUsd"`
+	BudgetExceeded bool    `json:"budgetExceeded"`
+}
@@ -1,13 +1,60 @@
 package model
 
 type SubcommitJSON struct {
-	ID          int64    `json:"id"`
-	CreatedAt   string   `json:"createdAt"`
-	Title       string   `json:"title"`
-	Idea        string   `json:"idea"`
-	Description string   `json:"description"`
-	CommitSHA   string   `json:"commitSha"`
-	Type        string   `json:"type"`
-	Epic        string   `json:"epic"`
-	Files       []string `json:"files"`
+	ID            int64            `json:"id"`
+	CreatedAt     string           `json:"createdAt"`
+	Title         string           `json:"title"`
+	Idea          string           `json:"idea"`
+	Description   string           `json:"description"`
+	CommitSHA     string           `json:"commitSha"`
+	Type          string           `json:"type"`
+	Epic          string           `json:"epic"`
+	Files         []string         `json:"files"`
+	Agent         string           `json:"agent,omitempty"`
+	PromptVersion string           `json:"promptVersion,omitempty"`
+	Author        *AuthorJSON      `json:"author,omitempty"`
+	FileStats     []FileStatJSON   `json:"fileStats"`
+	Additions     int              `json:"additions"`
+	Deletions     int              `json:"deletions"`
+	Churn         int              `json:"churn"`
+	PullRequest   *PullRequestJSON `json:"pullRequest,omitempty"`
+	Issues        []IssueJSON      `json:"issues"`
+}
+
+type IssueJSON struct {
+	Ref    string `json:"ref"`
+	URL    string `json:"url,omitempty"`
+	Closes bool   `json:"closes"`
+}
+
+type FileStatJSON struct {
+	Path         string `json:"path"`
+	PreviousPath string `json:"previousPath,omitempty"`
+	Status       string `json:"status"`
+	Additions    int    `json:"additions"`
+	Deletions    int    `json:"deletions"`
+}
+
+type AuthorJSON struct {
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	Login     string `json:"login,omitempty"`
+	AvatarURL string `json:"avatarUrl,omitempty"`
+}
+
+type PullRequestJSON struct {
+	Number   int    `json:"number"`
+	Title    string `json:"title"`
+	URL      string `json:"url"`
+	MergedAt string `json:"mergedAt,omitempty"`
+}
+
+type PullRequestGroupJSON struct {
+	PullRequest *PullRequestJSON `json:"pullRequest,omitempty"`
+	Date        string           `json:"date"`
+	CommitSHAs  []string         `json:"commitShas"`
+	Subcommits  []SubcommitJSON  `json:"subcommits"`
+	Additions   int              `json:"additions"`
+	Deletions   int              `json:"deletions"`
+	Churn       int              `json:"churn"`
 }
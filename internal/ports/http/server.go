@@ -24,11 +24,35 @@ func NewServer(application application.Application, oauthConfig *oauth2.Config,
 
 	// Protected routes
 	protected := http.NewServeMux()
+	protected.HandleFunc("PUT /auth/hosts/{host}/token", authHandler.SetHostToken)
+	protected.HandleFunc("DELETE /auth/hosts/{host}/token", authHandler.ClearHostToken)
 	protected.HandleFunc("GET /user/profile", applicationHandler.GetUserProfileQuery)
 	protected.HandleFunc("GET /user/repos/search", applicationHandler.SearchReposQuery)
 	protected.HandleFunc("GET /repositories", applicationHandler.GetReposQuery)
 	protected.HandleFunc("POST /analyze", applicationHandler.AnalyzeRepoCommand)
+	protected.HandleFunc("POST /analyze/{repoId}/cancel", applicationHandler.CancelAnalysisCommand)
+	protected.HandleFunc("POST /analyze/{repoId}/retry-failed", applicationHandler.RetryFailedCommitsCommand)
 	protected.HandleFunc("GET /subcommits-timeline", applicationHandler.GetSubcommitsQuery)
+	protected.HandleFunc("GET /repositories/{repoId}/pull-requests", applicationHandler.GetPullRequestTimelineQuery)
+	protected.HandleFunc("GET /analyses", applicationHandler.GetAnalysisRunsQuery)
+	protected.HandleFunc("GET /analyses/{id}", applicationHandler.GetAnalysisRunQuery)
+	protected.HandleFunc("GET /analyses/{repoId}/events", applicationHandler.StreamAnalysisEvents)
+	protected.HandleFunc("GET /repositories/{repoId}/ignore-patterns", applicationHandler.GetIgnorePatternsQuery)
+	protected.HandleFunc("PUT /repositories/{repoId}/ignore-patterns", applicationHandler.SetIgnorePatternsCommand)
+	protected.HandleFunc("DELETE /repositories/{repoId}/ignore-patterns", applicationHandler.ResetIgnorePatternsCommand)
+	protected.HandleFunc("GET /repositories/{repoId}/usage", applicationHandler.GetUsageQuery)
+	protected.HandleFunc("PUT /repositories/{repoId}/budget", applicationHandler.SetBudgetCommand)
+	protected.HandleFunc("DELETE /repositories/{repoId}/budget", applicationHandler.ResetBudgetCommand)
+	protected.HandleFunc("GET /repositories/{repoId}/prompt", applicationHandler.GetPromptOverridesQuery)
+	protected.HandleFunc("PUT /repositories/{repoId}/prompt", applicationHandler.SetPromptOverridesCommand)
+	protected.HandleFunc("DELETE /repositories/{repoId}/prompt", applicationHandler.ResetPromptOverridesCommand)
+	protected.HandleFunc("GET /repositories/{repoId}/modification-types", applicationHandler.GetModificationTypesQuery)
+	protected.HandleFunc("PUT /repositories/{repoId}/modification-types", applicationHandler.SetModificationTypesCommand)
+	protected.HandleFunc("DELETE /repositories/{repoId}/modification-types", applicationHandler.ResetModificationTypesCommand)
+	protected.HandleFunc("GET /repositories/{repoId}/issue-trackers", applicationHandler.GetIssueTrackersQuery)
+	protected.HandleFunc("PUT /repositories/{repoId}/issue-trackers", applicationHandler.SetIssueTrackersCommand)
+	protected.HandleFunc("DELETE /repositories/{repoId}/issue-trackers", applicationHandler.ResetIssueTrackersCommand)
+	protected.HandleFunc("GET /repositories/{repoId}/issues/{ref}/subcommits", applicationHandler.GetIssueSubcommitsQuery)
 
 	mux.Handle("/", utils.AuthMiddleware(protected))
 
@@ -36,7 +60,16 @@ func NewServer(application application.Application, oauthConfig *oauth2.Config,
 
 	slog.Info("HTTP server configured", "port", port, "frontend_url", frontendURL, "routes", []string{
 		"GET /auth/status", "GET /auth/github/login", "GET /auth/github/callback", "POST /auth/logout",
-		"GET /user/profile", "GET /user/repos/search", "GET /repositories", "POST /analyze", "GET /subcommits-timeline",
+		"PUT /auth/hosts/{host}/token", "DELETE /auth/hosts/{host}/token",
+		"GET /user/profile", "GET /user/repos/search", "GET /repositories", "POST /analyze", "POST /analyze/{repoId}/cancel", "POST /analyze/{repoId}/retry-failed", "GET /subcommits-timeline",
+		"GET /repositories/{repoId}/pull-requests",
+		"GET /analyses", "GET /analyses/{id}", "GET /analyses/{repoId}/events",
+		"GET /repositories/{repoId}/ignore-patterns", "PUT /repositories/{repoId}/ignore-patterns", "DELETE /repositories/{repoId}/ignore-patterns",
+		"GET /repositories/{repoId}/usage", "PUT /repositories/{repoId}/budget", "DELETE /repositories/{repoId}/budget",
+		"GET /repositories/{repoId}/prompt", "PUT /repositories/{repoId}/prompt", "DELETE /repositories/{repoId}/prompt",
+		"GET /repositories/{repoId}/modification-types", "PUT /repositories/{repoId}/modification-types", "DELETE /repositories/{repoId}/modification-types",
+		"GET /repositories/{repoId}/issue-trackers", "PUT /repositories/{repoId}/issue-trackers", "DELETE /repositories/{repoId}/issue-trackers",
+		"GET /repositories/{repoId}/issues/{ref}/subcommits",
 	})
 
 	return &http.Server{
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"time"
+
+	"github.com/octokerbs/chronocode/internal/domain/analysis"
+	"github.com/octokerbs/chronocode/internal/ports/http/model"
+)
+
+func MapAnalysisRun(run *analysis.Run) model.AnalysisRunJSON {
+	counts := run.Counts()
+	usage := run.Usage()
+	result := model.AnalysisRunJSON{
+		ID:              run.ID(),
+		RepoID:          FormatInt64(run.RepoID()),
+		Status:          string(run.Status()),
+		HeadSHA:         run.HeadSHA(),
+		CommitsTotal:    counts.Total,
+		CommitsAnalyzed: counts.Analyzed,
+		CommitsSkipped:  counts.Skipped,
+		CommitsFailed:   counts.Failed,
+		CommitsCached:   counts.Cached,
+		CacheHitRate:    counts.CacheHitRate(),
+		PromptTokens:    usage.PromptTokens,
+		ResponseTokens:  usage.ResponseTokens,
+		CostUSD:         usage.CostUSD,
+		Error:           run.Error(),
+		StartedAt:       run.StartedAt().Format(time.RFC3339),
+	}
+	if !run.FinishedAt().IsZero() {
+		result.FinishedAt = run.FinishedAt().Format(time.RFC3339)
+	}
+	return result
+}
+
+func MapAnalysisRuns(runs []*analysis.Run) []model.AnalysisRunJSON {
+	result := make([]model.AnalysisRunJSON, len(runs))
+	for i, run := range runs {
+		result[i] = MapAnalysisRun(run)
+	}
+	return result
+}
+
+func MapAnalysisEvent(event analysis.ProgressEvent) model.AnalysisEventJSON {
+	return model.AnalysisEventJSON{
+		Type:            string(event.Type),
+		RepoID:          FormatInt64(event.RepoID),
+		RunID:           event.RunID,
+		CommitSHA:       event.CommitSHA,
+		CommitsTotal:    event.Counts.Total,
+		CommitsAnalyzed: event.Counts.Analyzed,
+		CommitsSkipped:  event.Counts.Skipped,
+		CommitsFailed:   event.Counts.Failed,
+		CommitsCached:   event.Counts.Cached,
+		CacheHitRate:    event.Counts.CacheHitRate(),
+		Error:           event.Error,
+		OccurredAt:      event.OccurredAt.Format(time.RFC3339Nano),
+	}
+}
@@ -2,15 +2,24 @@ package utils
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"log/slog"
 	"net/http"
+	"strings"
 	"time"
+
+	"github.com/octokerbs/chronocode/internal/domain/codehost"
 )
 
 type contextKey string
 
 const accessTokenKey contextKey = "access_token"
 
+// HostTokensCookie
+// Holds the caller's tokens for code hosts other than the one they sign in with
+const HostTokensCookie = "host_tokens"
+
 type responseRecorder struct {
 	http.ResponseWriter
 	statusCode int
@@ -21,12 +30,18 @@ func (rr *responseRecorder) WriteHeader(code int) {
 	rr.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap
+// Lets http.ResponseController reach the underlying writer, needed to flush SSE streams
+func (rr *responseRecorder) Unwrap() http.ResponseWriter {
+	return rr.ResponseWriter
+}
+
 func CORSMiddleware(frontendURL string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Access-Control-Allow-Origin", frontendURL)
 			w.Header().Set("Access-Control-Allow-Credentials", "true")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 
 			if r.Method == http.MethodOptions {
@@ -65,6 +80,7 @@ func AuthMiddleware(next http.Handler) http.Handler {
 		slog.Debug("Request authenticated via cookie", "path", r.URL.Path)
 
 		ctx := context.WithValue(r.Context(), accessTokenKey, cookie.Value)
+		ctx = codehost.WithHostTokens(ctx, HostTokensFromRequest(r))
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -73,3 +89,46 @@ func AccessTokenFromContext(ctx context.Context) string {
 	token, _ := ctx.Value(accessTokenKey).(string)
 	return token
 }
+
+// HostTokensFromRequest
+// The host tokens cookie, keyed by lowercased host. A malformed cookie counts as empty.
+func HostTokensFromRequest(r *http.Request) map[string]string {
+	tokens := make(map[string]string)
+	cookie, err := r.Cookie(HostTokensCookie)
+	if err != nil || cookie.Value == "" {
+		return tokens
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err == nil {
+		err = json.Unmarshal(raw, &tokens)
+	}
+	if err != nil {
+		slog.Warn("Ignoring malformed host tokens cookie", "error", err)
+		return make(map[string]string)
+	}
+	return tokens
+}
+
+// SetHostTokensCookie
+// Replaces the host tokens cookie, clearing it when tokens is empty
+func SetHostTokensCookie(w http.ResponseWriter, tokens map[string]string) {
+	cookie := &http.Cookie{
+		Name:     HostTokensCookie,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   int(24 * time.Hour / time.Second),
+	}
+	if len(tokens) == 0 {
+		cookie.MaxAge = -1
+	} else {
+		normalized := make(map[string]string, len(tokens))
+		for host, token := range tokens {
+			normalized[strings.ToLower(host)] = token
+		}
+		raw, _ := json.Marshal(normalized)
+		cookie.Value = base64.RawURLEncoding.EncodeToString(raw)
+	}
+	http.SetCookie(w, cookie)
+}
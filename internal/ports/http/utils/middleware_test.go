@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+const frontendURL = "http://localhost:3000"
+
+type CORSMiddlewareTestSuite struct {
+	suite.Suite
+	handler http.Handler
+	reached bool
+}
+
+func TestCORSMiddlewareTestSuite(t *testing.T) {
+	suite.Run(t, new(CORSMiddlewareTestSuite))
+}
+
+func (s *CORSMiddlewareTestSuite) SetupTest() {
+	s.reached = false
+	s.handler = CORSMiddleware(frontendURL)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.reached = true
+	}))
+}
+
+func (s *CORSMiddlewareTestSuite) TestPreflightAllowsSettingsMethods() {
+	for _, method := range []string{http.MethodPut, http.MethodDelete} {
+		req := httptest.NewRequest(http.MethodOptions, "/repositories/1/budget", nil)
+		req.Header.Set("Origin", frontendURL)
+		req.Header.Set("Access-Control-Request-Method", method)
+		rec := httptest.NewRecorder()
+		s.handler.ServeHTTP(rec, req)
+
+		allowed := strings.Split(rec.Header().Get("Access-Control-Allow-Methods"), ", ")
+		assert.Equal(s.T(), http.StatusNoContent, rec.Code)
+		assert.Contains(s.T(), allowed, method)
+		assert.Equal(s.T(), frontendURL, rec.Header().Get("Access-Control-Allow-Origin"))
+	}
+	assert.False(s.T(), s.reached)
+}
+
+func (s *CORSMiddlewareTestSuite) TestRequestsReachTheHandler() {
+	req := httptest.NewRequest(http.MethodPut, "/repositories/1/budget", nil)
+	req.Header.Set("Origin", frontendURL)
+	s.handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.True(s.T(), s.reached)
+}
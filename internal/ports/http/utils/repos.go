@@ -4,6 +4,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/octokerbs/chronocode/internal/domain/agent"
 	"github.com/octokerbs/chronocode/internal/domain/repo"
 	"github.com/octokerbs/chronocode/internal/ports/http/model"
 )
@@ -24,3 +25,63 @@ func MapRepos(repos []*repo.Repo) []model.RepoJSON {
 	}
 	return result
 }
+
+func MapIgnorePatterns(r *repo.Repo) model.IgnorePatternsJSON {
+	return model.IgnorePatternsJSON{
+		RepoID:   FormatInt64(r.ID()),
+		Patterns: r.IgnorePatterns(),
+		Custom:   r.HasCustomIgnorePatterns(),
+	}
+}
+
+func MapBudget(r *repo.Repo) model.BudgetJSON {
+	return model.BudgetJSON{
+		RepoID:     FormatInt64(r.ID()),
+		MaxTokens:  r.Budget().MaxTokens,
+		MaxCostUSD: r.Budget().MaxCostUSD,
+	}
+}
+
+func MapPromptOverrides(r *repo.Repo) model.PromptOverridesJSON {
+	overrides := r.PromptOverrides()
+	return model.PromptOverridesJSON{
+		RepoID:       FormatInt64(r.ID()),
+		Glossary:     overrides.Glossary,
+		TitleStyle:   overrides.TitleStyle,
+		Instructions: overrides.Instructions,
+	}
+}
+
+func MapModificationTypes(r *repo.Repo) model.ModificationTypesJSON {
+	return model.ModificationTypesJSON{
+		RepoID:            FormatInt64(r.ID()),
+		ModificationTypes: r.ModificationTypes(),
+		Custom:            r.HasCustomModificationTypes(),
+	}
+}
+
+func MapIssueTrackers(r *repo.Repo) model.IssueTrackersJSON {
+	trackers := make([]model.IssueTrackerJSON, len(r.IssueTrackers()))
+	for i, tracker := range r.IssueTrackers() {
+		trackers[i] = model.IssueTrackerJSON(tracker)
+	}
+
+	return model.IssueTrackersJSON{
+		RepoID:        FormatInt64(r.ID()),
+		IssueTrackers: trackers,
+		Custom:        r.HasCustomIssueTrackers(),
+	}
+}
+
+func MapUsage(r *repo.Repo, usage agent.Usage) model.UsageJSON {
+	return model.UsageJSON{
+		RepoID:         FormatInt64(r.ID()),
+		PromptTokens:   usage.PromptTokens,
+		ResponseTokens: usage.ResponseTokens,
+		TotalTokens:    usage.TotalTokens(),
+		CostUSD:        usage.CostUSD,
+		MaxTokens:      r.Budget().MaxTokens,
+		MaxCostUSD:     r.Budget().MaxCostUSD,
+		BudgetExceeded: r.Budget().ExceededBy(usage),
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"net/http"
 
+	"github.com/octokerbs/chronocode/internal/domain/agent"
 	"github.com/octokerbs/chronocode/internal/domain/analysis"
 	"github.com/octokerbs/chronocode/internal/domain/codehost"
 	"github.com/octokerbs/chronocode/internal/domain/repo"
@@ -29,10 +30,24 @@ func mapDomainError(err error) (int, string) {
 		return http.StatusForbidden, "access denied"
 	case errors.Is(err, codehost.ErrInvalidRepoURL):
 		return http.StatusBadRequest, "invalid repository URL"
+	case errors.Is(err, repo.ErrInvalidIgnorePattern), errors.Is(err, repo.ErrInvalidIssueTracker):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, agent.ErrInvalidBudget):
+		return http.StatusBadRequest, "invalid budget"
+	case errors.Is(err, agent.ErrInvalidPromptOverrides), errors.Is(err, agent.ErrInvalidModificationTypes):
+		return http.StatusBadRequest, err.Error()
 	case errors.Is(err, repo.ErrRepositoryNotFound):
 		return http.StatusNotFound, "repository not found"
 	case errors.Is(err, analysis.ErrAnalysisInProgress):
 		return http.StatusConflict, "analysis already in progress"
+	case errors.Is(err, analysis.ErrRunNotFound):
+		return http.StatusNotFound, "analysis run not found"
+	case errors.Is(err, analysis.ErrNoAnalysisRunning):
+		return http.StatusConflict, "no analysis in progress"
+	case errors.Is(err, analysis.ErrNoFailedCommits):
+		return http.StatusConflict, "no failed commits to retry"
+	case errors.Is(err, analysis.ErrBudgetExceeded):
+		return http.StatusConflict, "analysis budget exceeded"
 	default:
 		return http.StatusInternalServerError, "internal server error"
 	}
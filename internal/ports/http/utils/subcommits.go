@@ -11,16 +11,80 @@ func MapSubcommits(scs []subcommit.Subcommit) []model.SubcommitJSON {
 	result := make([]model.SubcommitJSON, len(scs))
 	for i, sc := range scs {
 		result[i] = model.SubcommitJSON{
-			ID:          sc.ID(),
-			CreatedAt:   sc.CommittedAt().Format(time.RFC3339),
-			Title:       sc.Title(),
-			Idea:        sc.Idea(),
-			Description: sc.Description(),
-			CommitSHA:   sc.CommitSHA(),
-			Type:        sc.ModificationType(),
-			Epic:        sc.Epic(),
-			Files:       sc.Files(),
+			ID:            sc.ID(),
+			CreatedAt:     sc.CommittedAt().Format(time.RFC3339),
+			Title:         sc.Title(),
+			Idea:          sc.Idea(),
+			Description:   sc.Description(),
+			CommitSHA:     sc.CommitSHA(),
+			Type:          sc.ModificationType(),
+			Epic:          sc.Epic(),
+			Files:         sc.Files(),
+			Agent:         sc.Agent(),
+			PromptVersion: sc.PromptVersion(),
+			Author:        mapAuthor(sc.Author()),
+			FileStats:     mapFileStats(sc.FileStats()),
+			Additions:     sc.Additions(),
+			Deletions:     sc.Deletions(),
+			Churn:         sc.Churn(),
+			PullRequest:   mapPullRequest(sc.PullRequest()),
+			Issues:        mapIssues(sc.Issues()),
 		}
 	}
 	return result
 }
+
+// mapAuthor
+// nil for subcommits stored before authors were recorded
+func mapAuthor(author subcommit.Author) *model.AuthorJSON {
+	if author == (subcommit.Author{}) {
+		return nil
+	}
+	return &model.AuthorJSON{Name: author.Name, Email: author.Email, Login: author.Login, AvatarURL: author.AvatarURL}
+}
+
+func mapFileStats(stats []subcommit.FileStat) []model.FileStatJSON {
+	result := make([]model.FileStatJSON, len(stats))
+	for i, stat := range stats {
+		result[i] = model.FileStatJSON{Path: stat.Path, PreviousPath: stat.PreviousPath, Status: stat.Status, Additions: stat.Additions, Deletions: stat.Deletions}
+	}
+	return result
+}
+
+func mapIssues(issues []subcommit.Issue) []model.IssueJSON {
+	result := make([]model.IssueJSON, len(issues))
+	for i, issue := range issues {
+		result[i] = model.IssueJSON(issue)
+	}
+	return result
+}
+
+func MapPullRequestGroups(groups []subcommit.PullRequestGroup) []model.PullRequestGroupJSON {
+	result := make([]model.PullRequestGroupJSON, len(groups))
+	for i, group := range groups {
+		result[i] = model.PullRequestGroupJSON{
+			PullRequest: mapPullRequest(group.PullRequest),
+			Date:        group.Date().Format(time.RFC3339),
+			CommitSHAs:  group.CommitSHAs,
+			Subcommits:  MapSubcommits(group.Subcommits),
+			Additions:   group.Additions(),
+			Deletions:   group.Deletions(),
+			Churn:       group.Churn(),
+		}
+	}
+	return result
+}
+
+// mapPullRequest
+// nil for commits pushed directly, and for subcommits stored before pull requests were recorded
+func mapPullRequest(pr *subcommit.PullRequest) *model.PullRequestJSON {
+	if pr == nil {
+		return nil
+	}
+
+	result := &model.PullRequestJSON{Number: pr.Number, Title: pr.Title, URL: pr.URL}
+	if !pr.MergedAt.IsZero() {
+		result.MergedAt = pr.MergedAt.Format(time.RFC3339)
+	}
+	return result
+}
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS analysis_run (
+    id               BIGSERIAL PRIMARY KEY,
+    repo_id          BIGINT NOT NULL REFERENCES repository(id),
+    status           TEXT NOT NULL,
+    head_sha         TEXT NOT NULL DEFAULT '',
+    commits_total    BIGINT NOT NULL DEFAULT 0,
+    commits_analyzed BIGINT NOT NULL DEFAULT 0,
+    commits_skipped  BIGINT NOT NULL DEFAULT 0,
+    commits_failed   BIGINT NOT NULL DEFAULT 0,
+    error            TEXT NOT NULL DEFAULT '',
+    started_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at      TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_analysis_run_repo_started ON analysis_run(repo_id, started_at DESC);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS commit_analysis (
+    repo_id      BIGINT NOT NULL REFERENCES repository(id),
+    commit_sha   TEXT NOT NULL,
+    committed_at TIMESTAMPTZ NOT NULL,
+    status       TEXT NOT NULL,
+    attempts     INT NOT NULL DEFAULT 0,
+    last_error   TEXT NOT NULL DEFAULT '',
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (repo_id, commit_sha)
+);
+
+CREATE INDEX IF NOT EXISTS idx_commit_analysis_repo_status ON commit_analysis(repo_id, status);
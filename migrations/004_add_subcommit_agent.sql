@@ -0,0 +1 @@
+ALTER TABLE subcommit ADD COLUMN IF NOT EXISTS agent TEXT NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+ALTER TABLE repository ADD COLUMN IF NOT EXISTS ignore_patterns TEXT[];
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS analysis_cache (
+    key        TEXT PRIMARY KEY,
+    results    JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE analysis_run ADD COLUMN IF NOT EXISTS commits_cached BIGINT NOT NULL DEFAULT 0;
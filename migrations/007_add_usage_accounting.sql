@@ -0,0 +1,13 @@
+ALTER TABLE commit_analysis
+    ADD COLUMN IF NOT EXISTS prompt_tokens   BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS response_tokens BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS cost_usd        DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+ALTER TABLE analysis_run
+    ADD COLUMN IF NOT EXISTS prompt_tokens   BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS response_tokens BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS cost_usd        DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+ALTER TABLE repository
+    ADD COLUMN IF NOT EXISTS budget_tokens   BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS budget_cost_usd DOUBLE PRECISION NOT NULL DEFAULT 0;
@@ -0,0 +1,6 @@
+ALTER TABLE subcommit ADD COLUMN IF NOT EXISTS prompt_version TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE repository
+    ADD COLUMN IF NOT EXISTS prompt_glossary     TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS prompt_title_style  TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS prompt_instructions TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,5 @@
+ALTER TABLE subcommit
+    ADD COLUMN IF NOT EXISTS author_name       TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS author_email      TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS author_login      TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS author_avatar_url TEXT NOT NULL DEFAULT '';
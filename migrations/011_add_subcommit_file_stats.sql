@@ -0,0 +1 @@
+ALTER TABLE subcommit ADD COLUMN IF NOT EXISTS file_stats JSONB NOT NULL DEFAULT '[]';
@@ -0,0 +1,7 @@
+ALTER TABLE subcommit
+    ADD COLUMN IF NOT EXISTS pr_number    INTEGER,
+    ADD COLUMN IF NOT EXISTS pr_title     TEXT,
+    ADD COLUMN IF NOT EXISTS pr_url       TEXT,
+    ADD COLUMN IF NOT EXISTS pr_merged_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_subcommit_repo_pr ON subcommit (repo_id, pr_number);
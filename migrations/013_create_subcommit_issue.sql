@@ -0,0 +1,13 @@
+ALTER TABLE repository ADD COLUMN IF NOT EXISTS issue_trackers JSONB;
+
+CREATE TABLE IF NOT EXISTS subcommit_issue (
+    subcommit_id BIGINT NOT NULL REFERENCES subcommit(id) ON DELETE CASCADE,
+    repo_id      BIGINT NOT NULL REFERENCES repository(id),
+    ref          TEXT NOT NULL,
+    url          TEXT NOT NULL DEFAULT '',
+    closes       BOOLEAN NOT NULL DEFAULT FALSE,
+    position     INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (subcommit_id, ref)
+);
+
+CREATE INDEX IF NOT EXISTS idx_subcommit_issue_repo_ref ON subcommit_issue(repo_id, ref);